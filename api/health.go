@@ -41,13 +41,30 @@ func (h *HealthChecker) health(ctx context.Context) map[string]interface{} {
 		oracleSlaveStatus = FAILED
 	}
 
+	status := map[string]string{
+		"application":    applicationStatus,
+		"oracleMasterDB": oracleMasterStatus,
+		"oracleSlaveDB":  oracleSlaveStatus,
+	}
+	if db, ok := h.Master.(sql.BreakerDB); ok {
+		status["oracleMasterBreaker"] = db.Breaker().State().String()
+	}
+	if db, ok := h.Slave.(sql.BreakerDB); ok {
+		status["oracleSlaveBreaker"] = db.Breaker().State().String()
+	}
+	if balancer, ok := h.Slave.(*sql.Balancer); ok {
+		for _, replica := range balancer.Replicas() {
+			replicaStatus := OK
+			if !replica.Healthy {
+				replicaStatus = FAILED
+			}
+			status["oracleSlaveReplica "+replica.Name] = replicaStatus
+		}
+	}
+
 	resp := map[string]interface{}{
-		"name": os.Args[0],
-		"status": map[string]string{
-			"application":    applicationStatus,
-			"oracleMasterDB": oracleMasterStatus,
-			"oracleSlaveDB":  oracleSlaveStatus,
-		},
+		"name":   os.Args[0],
+		"status": status,
 	}
 
 	return resp
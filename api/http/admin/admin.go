@@ -0,0 +1,25 @@
+package admin
+
+import (
+	"net/http"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/response"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+// GetSlowQueries : HTTP Handler for Get Slow Queries
+// @Summary Get Slow Queries
+// @Description GetSlowQueries returns the last statements slower than SLOW_QUERY_THRESHOLD, the latest first
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Success 200 {object} response.Response{data=[]service.SlowQuery} "Success Response"
+// @Failure 401 "Unauthorized"
+// @Router /admin/slow-queries [GET]
+// GetSlowQueries
+func GetSlowQueries(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response{}
+	defer resp.Render(w, r)
+
+	resp.Data = service.SlowQueries()
+}
@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"oracle.com/oracle/my-go-oracle-app/api"
+	"oracle.com/oracle/my-go-oracle-app/pkg/helpers"
+	"oracle.com/oracle/my-go-oracle-app/pkg/response"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+var (
+	backfillService api.BackfillService
+)
+
+func Init(backfills api.BackfillService) {
+	backfillService = backfills
+}
+
+// GetBackfills : HTTP Handler for Get Backfills
+// @Summary Get Backfills
+// @Description GetBackfills returns the progress of the registered backfills
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Success 200 {object} response.Response{data=[]service.BackfillProgress} "Success Response"
+// @Failure 401 "Unauthorized"
+// @Router /admin/backfills [GET]
+// GetBackfills
+func GetBackfills(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response{}
+	defer resp.Render(w, r)
+
+	resp.Data = backfillService.Progress()
+}
+
+// StartBackfill : HTTP Handler for Start Backfill
+// @Summary Start Backfill
+// @Description StartBackfill runs a backfill from its last checkpoint or resumes it when paused. In dry-run mode every chunk is rolled back and no checkpoint is recorded.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Param name path string true "name of the Backfill"
+// @Param dryRun query bool false "roll back every chunk"
+// @Success 200 {object} response.Response{data=service.BackfillProgress} "Success Response"
+// @Failure 400 "Bad Request"
+// @Failure 404 "Not Found"
+// @Failure 409 "Backfill already running"
+// @Failure 401 "Unauthorized"
+// @Router /admin/backfills/{name}/start [POST]
+// StartBackfill
+func StartBackfill(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response{}
+	defer resp.Render(w, r)
+
+	var dryRun bool
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			slog.WarnContext(r.Context(), fmt.Sprintf("Parse dryRun Failed. %v", err))
+			resp.SetError(err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	name := helpers.GetUrlPathString(r, "name")
+	progress, err := backfillService.Start(name, dryRun)
+	setBackfill(r, &resp, progress, err)
+}
+
+// PauseBackfill : HTTP Handler for Pause Backfill
+// @Summary Pause Backfill
+// @Description PauseBackfill suspends a running backfill once its current chunk is committed
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Param name path string true "name of the Backfill"
+// @Success 200 {object} response.Response{data=service.BackfillProgress} "Success Response"
+// @Failure 404 "Not Found"
+// @Failure 409 "Backfill not running"
+// @Failure 401 "Unauthorized"
+// @Router /admin/backfills/{name}/pause [POST]
+// PauseBackfill
+func PauseBackfill(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response{}
+	defer resp.Render(w, r)
+
+	progress, err := backfillService.Pause(helpers.GetUrlPathString(r, "name"))
+	setBackfill(r, &resp, progress, err)
+}
+
+// CancelBackfill : HTTP Handler for Cancel Backfill
+// @Summary Cancel Backfill
+// @Description CancelBackfill stops a running or paused backfill, rolling back its current chunk
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Param name path string true "name of the Backfill"
+// @Success 200 {object} response.Response{data=service.BackfillProgress} "Success Response"
+// @Failure 404 "Not Found"
+// @Failure 409 "Backfill not running"
+// @Failure 401 "Unauthorized"
+// @Router /admin/backfills/{name}/cancel [POST]
+// CancelBackfill
+func CancelBackfill(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response{}
+	defer resp.Render(w, r)
+
+	progress, err := backfillService.Cancel(helpers.GetUrlPathString(r, "name"))
+	setBackfill(r, &resp, progress, err)
+}
+
+func setBackfill(r *http.Request, resp *response.Response, progress service.BackfillProgress, err error) {
+	switch {
+	case errors.Is(err, service.ErrBackfillNotFound):
+		slog.WarnContext(r.Context(), fmt.Sprintf("Not Found. err=%v", err))
+		resp.SetError(err, http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrBackfillState):
+		slog.WarnContext(r.Context(), fmt.Sprintf("backfill conflict: %v", err))
+		resp.SetError(err, http.StatusConflict)
+		resp.Data = progress
+		return
+	case err != nil:
+		resp.SetError(err, http.StatusInternalServerError)
+		return
+	}
+	resp.Data = progress
+}
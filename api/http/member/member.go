@@ -2,6 +2,7 @@ package member
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -30,16 +31,27 @@ func Init(service api.MemberService) {
 }
 
 var variableFilterMapping = map[string]service.FilterParam{
-	"name":        {Field: "M.NAME", Operand: constants.LIKE},
-	"address":     {Field: "JSON_VALUE(INFO, '$.address')", Operand: constants.LIKE},
-	"ageStart":    {Field: "JSON_VALUE(INFO, '$.age')", Operand: constants.GREATER_THAN_EQUAL},
-	"ageEnd":      {Field: "JSON_VALUE(INFO, '$.age')", Operand: constants.LESS_THAN_EQUAL},
-	"salaryStart": {Field: "JSON_VALUE(INFO, '$.salary')", Operand: constants.GREATER_THAN_EQUAL},
-	"salaryEnd":   {Field: "JSON_VALUE(INFO, '$.salary')", Operand: constants.LESS_THAN_EQUAL},
-	"category":    {Field: "JSON_VALUE(DETAIL, '$.category')", Operand: constants.LIKE},
-	"level":       {Field: "JSON_VALUE(DETAIL, '$.level')", Operand: constants.LIKE},
+	"name":            {Field: "M.NAME", Operand: constants.LIKE},
+	"address":         {Field: "JSON_VALUE(INFO, '$.address')", Operand: constants.LIKE},
+	"ageStart":        {Field: "JSON_VALUE(INFO, '$.age')", Operand: constants.GREATER_THAN_EQUAL},
+	"ageEnd":          {Field: "JSON_VALUE(INFO, '$.age')", Operand: constants.LESS_THAN_EQUAL},
+	"salaryStart":     {Field: "JSON_VALUE(INFO, '$.salary')", Operand: constants.GREATER_THAN_EQUAL},
+	"salaryEnd":       {Field: "JSON_VALUE(INFO, '$.salary')", Operand: constants.LESS_THAN_EQUAL},
+	"memberId":        {Field: "JSON_VALUE(DETAIL, '$.memberId')", Operand: constants.LIKE},
+	"onboardingStage": {Field: "JSON_VALUE(DETAIL, '$.onboardingStage')", Operand: constants.LIKE},
+	"riskRating":      {Field: "JSON_VALUE(DETAIL, '$.riskRating')", Operand: constants.LIKE},
+	// INFO is searched through its CONTEXT index MEMBER_INFO_JSON_IDX
+	"info":                {Field: "INFO", Operand: constants.CONTAINS},
+	"excludeInfo":         {Field: "INFO", Operand: constants.NOT_CONTAINS},
+	"dataCategory":        {Field: "POLICY", Operand: constants.CONTAINS, Path: "$.dataCategories"},
+	"excludeDataCategory": {Field: "POLICY", Operand: constants.NOT_CONTAINS, Path: "$.dataCategories"},
 }
 
+// variableDateFilters are the date ranges members can be filtered on, read in Jakarta time
+var variableDateFilters = service.NewDateFilters(
+	service.DateFilter{Name: "created", Field: "M.CREATED_DATE", StartKey: "createdStart", EndKey: "createdEnd", Location: constants.JAKARTA_LOCATION},
+)
+
 var variableOrderMapping = map[string]string{
 	"name": "M.NAME",
 	"id":   "M.ID",
@@ -53,8 +65,10 @@ var variableOrderMapping = map[string]string{
 // @Produce json
 // @Param Accept-Language header string true "accept language" default(id)
 // @Param id path string true "id of Member"
+// @Param includeDeleted query bool false "also return a soft-deleted member"
 // @Success 200 {object} response.Response{data=entity.MemberResponse} "Success Response"
 // @Failure 400 "Bad Request"
+// @Failure 404 "Not Found"
 // @Failure 500 "InternalServerError"
 // @Router /members/{id} [GET]
 // GetMemberById
@@ -74,9 +88,9 @@ func GetMemberById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err = memberService.FindById(r.Context(), id)
+	result, err = memberService.FindById(r.Context(), id, servicehelper.IsIncludeDeleted(r))
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			slog.WarnContext(r.Context(), fmt.Sprintf("Not Found. err=%v", err), slog.Int64("id", id))
 			resp.SetError(fmt.Errorf("DATA_NOT_EXIST"), http.StatusNotFound)
 			return
@@ -98,16 +112,25 @@ func GetMemberById(w http.ResponseWriter, r *http.Request) {
 // @Param Accept-Language header string true "accept language" default(id)
 // @Param limit query string false "limit data"
 // @Param page query integer false "page data"
+// @Param cursor query string false "keyset cursor, empty for the first page then pagination.nextCursor"
 // @Param name query string false "name filter"
 // @Param address query string false "address filter"
 // @Param ageStart query int false "ageStart filter"
 // @Param ageEnd query int false "ageEnd filter"
 // @Param salaryStart query string false "salaryStart filter"
 // @Param salaryEnd query string false "salaryEnd filter"
-// @Param category query string false "category filter"
-// @Param level query string false "level filter"
+// @Param memberId query string false "memberId filter"
+// @Param onboardingStage query string false "onboardingStage filter"
+// @Param riskRating query string false "riskRating filter"
+// @Param info query string false "members whose info contains the text"
+// @Param excludeInfo query string false "members whose info does not contain the text"
+// @Param dataCategory query string false "members whose policy data categories contain the value"
+// @Param excludeDataCategory query string false "members whose policy data categories do not contain the value"
+// @Param createdStart query string false "members created from, yyyy-mm-dd or yyyy-mm-dd hh:mm:ss in Jakarta time"
+// @Param createdEnd query string false "members created until, a date alone covering its whole day"
 // @Param orderBy query string false "orderBy order by"
 // @Param orderType query string false "orderType asc/desc"
+// @Param includeDeleted query bool false "also return soft-deleted members"
 // @Success 200 {object} response.Response{data=[]entity.MemberResponse} "Success Response"
 // @Failure 400 "Bad Request"
 // @Failure 500 "InternalServerError"
@@ -117,7 +140,20 @@ func GetAllMembers(w http.ResponseWriter, r *http.Request) {
 	resp := response.Response{}
 	defer resp.Render(w, r)
 
-	params := servicehelper.GelSqlParameterFromRequest(r, variableFilterMapping, variableOrderMapping)
+	params, err := servicehelper.GelSqlParameterFromRequest(r, variableFilterMapping, variableOrderMapping, variableDateFilters)
+	if err != nil {
+		slog.WarnContext(r.Context(), fmt.Sprintf(ErrParseUrlParamMsg, err))
+		resp.SetError(err, http.StatusBadRequest)
+		return
+	}
+
+	keyset, err := servicehelper.GetKeysetFromRequest(r, variableOrderMapping["id"], variableOrderMapping)
+	if err != nil {
+		slog.WarnContext(r.Context(), fmt.Sprintf(ErrParseUrlParamMsg, err))
+		resp.SetError(err, http.StatusBadRequest)
+		return
+	}
+	params.Keyset = keyset
 
 	result, page, err := memberService.FindAll(r.Context(), params)
 	if err != nil {
@@ -178,9 +214,11 @@ func CreateMember(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param Accept-Language header string true "accept language" default(id)
 // @Param id path string true "id of Member"
-// @Param member body entity.MemberRequest true "Member Request Body"
+// @Param member body entity.MemberRequest true "Member Request Body, version is mandatory"
 // @Success 200 {object} response.Response{data=entity.MemberResponse} "Success Response"
 // @Failure 400 "Bad Request"
+// @Failure 404 "Not Found"
+// @Failure 409 {object} response.Response{data=service.VersionConflictResponse} "Member was updated by someone else"
 // @Failure 500 "InternalServerError"
 // @Router /members/{id} [PUT]
 // UpdateMember
@@ -209,6 +247,23 @@ func UpdateMember(w http.ResponseWriter, r *http.Request) {
 
 	result, err := memberService.UpdateMember(r.Context(), id, &req)
 	if err != nil {
+		var conflict *service.VersionConflictError
+		switch {
+		case errors.Is(err, entity.ErrVersionRequired):
+			slog.WarnContext(r.Context(), fmt.Sprintf(ErrParseValidateMsg, err))
+			resp.SetError(err, http.StatusBadRequest)
+			return
+		case errors.Is(err, sql.ErrNoRows):
+			slog.WarnContext(r.Context(), fmt.Sprintf("Not Found. err=%v", err), slog.Int64("id", id))
+			resp.SetError(fmt.Errorf("DATA_NOT_EXIST"), http.StatusNotFound)
+			return
+		case errors.As(err, &conflict):
+			slog.WarnContext(r.Context(), fmt.Sprintf("update member conflict: %v", err), slog.Int64("id", id))
+			resp.SetError(service.ErrVersionConflict, http.StatusConflict)
+			resp.Data = service.VersionConflictResponse{CurrentVersion: conflict.CurrentVersion}
+			return
+		}
+
 		resp.SetError(err, http.StatusInternalServerError)
 		slog.WarnContext(r.Context(), fmt.Sprintf("failed to update member data: %v", err),
 			slog.Any("request", req))
@@ -221,7 +276,7 @@ func UpdateMember(w http.ResponseWriter, r *http.Request) {
 
 // DeleteMember : HTTP Handler for Delete Member
 // @Summary Delete Member
-// @Description DeleteMember handles request for deleting a member
+// @Description DeleteMember handles request for soft-deleting a member, see RestoreMember
 // @Tags Member
 // @Accept json
 // @Produce json
@@ -258,3 +313,88 @@ func DeleteMember(w http.ResponseWriter, r *http.Request) {
 	resp.Data = result
 
 }
+
+// RestoreMember : HTTP Handler for Restore Member
+// @Summary Restore Member
+// @Description RestoreMember handles request for restoring a soft-deleted member
+// @Tags Member
+// @Accept json
+// @Produce json
+// @Param Accept-Language header string true "accept language" default(id)
+// @Param id path string true "id of Member"
+// @Success 200 {object} response.Response{data=entity.MemberResponse} "Success Response"
+// @Failure 400 "Bad Request"
+// @Failure 404 "Not Found"
+// @Failure 500 "InternalServerError"
+// @Router /members/{id}/restore [POST]
+// RestoreMember
+func RestoreMember(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response{}
+	defer resp.Render(w, r)
+
+	id, err := helpers.GetUrlPathInt64(r, "id")
+	if err != nil {
+		slog.WarnContext(r.Context(), fmt.Sprintf(ErrParseUrlParamMsg, err))
+		resp.SetError(err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := memberService.RestoreMember(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.WarnContext(r.Context(), fmt.Sprintf("Not Found. err=%v", err), slog.Int64("id", id))
+			resp.SetError(fmt.Errorf("DATA_NOT_EXIST"), http.StatusNotFound)
+			return
+		}
+
+		resp.SetError(err, http.StatusInternalServerError)
+		slog.WarnContext(r.Context(), fmt.Sprintf("failed to restore member data: %v", err),
+			slog.Int64("id", id))
+		return
+	}
+
+	resp.Data = result
+}
+
+// GetMemberHistory : HTTP Handler for Get Member History
+// @Summary Get Member History
+// @Description GetMemberHistory handles request for the recorded changes of a member, latest first
+// @Tags Member
+// @Accept json
+// @Produce json
+// @Param Accept-Language header string true "accept language" default(id)
+// @Param id path string true "id of Member"
+// @Param limit query string false "limit data"
+// @Param page query integer false "page data"
+// @Success 200 {object} response.Response{data=[]service.HistoryEntry} "Success Response"
+// @Failure 400 "Bad Request"
+// @Failure 500 "InternalServerError"
+// @Router /members/{id}/history [GET]
+// GetMemberHistory
+func GetMemberHistory(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response{}
+	defer resp.Render(w, r)
+
+	id, err := helpers.GetUrlPathInt64(r, "id")
+	if err != nil {
+		slog.WarnContext(r.Context(), fmt.Sprintf(ErrParseUrlParamMsg, err))
+		resp.SetError(err, http.StatusBadRequest)
+		return
+	}
+
+	params, err := servicehelper.GelSqlParameterFromRequest(r, nil, nil, nil)
+	if err != nil {
+		slog.WarnContext(r.Context(), fmt.Sprintf(ErrParseUrlParamMsg, err))
+		resp.SetError(err, http.StatusBadRequest)
+		return
+	}
+
+	result, page, err := memberService.FindHistory(r.Context(), id, params)
+	if err != nil {
+		slog.WarnContext(r.Context(), fmt.Sprintf("failed to get member history: %v", err), slog.Int64("id", id))
+		resp.SetError(err, http.StatusInternalServerError)
+		return
+	}
+	resp.Data = result
+	resp.Pagination = page
+}
@@ -0,0 +1,70 @@
+package member_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	handler "oracle.com/oracle/my-go-oracle-app/api/http/member"
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+	"oracle.com/oracle/my-go-oracle-app/service"
+	"oracle.com/oracle/my-go-oracle-app/service/member"
+)
+
+// failingRepository fails every write with err
+type failingRepository struct {
+	member.MemberRepository
+	err error
+}
+
+func (f failingRepository) CreateMember(ctx context.Context, data *member.Member) (int64, error) {
+	return 0, f.err
+}
+
+func (f failingRepository) DeleteMember(ctx context.Context, id int64) (int64, error) {
+	return 0, f.err
+}
+
+func (f failingRepository) RestoreMember(ctx context.Context, id int64) (int64, error) {
+	return 0, f.err
+}
+
+func TestMemberWrites_DatabaseErrorStatus(t *testing.T) {
+	unavailable := &oracle.DatabaseUnavailableError{Database: "master", Retry: 30 * time.Second}
+	timeout := &service.QueryTimeoutError{Operation: "CreateMember", Timeout: time.Second,
+		Err: errors.New("ORA-01013: user requested cancel of current operation")}
+
+	r := chi.NewRouter()
+	r.Post("/members", handler.CreateMember)
+	r.Delete("/members/{id}", handler.DeleteMember)
+	r.Post("/members/{id}/restore", handler.RestoreMember)
+
+	requests := []struct {
+		method, path, body string
+	}{
+		{method: http.MethodPost, path: "/members", body: `{"name": "John"}`},
+		{method: http.MethodDelete, path: "/members/1"},
+		{method: http.MethodPost, path: "/members/1/restore"},
+	}
+	for _, req := range requests {
+		t.Run(req.method+" "+req.path, func(t *testing.T) {
+			handler.Init(member.NewMemberService(failingRepository{err: unavailable}))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(req.method, req.path, strings.NewReader(req.body)))
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+			assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+			handler.Init(member.NewMemberService(failingRepository{err: timeout}))
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(req.method, req.path, strings.NewReader(req.body)))
+			assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"log/slog"
 
 	"net/http"
 
@@ -12,8 +13,10 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	"oracle.com/oracle/my-go-oracle-app/api"
+	"oracle.com/oracle/my-go-oracle-app/api/http/admin"
 	"oracle.com/oracle/my-go-oracle-app/api/http/member"
 	config "oracle.com/oracle/my-go-oracle-app/configs"
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
 	"oracle.com/oracle/my-go-oracle-app/pkg/helpers"
 	"oracle.com/oracle/my-go-oracle-app/pkg/logger"
 	"oracle.com/oracle/my-go-oracle-app/pkg/panics"
@@ -35,7 +38,7 @@ func handler(checker api.HealthChecker, cfg *config.Config) http.Handler {
 
 	r.Use(middleware.Heartbeat("/ping"))
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(api.RealIP(cfg.TrustedProxies))
 	r.Use(panics.HTTPRecoveryMiddleware)
 	r.Use(middleware.Timeout(cfg.HttpInboundTimeout))
 
@@ -60,7 +63,8 @@ func handler(checker api.HealthChecker, cfg *config.Config) http.Handler {
 		cors := cors.New(cors.Options{
 			AllowedOrigins: []string{"*"},
 			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
-			AllowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
+			AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", constants.READ_YOUR_WRITES_HEADER},
+			ExposedHeaders: []string{constants.READ_YOUR_WRITES_HEADER},
 		})
 		r.Use(cors.Handler)
 
@@ -71,6 +75,9 @@ func handler(checker api.HealthChecker, cfg *config.Config) http.Handler {
 
 		r.With(api.InterceptorRequest()).Route("/my-go-oracle-app", func(r chi.Router) {
 			r.Use(api.NewMetricMiddleware())
+			if cfg.ReadYourWritesWindow > 0 {
+				r.Use(api.ReadYourWrites(cfg.ReadYourWritesWindow))
+			}
 			// members group
 			r.Route("/members", func(r chi.Router) {
 				r.Get("/", member.GetAllMembers)
@@ -78,8 +85,24 @@ func handler(checker api.HealthChecker, cfg *config.Config) http.Handler {
 				r.Post("/", member.CreateMember)
 				r.Put("/{id}", member.UpdateMember)
 				r.Delete("/{id}", member.DeleteMember)
+				r.Post("/{id}/restore", member.RestoreMember)
+				r.Get("/{id}/history", member.GetMemberHistory)
 			})
 
+			if cfg.AdminEnabled {
+				if cfg.AdminToken == "" {
+					slog.Warn("ADMIN_ENABLED without ADMIN_TOKEN, every admin request is refused")
+				}
+				r.Route("/admin", func(r chi.Router) {
+					r.Use(api.AdminAuth(cfg.AdminToken))
+					r.Get("/slow-queries", admin.GetSlowQueries)
+					r.Get("/backfills", admin.GetBackfills)
+					r.Post("/backfills/{name}/start", admin.StartBackfill)
+					r.Post("/backfills/{name}/pause", admin.PauseBackfill)
+					r.Post("/backfills/{name}/cancel", admin.CancelBackfill)
+				})
+			}
+
 		})
 	})
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"oracle.com/oracle/my-go-oracle-app/api"
+	"oracle.com/oracle/my-go-oracle-app/api/http/admin"
 	"oracle.com/oracle/my-go-oracle-app/api/http/member"
 	config "oracle.com/oracle/my-go-oracle-app/configs"
 )
@@ -17,6 +18,7 @@ type Server struct {
 	Cfg           *config.Config
 	HealthCheck   api.HealthChecker
 	MemberService api.MemberService
+	Backfills     api.BackfillService
 }
 
 var ()
@@ -25,6 +27,7 @@ var ()
 func (s *Server) Serve(port string) error {
 
 	member.Init(s.MemberService)
+	admin.Init(s.Backfills)
 	s.server = &http.Server{
 		ReadTimeout:  s.Cfg.HttpReadTimeout * time.Second,
 		WriteTimeout: s.Cfg.HttpWriteTimeout * time.Second,
@@ -8,9 +8,18 @@ import (
 )
 
 type MemberService interface {
-	FindById(ctx context.Context, id int64) (member.MemberResponse, error)
+	FindById(ctx context.Context, id int64, includeDeleted bool) (member.MemberResponse, error)
 	FindAll(ctx context.Context, param service.SqlParameter) ([]member.MemberResponse, service.Pagination, error)
 	CreateMember(ctx context.Context, data *member.MemberRequest) (member.MemberResponse, error)
 	UpdateMember(ctx context.Context, id int64, data *member.MemberRequest) (member.MemberResponse, error)
 	DeleteMember(ctx context.Context, id int64) (bool, error)
+	RestoreMember(ctx context.Context, id int64) (member.MemberResponse, error)
+	FindHistory(ctx context.Context, id int64, param service.SqlParameter) ([]service.HistoryEntry, service.Pagination, error)
+}
+
+type BackfillService interface {
+	Progress() []service.BackfillProgress
+	Start(name string, dryRun bool) (service.BackfillProgress, error)
+	Pause(name string) (service.BackfillProgress, error)
+	Cancel(name string) (service.BackfillProgress, error)
 }
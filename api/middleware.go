@@ -1,15 +1,31 @@
 package api
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+	"oracle.com/oracle/my-go-oracle-app/pkg/response"
+	"oracle.com/oracle/my-go-oracle-app/service"
 )
 
+// ADMIN_PRINCIPAL is the principal of the requests authenticated by AdminAuth
+const ADMIN_PRINCIPAL = "admin"
+
+var errUnauthorized = errors.New("UNAUTHORIZED")
+
 type requestBody struct {
 	Vertical string `json:"vertical"`
 }
@@ -20,7 +36,9 @@ func InterceptorRequest() func(next http.Handler) http.Handler {
 
 			slog.Debug(fmt.Sprintf("Request header = %v , Request URL = %v , Request Body = %v", r.Header, r.URL, r.Body))
 
-			ctx := r.Context()
+			// the client address until an authentication middleware such as AdminAuth sets the principal.
+			// RemoteAddr only comes from X-Forwarded-For / X-Real-IP behind a trusted proxy, see RealIP
+			ctx := service.SetActorInContext(r.Context(), remoteHost(r))
 
 			r = r.WithContext(ctx)
 
@@ -30,6 +48,163 @@ func InterceptorRequest() func(next http.Handler) http.Handler {
 	}
 }
 
+type principalKey struct{}
+
+// SetPrincipalInContext records who authenticated the request, the actor of the changes it makes
+func SetPrincipalInContext(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// GetPrincipalFromContext returns the principal set by SetPrincipalInContext, empty for an anonymous request
+func GetPrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// RealIP runs middleware.RealIP on the requests whose peer is one of the trusted proxies, addresses or CIDRs,
+// so RemoteAddr is not taken from a header set by any other client. Invalid entries are ignored.
+func RealIP(trusted []string) func(next http.Handler) http.Handler {
+	var prefixes []netip.Prefix
+	for _, proxy := range trusted {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				slog.Warn(fmt.Sprintf("invalid trusted proxy %q ignored", proxy))
+				continue
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return func(next http.Handler) http.Handler {
+		realIP := middleware.RealIP(next)
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if addr, err := netip.ParseAddr(remoteHost(r)); err == nil {
+				addr = addr.Unmap()
+				for _, prefix := range prefixes {
+					if prefix.Contains(addr) {
+						realIP.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// remoteHost is the host of r.RemoteAddr, which middleware.RealIP leaves without a port
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// AdminAuth lets through the requests with the bearer token as ADMIN_PRINCIPAL, 401 otherwise.
+// Every request is refused when token is empty.
+func AdminAuth(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				slog.WarnContext(r.Context(), fmt.Sprintf("admin request refused: %s %s", r.Method, r.URL.Path))
+				resp := response.Response{}
+				resp.SetError(errUnauthorized, http.StatusUnauthorized)
+				resp.Render(w, r)
+				return
+			}
+			ctx := SetPrincipalInContext(r.Context(), ADMIN_PRINCIPAL)
+			ctx = service.SetActorInContext(ctx, ADMIN_PRINCIPAL)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ReadYourWrites sends the reads of a client to the master database for window after its last write, so it does
+// not miss its own changes while the replica lags. The deadline is returned in the X-Read-Your-Writes header and
+// cookie of a response to a write, as unix milliseconds, and read back from either on the next requests.
+// A deadline beyond window is cut down to window.
+func ReadYourWrites(window time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			masterUntil := readYourWritesDeadline(r)
+			if latest := now.Add(window); masterUntil.After(latest) {
+				masterUntil = latest
+			}
+
+			ctx, session := service.WithReadSession(r.Context(), masterUntil)
+			writer := &readYourWritesWriter{ResponseWriter: w, session: session, window: window}
+
+			next.ServeHTTP(writer, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func readYourWritesDeadline(r *http.Request) time.Time {
+	token := r.Header.Get(constants.READ_YOUR_WRITES_HEADER)
+	if token == "" {
+		if cookie, err := r.Cookie(constants.READ_YOUR_WRITES_COOKIE); err == nil {
+			token = cookie.Value
+		}
+	}
+	millis, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis)
+}
+
+// readYourWritesWriter hands the read-your-writes deadline to the client before the response
+// headers are written, when the request wrote
+type readYourWritesWriter struct {
+	http.ResponseWriter
+	session     *service.ReadSession
+	window      time.Duration
+	wroteHeader bool
+}
+
+func (w *readYourWritesWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.session.Wrote() {
+			token := strconv.FormatInt(time.Now().Add(w.window).UnixMilli(), 10)
+			w.Header().Set(constants.READ_YOUR_WRITES_HEADER, token)
+			http.SetCookie(w, &http.Cookie{
+				Name:     constants.READ_YOUR_WRITES_COOKIE,
+				Value:    token,
+				Path:     "/",
+				MaxAge:   int(w.window.Seconds()),
+				HttpOnly: true,
+			})
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *readYourWritesWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *readYourWritesWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func GetIP(r *http.Request) string {
 	// First, check for the X-Forwarded-For header (used in proxies)
 	ip := r.Header.Get("X-Forwarded-For")
@@ -1,13 +1,18 @@
 package api_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"oracle.com/oracle/my-go-oracle-app/api"
 	config "oracle.com/oracle/my-go-oracle-app/configs"
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
 	"oracle.com/oracle/my-go-oracle-app/pkg/logger"
+	"oracle.com/oracle/my-go-oracle-app/service"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -135,3 +140,138 @@ func TestGetIP(t *testing.T) {
 		})
 	}
 }
+
+func TestInterceptorRequest_Actor(t *testing.T) {
+	var actor string
+	handler := api.InterceptorRequest()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = service.GetActorFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/10", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Actor", "alice")
+	req.Header.Set("X-Forwarded-For", "192.168.1.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "10.0.0.1", actor, "client headers are not trusted")
+
+	req = httptest.NewRequest(http.MethodGet, "/users/10", nil)
+	req.RemoteAddr = "192.168.1.1"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "192.168.1.1", actor, "as set by RealIP")
+}
+
+func TestRealIP(t *testing.T) {
+	var remoteAddr string
+	handler := api.RealIP([]string{"10.0.0.1", "172.16.0.0/12", "not-an-ip"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{name: "trusted proxy address", remoteAddr: "10.0.0.1:1234", want: "192.168.1.1"},
+		{name: "trusted proxy CIDR", remoteAddr: "172.20.3.4:1234", want: "192.168.1.1"},
+		{name: "untrusted client", remoteAddr: "10.0.0.2:1234", want: "10.0.0.2:1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/10", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "192.168.1.1")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.want, remoteAddr)
+		})
+	}
+
+	handler = api.RealIP(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/users/10", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Real-IP", "192.168.1.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "10.0.0.1:1234", remoteAddr, "no proxy trusted")
+}
+
+func TestPrincipalInContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), constants.AUTHORIZATION, "Bearer secret")
+	assert.Empty(t, api.GetPrincipalFromContext(ctx), "not read from another context key")
+
+	ctx = api.SetPrincipalInContext(ctx, api.ADMIN_PRINCIPAL)
+	assert.Equal(t, api.ADMIN_PRINCIPAL, api.GetPrincipalFromContext(ctx))
+	assert.Equal(t, "Bearer secret", ctx.Value(constants.AUTHORIZATION))
+}
+
+func TestReadYourWrites(t *testing.T) {
+	var readsMaster bool
+	handler := api.ReadYourWrites(5 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := service.ReadSessionFromContext(r.Context())
+		readsMaster = session.ReadsMaster()
+		if r.Method == http.MethodPost {
+			session.MarkWrite()
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/members/1", nil))
+	assert.False(t, readsMaster)
+	assert.Empty(t, rec.Header().Get(constants.READ_YOUR_WRITES_HEADER), "no write, no token")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/members", nil))
+	token := rec.Header().Get(constants.READ_YOUR_WRITES_HEADER)
+	assert.NotEmpty(t, token)
+	assert.Len(t, rec.Result().Cookies(), 1)
+
+	req := httptest.NewRequest(http.MethodGet, "/members/1", nil)
+	req.AddCookie(rec.Result().Cookies()[0])
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, readsMaster, "token sent back in the cookie")
+
+	req = httptest.NewRequest(http.MethodGet, "/members/1", nil)
+	req.Header.Set(constants.READ_YOUR_WRITES_HEADER, strconv.FormatInt(time.Now().Add(-time.Second).UnixMilli(), 10))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, readsMaster, "window elapsed")
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "valid token", token: "secret", authorization: "Bearer secret", want: http.StatusOK},
+		{name: "wrong token", token: "secret", authorization: "Bearer other", want: http.StatusUnauthorized},
+		{name: "not a bearer", token: "secret", authorization: "secret", want: http.StatusUnauthorized},
+		{name: "no header", token: "secret", want: http.StatusUnauthorized},
+		{name: "no token configured", authorization: "Bearer ", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Route("/admin/backfills", func(r chi.Router) {
+				r.Use(api.AdminAuth(tt.token))
+				r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, api.ADMIN_PRINCIPAL, api.GetPrincipalFromContext(r.Context()))
+					assert.Equal(t, api.ADMIN_PRINCIPAL, service.GetActorFromContext(r.Context()))
+				})
+			})
+
+			for _, path := range []string{"/admin/backfills", "/admin/backfills/"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.authorization != "" {
+					req.Header.Set("Authorization", tt.authorization)
+				}
+				rec := httptest.NewRecorder()
+				r.ServeHTTP(rec, req)
+				assert.Equal(t, tt.want, rec.Code, path)
+			}
+		})
+	}
+}
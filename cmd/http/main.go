@@ -44,6 +44,15 @@ func main() {
 		Env: helpers.GetEnvString(),
 	})
 
+	// `migrate up|down|status|goto N` runs the schema migrations instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := server.Migrate(cfg, os.Args[2:]); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	// init all DI for service handler implementation
 	if err := server.InitHttp(cfg); err != nil {
 		slog.Error(err.Error())
@@ -124,6 +124,39 @@ func setDefault() {
 	viper.SetDefault("HTTP_MAX_IDLE_CONNECTIONS", 100)
 	viper.SetDefault("HTTP_MAX_IDLE_CONNECTIONS_PER_HOST", 100)
 	viper.SetDefault("HTTP_IDLE_CONNECTION_TIMEOUT", "10s")
+	viper.SetDefault("ORACLE_SLAVE_HOSTS", "")
+	viper.SetDefault("ORACLE_SLAVE_BALANCER", "round_robin")
+	viper.SetDefault("ORACLE_SLAVE_PING_INTERVAL", "5s")
+	viper.SetDefault("ORACLE_SLAVE_PING_TIMEOUT", "2s")
+	viper.SetDefault("ORACLE_STATEMENT_CACHE_SIZE", 100)
+	viper.SetDefault("ORACLE_TIMEOUT_READ", "5s")
+	viper.SetDefault("ORACLE_TIMEOUT_WRITE", "5s")
+	viper.SetDefault("ORACLE_TIMEOUT_COUNT", "8s")
+	viper.SetDefault("ORACLE_TIMEOUT_EXPORT", "0s")
+	viper.SetDefault("ORACLE_TIMEOUT_MARGIN", "250ms")
+	viper.SetDefault("ORACLE_RETRY_MAX_RETRIES", 3)
+	viper.SetDefault("ORACLE_RETRY_BACKOFF", "100ms")
+	viper.SetDefault("ORACLE_RETRY_MAX_BACKOFF", "2s")
+	viper.SetDefault("ORACLE_RETRY_JITTER", 0.25)
+	viper.SetDefault("ORACLE_RETRY_TRANSIENT_CODES", "")
+	viper.SetDefault("ORACLE_BREAKER_FAILURE_RATE", 0.5)
+	viper.SetDefault("ORACLE_BREAKER_MIN_REQUESTS", 20)
+	viper.SetDefault("ORACLE_BREAKER_WINDOW", "10s")
+	viper.SetDefault("ORACLE_BREAKER_OPEN_TIMEOUT", "30s")
+	viper.SetDefault("ORACLE_BREAKER_HALF_OPEN_TRIALS", 3)
+	viper.SetDefault("ORACLE_READ_FALLBACK", true)
+	viper.SetDefault("READ_YOUR_WRITES_WINDOW", "5s")
+	viper.SetDefault("LOG_QUERIES", false)
+	viper.SetDefault("SLOW_QUERY_THRESHOLD", "1s")
+	viper.SetDefault("SLOW_QUERY_SAMPLE_SIZE", 100)
+	viper.SetDefault("MIGRATIONS_DIR", "")
+	viper.SetDefault("MIGRATE_ON_STARTUP", false)
+	viper.SetDefault("MIGRATE_LOCK_TIMEOUT", "1m")
+	viper.SetDefault("BACKFILL_CHUNK_SIZE", 500)
+	viper.SetDefault("BACKFILL_THROTTLE", "1s")
+	viper.SetDefault("ADMIN_ENABLED", false)
+	viper.SetDefault("ADMIN_TOKEN", "")
+	viper.SetDefault("TRUSTED_PROXIES", "")
 }
 
 // postprocess several config
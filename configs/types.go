@@ -30,11 +30,65 @@ type (
 		OracleSlaveUsername string `mapstructure:"ORACLE_SLAVE_USERNAME"`
 		OracleSlavePassword string `mapstructure:"ORACLE_SLAVE_PASSWORD"`
 
+		// OracleSlaveHosts are the connect strings, host:port/database, of the read replicas balanced
+		// with the slave credentials. The single ORACLE_SLAVE_HOST is used when empty.
+		OracleSlaveHosts        []string      `mapstructure:"ORACLE_SLAVE_HOSTS"`
+		OracleSlaveBalancer     string        `mapstructure:"ORACLE_SLAVE_BALANCER"`
+		OracleSlavePingInterval time.Duration `mapstructure:"ORACLE_SLAVE_PING_INTERVAL"`
+		OracleSlavePingTimeout  time.Duration `mapstructure:"ORACLE_SLAVE_PING_TIMEOUT"`
+
 		OracleMaxOpenConnection int           `mapstructure:"ORACLE_MAX_OPEN_CONNECTION"`
 		OracleMaxIdleConnection int           `mapstructure:"ORACLE_MAX_IDLE_CONNECTION"`
 		OracleConnMaxIdleTime   time.Duration `mapstructure:"ORACLE_CONN_MAX_IDLE_TIME"`
 		OracleConnMaxLifeTime   time.Duration `mapstructure:"ORACLE_CONN_MAX_LIFE_TIME"`
 
+		// OracleStatementCacheSize is how many prepared statements MasterDB and each replica keep, 0 disables the cache
+		OracleStatementCacheSize int `mapstructure:"ORACLE_STATEMENT_CACHE_SIZE"`
+
+		// timeouts of the operations by class, cut to the remaining request deadline minus OracleTimeoutMargin
+		OracleTimeoutRead   time.Duration `mapstructure:"ORACLE_TIMEOUT_READ"`
+		OracleTimeoutWrite  time.Duration `mapstructure:"ORACLE_TIMEOUT_WRITE"`
+		OracleTimeoutCount  time.Duration `mapstructure:"ORACLE_TIMEOUT_COUNT"`
+		OracleTimeoutExport time.Duration `mapstructure:"ORACLE_TIMEOUT_EXPORT"`
+		OracleTimeoutMargin time.Duration `mapstructure:"ORACLE_TIMEOUT_MARGIN"`
+
 		OracleLibDir string `mapstructure:"ORACLE_LIB_DIR"`
+
+		OracleRetryMaxRetries     int           `mapstructure:"ORACLE_RETRY_MAX_RETRIES"`
+		OracleRetryBackoff        time.Duration `mapstructure:"ORACLE_RETRY_BACKOFF"`
+		OracleRetryMaxBackoff     time.Duration `mapstructure:"ORACLE_RETRY_MAX_BACKOFF"`
+		OracleRetryJitter         float64       `mapstructure:"ORACLE_RETRY_JITTER"`
+		OracleRetryTransientCodes []int         `mapstructure:"ORACLE_RETRY_TRANSIENT_CODES"`
+
+		OracleBreakerFailureRate    float64       `mapstructure:"ORACLE_BREAKER_FAILURE_RATE"`
+		OracleBreakerMinRequests    int           `mapstructure:"ORACLE_BREAKER_MIN_REQUESTS"`
+		OracleBreakerWindow         time.Duration `mapstructure:"ORACLE_BREAKER_WINDOW"`
+		OracleBreakerOpenTimeout    time.Duration `mapstructure:"ORACLE_BREAKER_OPEN_TIMEOUT"`
+		OracleBreakerHalfOpenTrials int           `mapstructure:"ORACLE_BREAKER_HALF_OPEN_TRIALS"`
+
+		OracleReadFallback   bool          `mapstructure:"ORACLE_READ_FALLBACK"`
+		ReadYourWritesWindow time.Duration `mapstructure:"READ_YOUR_WRITES_WINDOW"`
+
+		LogQueries          bool          `mapstructure:"LOG_QUERIES"`
+		SlowQueryThreshold  time.Duration `mapstructure:"SLOW_QUERY_THRESHOLD"`
+		SlowQuerySampleSize int           `mapstructure:"SLOW_QUERY_SAMPLE_SIZE"`
+
+		// MigrationsDir holds the migrations run by migrate, the ones embedded from source/migrations when empty
+		MigrationsDir      string        `mapstructure:"MIGRATIONS_DIR"`
+		MigrateOnStartup   bool          `mapstructure:"MIGRATE_ON_STARTUP"`
+		MigrateLockTimeout time.Duration `mapstructure:"MIGRATE_LOCK_TIMEOUT"`
+
+		// BackfillChunkSize rows are rewritten per transaction, BackfillThrottle apart
+		BackfillChunkSize int           `mapstructure:"BACKFILL_CHUNK_SIZE"`
+		BackfillThrottle  time.Duration `mapstructure:"BACKFILL_THROTTLE"`
+
+		// AdminEnabled mounts the /admin routes, which require the bearer AdminToken
+		AdminEnabled bool   `mapstructure:"ADMIN_ENABLED"`
+		AdminToken   string `mapstructure:"ADMIN_TOKEN"`
+
+		CursorSecret string `mapstructure:"CURSOR_SECRET"`
+
+		// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For / X-Real-IP give the client address
+		TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
 	}
 )
@@ -91,14 +91,20 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "category filter",
-                        "name": "category",
+                        "description": "memberId filter",
+                        "name": "memberId",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "level filter",
-                        "name": "level",
+                        "description": "onboardingStage filter",
+                        "name": "onboardingStage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "riskRating filter",
+                        "name": "riskRating",
                         "in": "query"
                     },
                     {
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "oracle"
+
+// targets of a database operation
+const (
+	TARGET_MASTER = "master"
+	TARGET_SLAVE  = "slave"
+	TARGET_TX     = "tx"
+)
+
+// classes of a database error
+const (
+	ERROR_TRANSIENT = "transient"
+	ERROR_PERMANENT = "permanent"
+)
+
+// reasons of the database serving a read
+const (
+	ROUTE_REPLICA          = "replica"
+	ROUTE_READ_YOUR_WRITES = "read_your_writes"
+	ROUTE_UNHEALTHY        = "unhealthy"
+	ROUTE_FALLBACK         = "fallback"
+)
+
+// results of a statement cache lookup
+const (
+	CACHE_HIT  = "hit"
+	CACHE_MISS = "miss"
+)
+
+var (
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "query_duration_seconds",
+		Help:      "Latency of database operations by operation and target",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"operation", "target"})
+
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "query_errors_total",
+		Help:      "Failed database operations by operation, target and ORA code",
+	}, []string{"operation", "target", "code"})
+
+	retries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "retries_total",
+		Help:      "Operations retried after a transient error, by operation and target",
+	}, []string{"operation", "target"})
+
+	retriesExhausted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "retries_exhausted_total",
+		Help:      "Operations still failing with a transient error after the last retry",
+	}, []string{"operation", "target"})
+
+	classifiedErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "classified_errors_total",
+		Help:      "Database errors by ORA code and class, transient or permanent",
+	}, []string{"code", "class"})
+
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "breaker_state",
+		Help:      "State of the circuit breaker of a database: 0 closed, 1 open, 2 half-open",
+	}, []string{"database"})
+
+	replicaUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "replica_up",
+		Help:      "Whether a read replica answered its last ping: 1 admitted, 0 ejected",
+	}, []string{"replica"})
+
+	readRoutes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "read_routes_total",
+		Help:      "Reads by operation, the target serving them and the reason it was chosen",
+	}, []string{"operation", "target", "reason"})
+
+	statementCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "statement_cache_lookups_total",
+		Help:      "Prepared statement cache lookups by database and result, hit or miss",
+	}, []string{"database", "result"})
+
+	statementCacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "statement_cache_evictions_total",
+		Help:      "Prepared statements evicted from the cache of a database",
+	}, []string{"database"})
+)
+
+func observeQuery(operation, target string, duration time.Duration) {
+	queryDuration.WithLabelValues(operation, target).Observe(duration.Seconds())
+}
+
+// RecordRetry counts a retry of operation on target
+func RecordRetry(operation, target string) {
+	retries.WithLabelValues(operation, target).Inc()
+}
+
+// RecordRetriesExhausted counts an operation given up after its last retry
+func RecordRetriesExhausted(operation, target string) {
+	retriesExhausted.WithLabelValues(operation, target).Inc()
+}
+
+// RecordErrorClass counts an error classified as class, code being its ORA code or empty
+func RecordErrorClass(code, class string) {
+	classifiedErrors.WithLabelValues(code, class).Inc()
+}
+
+// SetBreakerState sets the state of the circuit breaker of database
+func SetBreakerState(database string, state int) {
+	breakerState.WithLabelValues(database).Set(float64(state))
+}
+
+// SetReplicaUp sets whether replica answered its last ping
+func SetReplicaUp(replica string, up bool) {
+	value := 0.0
+	if up {
+		value = 1
+	}
+	replicaUp.WithLabelValues(replica).Set(value)
+}
+
+// RecordReadRoute counts a read of operation sent to target for reason
+func RecordReadRoute(operation, target, reason string) {
+	readRoutes.WithLabelValues(operation, target, reason).Inc()
+}
+
+// RecordStatementCache counts a lookup in the statement cache of database, result being CACHE_HIT or CACHE_MISS
+func RecordStatementCache(database, result string) {
+	statementCacheLookups.WithLabelValues(database, result).Inc()
+}
+
+// RecordStatementCacheEviction counts a statement evicted from the cache of database
+func RecordStatementCacheEviction(database string) {
+	statementCacheEvictions.WithLabelValues(database).Inc()
+}
@@ -0,0 +1,404 @@
+// Package migrate applies the numbered schema migrations, NNNNNN_name.up.sql and NNNNNN_name.down.sql,
+// against MasterDB and records them in SCHEMA_MIGRATIONS
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+)
+
+const (
+	MIGRATIONS_TABLE     = "SCHEMA_MIGRATIONS"
+	DEFAULT_LOCK_TIMEOUT = time.Minute
+)
+
+// DBMS_LOCK.REQUEST results
+const (
+	lockGranted    = 0
+	lockTimeout    = 1
+	lockAlreadyOwn = 4
+)
+
+const (
+	createMigrationsTableBlock = `BEGIN
+	EXECUTE IMMEDIATE 'CREATE TABLE ` + MIGRATIONS_TABLE + ` (
+		VERSION    NUMBER(19) PRIMARY KEY,
+		NAME       VARCHAR2(255) NOT NULL,
+		CHECKSUM   VARCHAR2(64) NOT NULL,
+		DIRTY      NUMBER(1) DEFAULT 0 NOT NULL,
+		APPLIED_AT TIMESTAMP NOT NULL
+	)';
+EXCEPTION
+	WHEN OTHERS THEN
+		IF SQLCODE != -955 THEN
+			RAISE;
+		END IF;
+END;`
+	lockBlock = `DECLARE
+	lock_handle VARCHAR2(128);
+BEGIN
+	DBMS_LOCK.ALLOCATE_UNIQUE(:1, lock_handle);
+	:2 := DBMS_LOCK.REQUEST(lock_handle, DBMS_LOCK.X_MODE, :3, TRUE);
+END;`
+	findMigrationsQuery  = `SELECT VERSION, NAME, CHECKSUM, DIRTY, APPLIED_AT FROM ` + MIGRATIONS_TABLE + ` ORDER BY VERSION`
+	insertMigrationQuery = `INSERT INTO ` + MIGRATIONS_TABLE + ` (VERSION, NAME, CHECKSUM, DIRTY, APPLIED_AT) VALUES (:1, :2, :3, 1, SYSTIMESTAMP)`
+	cleanMigrationQuery  = `UPDATE ` + MIGRATIONS_TABLE + ` SET DIRTY = 0, APPLIED_AT = SYSTIMESTAMP WHERE VERSION = :1`
+	dirtyMigrationQuery  = `UPDATE ` + MIGRATIONS_TABLE + ` SET DIRTY = 1 WHERE VERSION = :1`
+	deleteMigrationQuery = `DELETE FROM ` + MIGRATIONS_TABLE + ` WHERE VERSION = :1`
+)
+
+var fileRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+var ErrLockTimeout = errors.New("another migration holds the " + MIGRATIONS_TABLE + " lock")
+
+// DirtyError is returned while a migration which failed halfway is recorded: Oracle DDL is not transactional,
+// so the schema has to be repaired by hand before the row of the version is deleted or its DIRTY set to 0.
+type DirtyError struct {
+	Version int64
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("migration %d failed halfway: repair the schema, then delete its row from %s or set DIRTY to 0",
+		e.Version, MIGRATIONS_TABLE)
+}
+
+// Migration is a numbered schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is the SHA-256 of Up, recorded when applied
+	Checksum string
+}
+
+// Status is the state of a migration, see Migrator.Status
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Dirty     bool
+	// Modified is set when the up file changed since the migration was applied
+	Modified bool
+	// Missing is set when the migration is applied but has no file
+	Missing bool
+}
+
+// State returns pending, applied, dirty, modified or missing
+func (s Status) State() string {
+	switch {
+	case s.Dirty:
+		return "dirty"
+	case s.Missing:
+		return "missing"
+	case s.Modified:
+		return "modified"
+	case s.Applied:
+		return "applied"
+	default:
+		return "pending"
+	}
+}
+
+type appliedMigration struct {
+	Version   int64     `db:"VERSION"`
+	Name      string    `db:"NAME"`
+	Checksum  string    `db:"CHECKSUM"`
+	Dirty     int       `db:"DIRTY"`
+	AppliedAt time.Time `db:"APPLIED_AT"`
+}
+
+// Load reads the migrations of fsys ordered by version. Every version needs an up file, the down file is optional.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			sum := sha256.Sum256(content)
+			migration.Up, migration.Checksum = string(content), hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return int(a.Version - b.Version) })
+	return migrations, nil
+}
+
+// Migrator applies migrations against a MasterDB. Runners are serialized by a DBMS_LOCK lock held
+// by the session of a transaction, which requires EXECUTE on DBMS_LOCK and a spare connection.
+type Migrator struct {
+	db          oracle.MasterDB
+	migrations  []Migration
+	lockTimeout time.Duration
+	// lock serializes the runners, unlock being called once done
+	lock func(ctx context.Context) (unlock func(), err error)
+}
+
+type Option func(*Migrator)
+
+// WithLockTimeout sets how long a runner waits for another one, DEFAULT_LOCK_TIMEOUT by default
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// New returns a Migrator applying the migrations of fsys against db
+func New(db oracle.MasterDB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{db: db, migrations: migrations, lockTimeout: DEFAULT_LOCK_TIMEOUT}
+	m.lock = m.dbLock
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.Goto(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down reverts the last applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(applied []appliedMigration) error {
+		if len(applied) == 0 {
+			return nil
+		}
+		last, _ := m.find(applied[len(applied)-1].Version)
+		return m.apply(ctx, last, false)
+	})
+}
+
+// Goto applies the pending migrations up to version and reverts the applied ones above it, 0 reverting all
+func (m *Migrator) Goto(ctx context.Context, version int64) error {
+	return m.locked(ctx, func(applied []appliedMigration) error {
+		return m.migrate(ctx, applied, version)
+	})
+}
+
+// Status returns the state of the known and applied migrations, ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if _, err := m.db.ExecContext(ctx, createMigrationsTableBlock); err != nil {
+		return nil, fmt.Errorf("create %s: %w", MIGRATIONS_TABLE, err)
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]appliedMigration{}
+	for _, a := range applied {
+		byVersion[a.Version] = a
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if a, ok := byVersion[migration.Version]; ok {
+			status.Applied, status.AppliedAt, status.Dirty = true, a.AppliedAt, a.Dirty == 1
+			status.Modified = a.Checksum != migration.Checksum
+			delete(byVersion, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, a := range byVersion {
+		statuses = append(statuses, Status{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: a.AppliedAt, Dirty: a.Dirty == 1, Missing: true})
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return int(a.Version - b.Version) })
+	return statuses, nil
+}
+
+// locked runs fn with the applied migrations while holding the lock, once they are checked against the files
+func (m *Migrator) locked(ctx context.Context, fn func(applied []appliedMigration) error) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := m.db.ExecContext(ctx, createMigrationsTableBlock); err != nil {
+		return fmt.Errorf("create %s: %w", MIGRATIONS_TABLE, err)
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range applied {
+		if a.Dirty == 1 {
+			return &DirtyError{Version: a.Version}
+		}
+		migration, ok := m.find(a.Version)
+		if !ok {
+			return fmt.Errorf("applied migration %d_%s has no file", a.Version, a.Name)
+		}
+		if migration.Checksum != a.Checksum {
+			return fmt.Errorf("migration %d_%s was modified after being applied", a.Version, a.Name)
+		}
+	}
+	return fn(applied)
+}
+
+// migrate applies the pending migrations up to target, then reverts the applied ones above it
+func (m *Migrator) migrate(ctx context.Context, applied []appliedMigration, target int64) error {
+	if target != 0 {
+		if _, ok := m.find(target); !ok {
+			return fmt.Errorf("migration %d does not exist", target)
+		}
+	}
+
+	isApplied := map[int64]bool{}
+	for _, a := range applied {
+		isApplied[a.Version] = true
+	}
+	for _, migration := range m.migrations {
+		if migration.Version <= target && !isApplied[migration.Version] {
+			if err := m.apply(ctx, migration, true); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(applied) - 1; i >= 0; i-- {
+		if applied[i].Version > target {
+			migration, _ := m.find(applied[i].Version)
+			if err := m.apply(ctx, migration, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply runs the up or down script of migration, the migration being recorded dirty until it succeeds
+func (m *Migrator) apply(ctx context.Context, migration Migration, up bool) error {
+	direction, script := "up", migration.Up
+	if !up {
+		direction, script = "down", migration.Down
+	}
+	statements, err := Split(script)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	if !up && len(statements) == 0 {
+		return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+	}
+
+	start := time.Now()
+	if up {
+		_, err = m.db.ExecContext(ctx, insertMigrationQuery, migration.Version, migration.Name, migration.Checksum)
+	} else {
+		_, err = m.db.ExecContext(ctx, dirtyMigrationQuery, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("record migration %d: %w", migration.Version, err)
+	}
+
+	for i, statement := range statements {
+		if _, err := m.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d_%s %s, statement %d: %w; %w", migration.Version, migration.Name, direction, i+1, err,
+				&DirtyError{Version: migration.Version})
+		}
+	}
+
+	if up {
+		_, err = m.db.ExecContext(ctx, cleanMigrationQuery, migration.Version)
+	} else {
+		_, err = m.db.ExecContext(ctx, deleteMigrationQuery, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("record migration %d: %w", migration.Version, err)
+	}
+	slog.InfoContext(ctx, fmt.Sprintf("migration %d_%s %s applied in %v", migration.Version, migration.Name, direction, time.Since(start)))
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) ([]appliedMigration, error) {
+	var applied []appliedMigration
+	if err := m.db.SelectContext(ctx, &applied, findMigrationsQuery); err != nil {
+		return nil, fmt.Errorf("read %s: %w", MIGRATIONS_TABLE, err)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	i := slices.IndexFunc(m.migrations, func(migration Migration) bool { return migration.Version == version })
+	if i < 0 {
+		return Migration{}, false
+	}
+	return m.migrations[i], true
+}
+
+// dbLock takes the exclusive DBMS_LOCK lock of the migrations in a transaction, released when it rolls back.
+// A runner dying releases it with its session.
+func (m *Migrator) dbLock(ctx context.Context) (func(), error) {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result int64
+	_, err = tx.ExecContext(ctx, lockBlock, MIGRATIONS_TABLE, sql.Out{Dest: &result}, int64(m.lockTimeout.Seconds()))
+	if err == nil {
+		switch result {
+		case lockGranted, lockAlreadyOwn:
+			return func() { _ = tx.Rollback() }, nil
+		case lockTimeout:
+			err = ErrLockTimeout
+		default:
+			err = fmt.Errorf("DBMS_LOCK.REQUEST returned %d", result)
+		}
+	}
+	_ = tx.Rollback()
+	return nil, err
+}
@@ -0,0 +1,200 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+	"oracle.com/oracle/my-go-oracle-app/source/migrations"
+)
+
+var errTableExists = errors.New("ORA-00955: name is already used by an existing object")
+
+// fakeMaster is a MasterDB keeping SCHEMA_MIGRATIONS in memory and recording the other statements
+type fakeMaster struct {
+	oracle.MasterDB
+	applied    map[int64]appliedMigration
+	statements []string
+	failOn     string
+}
+
+func newFakeMaster() *fakeMaster {
+	return &fakeMaster{applied: map[int64]appliedMigration{}}
+}
+
+func (f *fakeMaster) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	switch query {
+	case createMigrationsTableBlock:
+	case insertMigrationQuery:
+		f.applied[args[0].(int64)] = appliedMigration{Version: args[0].(int64), Name: args[1].(string), Checksum: args[2].(string), Dirty: 1}
+	case cleanMigrationQuery, dirtyMigrationQuery:
+		applied := f.applied[args[0].(int64)]
+		applied.Dirty = 0
+		if query == dirtyMigrationQuery {
+			applied.Dirty = 1
+		}
+		f.applied[args[0].(int64)] = applied
+	case deleteMigrationQuery:
+		delete(f.applied, args[0].(int64))
+	default:
+		if f.failOn != "" && strings.Contains(query, f.failOn) {
+			return nil, errTableExists
+		}
+		f.statements = append(f.statements, query)
+	}
+	return nil, nil
+}
+
+func (f *fakeMaster) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	rows := dest.(*[]appliedMigration)
+	for _, applied := range f.applied {
+		*rows = append(*rows, applied)
+	}
+	slices.SortFunc(*rows, func(a, b appliedMigration) int { return int(a.Version - b.Version) })
+	return nil
+}
+
+var testMigrations = fstest.MapFS{
+	"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE A (ID NUMBER);\nCREATE INDEX A_IDX ON A (ID);\n")},
+	"000001_create_a.down.sql": {Data: []byte("DROP TABLE A;\n")},
+	"000002_create_b.up.sql":   {Data: []byte("CREATE TABLE B (ID NUMBER);\n")},
+	"000002_create_b.down.sql": {Data: []byte("DROP TABLE B;\n")},
+	"000010_seed_b.up.sql":     {Data: []byte("BEGIN\n  INSERT INTO B VALUES (1);\nEND;\n/\n")},
+	"README.md":                {Data: []byte("not a migration")},
+}
+
+func newTestMigrator(t *testing.T, db oracle.MasterDB, fsys fstest.MapFS) *Migrator {
+	m, err := New(db, fsys)
+	require.NoError(t, err)
+	m.lock = func(ctx context.Context) (func(), error) { return func() {}, nil }
+	return m
+}
+
+func TestLoad(t *testing.T) {
+	loaded, err := Load(testMigrations)
+	require.NoError(t, err)
+	require.Len(t, loaded, 3)
+	assert.Equal(t, int64(1), loaded[0].Version)
+	assert.Equal(t, "create_a", loaded[0].Name)
+	assert.Equal(t, "DROP TABLE A;\n", loaded[0].Down)
+	assert.Len(t, loaded[0].Checksum, 64)
+	assert.Equal(t, int64(10), loaded[2].Version)
+	assert.Empty(t, loaded[2].Down)
+
+	_, err = Load(fstest.MapFS{"000001_a.down.sql": {Data: []byte("DROP TABLE A;")}})
+	assert.ErrorContains(t, err, "no up file")
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	for _, migration := range loaded {
+		up, err := Split(migration.Up)
+		require.NoError(t, err)
+		assert.NotEmpty(t, up, "%d_%s up", migration.Version, migration.Name)
+		down, err := Split(migration.Down)
+		require.NoError(t, err)
+		assert.NotEmpty(t, down, "%d_%s down", migration.Version, migration.Name)
+	}
+	assert.True(t, strings.HasPrefix(loaded[0].Up, "CREATE TABLE MEMBER"))
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	db := newFakeMaster()
+	m := newTestMigrator(t, db, testMigrations)
+	ctx := context.Background()
+
+	require.NoError(t, m.Up(ctx))
+	assert.Equal(t, []string{
+		"CREATE TABLE A (ID NUMBER)",
+		"CREATE INDEX A_IDX ON A (ID)",
+		"CREATE TABLE B (ID NUMBER)",
+		"BEGIN\n  INSERT INTO B VALUES (1);\nEND;",
+	}, db.statements)
+	assert.Len(t, db.applied, 3)
+
+	db.statements = nil
+	require.NoError(t, m.Up(ctx))
+	assert.Empty(t, db.statements, "nothing pending")
+
+	assert.ErrorContains(t, m.Down(ctx), "10_seed_b has no down file")
+
+	require.NoError(t, m.Goto(ctx, 10))
+	delete(db.applied, 10)
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, []string{"DROP TABLE B"}, db.statements)
+	assert.Len(t, db.applied, 1)
+}
+
+func TestMigrator_Goto(t *testing.T) {
+	db := newFakeMaster()
+	m := newTestMigrator(t, db, testMigrations)
+	ctx := context.Background()
+
+	require.NoError(t, m.Goto(ctx, 2))
+	assert.Len(t, db.applied, 2)
+
+	db.statements = nil
+	require.NoError(t, m.Goto(ctx, 0))
+	assert.Equal(t, []string{"DROP TABLE B", "DROP TABLE A"}, db.statements, "reverted latest first")
+	assert.Empty(t, db.applied)
+
+	assert.ErrorContains(t, m.Goto(ctx, 3), "migration 3 does not exist")
+}
+
+func TestMigrator_DirtyAfterFailure(t *testing.T) {
+	db := newFakeMaster()
+	db.failOn = "CREATE TABLE B"
+	m := newTestMigrator(t, db, testMigrations)
+	ctx := context.Background()
+
+	err := m.Up(ctx)
+	assert.ErrorIs(t, err, errTableExists)
+	var dirty *DirtyError
+	require.ErrorAs(t, err, &dirty)
+	assert.Equal(t, int64(2), dirty.Version)
+
+	db.failOn = ""
+	err = m.Up(ctx)
+	assert.ErrorAs(t, err, &dirty, "blocked until repaired")
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"applied", "dirty", "pending"}, states(statuses))
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db := newFakeMaster()
+	m := newTestMigrator(t, db, testMigrations)
+	ctx := context.Background()
+	require.NoError(t, m.Goto(ctx, 1))
+
+	db.applied[7] = appliedMigration{Version: 7, Name: "removed", Checksum: "x", AppliedAt: time.Now()}
+	applied := db.applied[1]
+	applied.Checksum = "changed"
+	db.applied[1] = applied
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"modified", "pending", "missing", "pending"}, states(statuses))
+	assert.ErrorContains(t, m.Up(ctx), "create_a was modified after being applied")
+}
+
+func states(statuses []Status) []string {
+	states := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		states = append(states, status.State())
+	}
+	return states
+}
@@ -0,0 +1,138 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// plsqlStart matches the statements ended by a line holding only "/", their body containing ";"
+var plsqlStart = regexp.MustCompile(`(?is)^(BEGIN|DECLARE|CREATE\s+(OR\s+REPLACE\s+)?((NON)?EDITIONABLE\s+)?(FUNCTION|PROCEDURE|PACKAGE|TRIGGER|TYPE|LIBRARY))\b`)
+
+// qQuoteClosing are the closing delimiters of Oracle q'[...]' literals opened by another one than themselves
+var qQuoteClosing = map[byte]byte{'[': ']', '(': ')', '{': '}', '<': '>'}
+
+// Split splits script into statements the way SQL*Plus does: a SQL statement ends with ";", which is
+// dropped, a PL/SQL block or a stored unit ends with a line holding only "/". Semicolons in literals,
+// quoted identifiers and comments are ignored, comments between statements are dropped.
+func Split(script string) ([]string, error) {
+	var (
+		statements []string
+		start      = -1
+		plsql      bool
+	)
+	end := func(i int) {
+		if statement := strings.TrimSpace(script[start:i]); statement != "" {
+			statements = append(statements, statement)
+		}
+		start = -1
+	}
+
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case start < 0 && isSpace(c):
+			i++
+			continue
+		case strings.HasPrefix(script[i:], "--"):
+			i = endOfLine(script, i)
+			continue
+		case strings.HasPrefix(script[i:], "/*"):
+			closing := strings.Index(script[i+2:], "*/")
+			if closing < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			i += closing + 4
+			continue
+		}
+
+		if start < 0 {
+			if c == '/' && blankUntilEndOfLine(script, i+1) {
+				// terminator of a statement already ended by ";"
+				i = endOfLine(script, i)
+				continue
+			}
+			start, plsql = i, plsqlStart.MatchString(script[i:])
+		}
+
+		switch {
+		case c == '\'' || c == '"':
+			closing, err := endOfQuoted(script, i, c)
+			if err != nil {
+				return nil, err
+			}
+			i = closing
+		case (c == 'q' || c == 'Q') && strings.HasPrefix(script[i+1:], "'") && i+2 < len(script) && (i == 0 || !isIdentifier(script[i-1])):
+			delimiter := script[i+2]
+			if closing, ok := qQuoteClosing[delimiter]; ok {
+				delimiter = closing
+			}
+			closing := strings.Index(script[i+3:], string(delimiter)+"'")
+			if closing < 0 {
+				return nil, fmt.Errorf("unterminated literal at offset %d", i)
+			}
+			i += closing + 5
+		case c == ';' && !plsql:
+			end(i)
+			i++
+		case c == '/' && blankFromStartOfLine(script, i) && blankUntilEndOfLine(script, i+1):
+			end(i)
+			i = endOfLine(script, i)
+		default:
+			i++
+		}
+	}
+	if start >= 0 {
+		end(len(script))
+	}
+	return statements, nil
+}
+
+// endOfQuoted returns the offset following the literal or quoted identifier opened by quote at i,
+// a doubled quote being an escaped one
+func endOfQuoted(script string, i int, quote byte) (int, error) {
+	for j := i + 1; j < len(script); j++ {
+		if script[j] != quote {
+			continue
+		}
+		if j+1 < len(script) && script[j+1] == quote {
+			j++
+			continue
+		}
+		return j + 1, nil
+	}
+	return 0, fmt.Errorf("unterminated literal at offset %d", i)
+}
+
+func endOfLine(script string, i int) int {
+	if newline := strings.IndexByte(script[i:], '\n'); newline >= 0 {
+		return i + newline + 1
+	}
+	return len(script)
+}
+
+func blankUntilEndOfLine(script string, i int) bool {
+	for ; i < len(script) && script[i] != '\n'; i++ {
+		if !isSpace(script[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func blankFromStartOfLine(script string, i int) bool {
+	for i--; i >= 0 && script[i] != '\n'; i-- {
+		if !isSpace(script[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isIdentifier(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
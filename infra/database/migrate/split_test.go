@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	testCases := []struct {
+		name       string
+		script     string
+		statements []string
+	}{
+		{
+			name:       "statements",
+			script:     "CREATE TABLE A (ID NUMBER);\n\nINSERT INTO A VALUES (1);\nCOMMIT;\n",
+			statements: []string{"CREATE TABLE A (ID NUMBER)", "INSERT INTO A VALUES (1)", "COMMIT"},
+		},
+		{
+			name:       "without a last semicolon",
+			script:     "DROP TABLE MEMBER",
+			statements: []string{"DROP TABLE MEMBER"},
+		},
+		{
+			name:       "semicolons in literals and comments",
+			script:     "-- drop; then create\nINSERT INTO A VALUES ('a;b', q'[it's; fine]', \"x;y\"); /* c; d */\nSELECT 4/2 FROM dual;",
+			statements: []string{`INSERT INTO A VALUES ('a;b', q'[it's; fine]', "x;y")`, "SELECT 4/2 FROM dual"},
+		},
+		{
+			name:       "escaped quote",
+			script:     "INSERT INTO A VALUES ('it''s;');",
+			statements: []string{"INSERT INTO A VALUES ('it''s;')"},
+		},
+		{
+			name: "plsql block",
+			script: "BEGIN\n  EXECUTE IMMEDIATE 'DROP TABLE A';\nEXCEPTION\n  WHEN OTHERS THEN NULL;\nEND;\n/\n" +
+				"CREATE INDEX A_IDX ON A (ID);",
+			statements: []string{
+				"BEGIN\n  EXECUTE IMMEDIATE 'DROP TABLE A';\nEXCEPTION\n  WHEN OTHERS THEN NULL;\nEND;",
+				"CREATE INDEX A_IDX ON A (ID)",
+			},
+		},
+		{
+			name: "stored units",
+			script: "create or replace editionable trigger A_TRG before insert on A for each row\nbegin\n  :new.ID := 1;\nend;\n/\n" +
+				"CREATE OR REPLACE PACKAGE BODY P AS\n  PROCEDURE X IS BEGIN NULL; END;\nEND P;\n  /  \n",
+			statements: []string{
+				"create or replace editionable trigger A_TRG before insert on A for each row\nbegin\n  :new.ID := 1;\nend;",
+				"CREATE OR REPLACE PACKAGE BODY P AS\n  PROCEDURE X IS BEGIN NULL; END;\nEND P;",
+			},
+		},
+		{
+			name:       "slash after a statement",
+			script:     "CREATE TABLE A (ID NUMBER);\n/\nDECLARE\n  n NUMBER;\nBEGIN\n  n := 1;\nEND;",
+			statements: []string{"CREATE TABLE A (ID NUMBER)", "DECLARE\n  n NUMBER;\nBEGIN\n  n := 1;\nEND;"},
+		},
+		{
+			name:   "comments only",
+			script: "-- nothing\n/* to do */\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			statements, err := Split(tc.script)
+			require.NoError(t, err)
+			assert.Equal(t, tc.statements, statements)
+		})
+	}
+}
+
+func TestSplit_Unterminated(t *testing.T) {
+	for _, script := range []string{"INSERT INTO A VALUES ('a);", "SELECT 1 FROM dual /* ;", "SELECT q'[a' FROM dual;"} {
+		_, err := Split(script)
+		assert.Error(t, err, script)
+	}
+}
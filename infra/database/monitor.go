@@ -5,25 +5,30 @@ import (
 	"time"
 )
 
+// Event is a database operation measured from StartMetrics until QueryMonitor.Succeeded or Failed
 type Event struct {
+	// Name of the operation, the caller of the repository method
 	Name string
-	Time time.Time
+	// Target is TARGET_MASTER, TARGET_SLAVE or TARGET_TX
+	Target string
+	Time   time.Time
 }
 
 type ContextKey string
 
-const MongoEventContextKey ContextKey = "MysqlEvent"
+const QueryEventContextKey ContextKey = "OracleEvent"
 
-type MysqlMonitor struct {
+// QueryMonitor records the latency and the errors of the events started with StartMetrics
+type QueryMonitor struct {
 }
 
 func StartMetrics(ctx context.Context, event Event) context.Context {
 	event.Time = time.Now()
-	return context.WithValue(ctx, MongoEventContextKey, event)
+	return context.WithValue(ctx, QueryEventContextKey, event)
 }
 
 func getEventFromContext(ctx context.Context) *Event {
-	val := ctx.Value(MongoEventContextKey)
+	val := ctx.Value(QueryEventContextKey)
 	if val == nil {
 		return nil
 	}
@@ -33,23 +38,27 @@ func getEventFromContext(ctx context.Context) *Event {
 	return &event
 }
 
-func NewCommandMonitor() MysqlMonitor {
-	return MysqlMonitor{}
+func NewQueryMonitor() QueryMonitor {
+	return QueryMonitor{}
 }
 
-func (m *MysqlMonitor) Succeeded(ctx context.Context) {
-	eventMysqlFromClient := getEventFromContext(ctx)
-	if eventMysqlFromClient == nil {
+// Succeeded records the latency of the event of ctx
+func (m *QueryMonitor) Succeeded(ctx context.Context) {
+	event := getEventFromContext(ctx)
+	if event == nil {
 		return
 	}
 
+	observeQuery(event.Name, event.Target, time.Since(event.Time))
 }
 
-func (m *MysqlMonitor) Failed(ctx context.Context) {
-
-	eventMysqlFromClient := getEventFromContext(ctx)
-	if eventMysqlFromClient == nil {
+// Failed records the latency and the error of the event of ctx, code being the ORA code of the error or empty
+func (m *QueryMonitor) Failed(ctx context.Context, code string) {
+	event := getEventFromContext(ctx)
+	if event == nil {
 		return
 	}
 
+	observeQuery(event.Name, event.Target, time.Since(event.Time))
+	queryErrors.WithLabelValues(event.Name, event.Target, code).Inc()
 }
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleCount(t *testing.T, operation, target string) uint64 {
+	metric := &dto.Metric{}
+	require.NoError(t, queryDuration.WithLabelValues(operation, target).(prometheus.Histogram).Write(metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestQueryMonitor(t *testing.T) {
+	monitor := NewQueryMonitor()
+	ctx := StartMetrics(context.Background(), Event{Name: "member.FindById", Target: TARGET_SLAVE})
+
+	monitor.Succeeded(ctx)
+	monitor.Failed(ctx, "ORA-03113")
+	monitor.Failed(context.Background(), "ORA-03113")
+
+	assert.Equal(t, uint64(2), sampleCount(t, "member.FindById", TARGET_SLAVE), "events without StartMetrics are ignored")
+	assert.Equal(t, 1.0, testutil.ToFloat64(queryErrors.WithLabelValues("member.FindById", TARGET_SLAVE, "ORA-03113")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(queryErrors.WithLabelValues("member.FindById", TARGET_SLAVE, "")))
+}
@@ -0,0 +1,306 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+)
+
+// ErrNoReplicaAvailable is returned by a Balancer when every replica is ejected or its circuit breaker is open
+var ErrNoReplicaAvailable = errors.New("no replica available")
+
+// NoReplicaAvailableError is the ErrNoReplicaAvailable of a Balancer, which may be tried again after RetryAfter
+type NoReplicaAvailableError struct {
+	Retry time.Duration
+}
+
+func (e *NoReplicaAvailableError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", ErrNoReplicaAvailable, e.Retry)
+}
+
+func (e *NoReplicaAvailableError) Is(target error) bool {
+	return target == ErrNoReplicaAvailable
+}
+
+// RetryAfter is the ping interval, after which an ejected replica may be readmitted
+func (e *NoReplicaAvailableError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// balancing policies of a Balancer
+const (
+	BALANCE_ROUND_ROBIN     = "round_robin"
+	BALANCE_LEAST_IN_FLIGHT = "least_in_flight"
+)
+
+const (
+	DEFAULT_REPLICA_PING_INTERVAL = 5 * time.Second
+	DEFAULT_REPLICA_PING_TIMEOUT  = 2 * time.Second
+)
+
+// BalancerConfig configures how a Balancer spreads reads and checks its replicas
+type BalancerConfig struct {
+	// Policy is BALANCE_ROUND_ROBIN, the default, or BALANCE_LEAST_IN_FLIGHT
+	Policy string
+	// PingInterval between two pings of every replica, a replica failing one is ejected until one succeeds
+	PingInterval time.Duration
+	PingTimeout  time.Duration
+}
+
+// Replica is one read replica of a Balancer
+type Replica struct {
+	// Name of the replica in logs, metrics and health, e.g. its connect string
+	Name string
+	DB   SlaveDB
+}
+
+// ReplicaState is the state of a replica of a Balancer
+type ReplicaState struct {
+	Name     string
+	Healthy  bool
+	InFlight int64
+}
+
+// AvailableDB is a database telling whether it can serve queries right now
+type AvailableDB interface {
+	Available() bool
+}
+
+type replica struct {
+	Replica
+	healthy  atomic.Bool
+	inFlight atomic.Int64
+}
+
+// available reports whether r answered its last ping and its circuit breaker, if any, is not open
+func (r *replica) available() bool {
+	if !r.healthy.Load() {
+		return false
+	}
+	if db, ok := r.DB.(AvailableDB); ok {
+		return db.Available()
+	}
+	return true
+}
+
+// Balancer is a SlaveDB spreading reads over several replicas, e.g. Active Data Guard standbys.
+// Replicas are pinged in the background: one failing a ping is ejected, one answering again is readmitted.
+// A statement prepared or rows queried stay on the replica they came from.
+type Balancer struct {
+	replicas []*replica
+	config   BalancerConfig
+	next     atomic.Uint64
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewBalancer pings replicas once, then returns a Balancer pinging them every config.PingInterval until Close
+func NewBalancer(config BalancerConfig, replicas ...Replica) (*Balancer, error) {
+	if len(replicas) == 0 {
+		return nil, errors.New("balancer needs at least one replica")
+	}
+	switch config.Policy {
+	case "":
+		config.Policy = BALANCE_ROUND_ROBIN
+	case BALANCE_ROUND_ROBIN, BALANCE_LEAST_IN_FLIGHT:
+	default:
+		return nil, fmt.Errorf("unknown balancing policy %q", config.Policy)
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = DEFAULT_REPLICA_PING_INTERVAL
+	}
+	if config.PingTimeout <= 0 {
+		config.PingTimeout = DEFAULT_REPLICA_PING_TIMEOUT
+	}
+
+	b := &Balancer{config: config, stop: make(chan struct{})}
+	for _, r := range replicas {
+		rep := &replica{Replica: r}
+		rep.healthy.Store(true)
+		b.replicas = append(b.replicas, rep)
+	}
+	b.ping()
+
+	b.done.Add(1)
+	go b.pingEvery(config.PingInterval)
+	return b, nil
+}
+
+func (b *Balancer) pingEvery(interval time.Duration) {
+	defer b.done.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.ping()
+		}
+	}
+}
+
+// ping pings every replica concurrently, ejecting or readmitting it
+func (b *Balancer) ping() {
+	var wg sync.WaitGroup
+	for _, r := range b.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), b.config.PingTimeout)
+			defer cancel()
+
+			err := pingContext(ctx, r.DB)
+			healthy := err == nil
+			if r.healthy.Swap(healthy) != healthy {
+				if healthy {
+					slog.Warn(fmt.Sprintf("replica %s readmitted", r.Name))
+				} else {
+					slog.Warn(fmt.Sprintf("replica %s ejected: %v", r.Name, err))
+				}
+			}
+			database.SetReplicaUp(r.Name, healthy)
+		}()
+	}
+	wg.Wait()
+}
+
+// pingContext pings db within ctx. The wrappers of this package forward PingContext, so only a DB without it
+// is pinged without a deadline.
+func pingContext(ctx context.Context, db DB) error {
+	if pinger, ok := db.(interface{ PingContext(context.Context) error }); ok {
+		return pinger.PingContext(ctx)
+	}
+	return db.Ping()
+}
+
+// pick returns the replica serving the next read as the policy says, skipping the unavailable ones
+func (b *Balancer) pick() (*replica, error) {
+	start := int(b.next.Add(1) - 1)
+	var picked *replica
+	for i := range b.replicas {
+		r := b.replicas[(start+i)%len(b.replicas)]
+		if !r.available() {
+			continue
+		}
+		if b.config.Policy == BALANCE_ROUND_ROBIN {
+			return r, nil
+		}
+		if picked == nil || r.inFlight.Load() < picked.inFlight.Load() {
+			picked = r
+		}
+	}
+	if picked == nil {
+		return nil, &NoReplicaAvailableError{Retry: b.config.PingInterval}
+	}
+	return picked, nil
+}
+
+// run calls fn with the replica picked for it, counted in flight until fn returns
+func (b *Balancer) run(fn func(db SlaveDB) error) error {
+	r, err := b.pick()
+	if err != nil {
+		return err
+	}
+	r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+	return fn(r.DB)
+}
+
+// Available reports whether a replica can serve reads
+func (b *Balancer) Available() bool {
+	for _, r := range b.replicas {
+		if r.available() {
+			return true
+		}
+	}
+	return false
+}
+
+// Replicas returns the state of every replica of b
+func (b *Balancer) Replicas() []ReplicaState {
+	states := make([]ReplicaState, 0, len(b.replicas))
+	for _, r := range b.replicas {
+		states = append(states, ReplicaState{Name: r.Name, Healthy: r.available(), InFlight: r.inFlight.Load()})
+	}
+	return states
+}
+
+func (b *Balancer) Rebind(query string) string {
+	return b.replicas[0].DB.Rebind(query)
+}
+
+// Ping succeeds when a replica answers
+func (b *Balancer) Ping() error {
+	var errs []error
+	for _, r := range b.replicas {
+		err := r.DB.Ping()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("replica %s: %w", r.Name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops pinging and closes every replica
+func (b *Balancer) Close() error {
+	close(b.stop)
+	b.done.Wait()
+	var errs []error
+	for _, r := range b.replicas {
+		if err := r.DB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("replica %s: %w", r.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Balancer) PreparexContext(ctx context.Context, query string) (stmt SlaveStatement, err error) {
+	err = b.run(func(db SlaveDB) error {
+		stmt, err = db.PreparexContext(ctx, query)
+		return err
+	})
+	return stmt, err
+}
+
+func (b *Balancer) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return b.run(func(db SlaveDB) error {
+		return db.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// QueryRowxContext runs on the first replica when none is available, the row reporting the error on Scan
+func (b *Balancer) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	r, err := b.pick()
+	if err != nil {
+		r = b.replicas[0]
+	}
+	r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+	return r.DB.QueryRowxContext(ctx, query, args...)
+}
+
+func (b *Balancer) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return b.run(func(db SlaveDB) error {
+		return db.GetContext(ctx, dest, query, args...)
+	})
+}
+
+// QueryxContext counts the query in flight until it returns, not until rows are closed
+func (b *Balancer) QueryxContext(ctx context.Context, query string, args ...interface{}) (rows *sqlx.Rows, err error) {
+	err = b.run(func(db SlaveDB) error {
+		rows, err = db.QueryxContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
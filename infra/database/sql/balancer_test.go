@@ -0,0 +1,171 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/response"
+)
+
+var errPing = errors.New("ORA-12541: TNS:no listener")
+
+// fakeReplica is a SlaveDB recording the reads it served, GetContext blocking while block is set
+// and PingContext until its context ends when hung is set
+type fakeReplica struct {
+	SlaveDB
+	reads atomic.Int64
+	down  atomic.Bool
+	hung  atomic.Bool
+	block chan struct{}
+}
+
+func (f *fakeReplica) Ping() error {
+	if f.down.Load() {
+		return errPing
+	}
+	return nil
+}
+
+func (f *fakeReplica) PingContext(ctx context.Context) error {
+	if f.hung.Load() {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return f.Ping()
+}
+
+func (f *fakeReplica) Close() error {
+	return nil
+}
+
+func (f *fakeReplica) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	f.reads.Add(1)
+	if f.block != nil {
+		<-f.block
+	}
+	return nil
+}
+
+func newTestBalancer(t *testing.T, policy string, replicas ...SlaveDB) *Balancer {
+	named := make([]Replica, 0, len(replicas))
+	for i, db := range replicas {
+		named = append(named, Replica{Name: string(rune('a' + i)), DB: db})
+	}
+	b, err := NewBalancer(BalancerConfig{Policy: policy, PingInterval: time.Hour}, named...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+func TestNewBalancer_Invalid(t *testing.T) {
+	_, err := NewBalancer(BalancerConfig{})
+	assert.Error(t, err)
+
+	_, err = NewBalancer(BalancerConfig{Policy: "random"}, Replica{Name: "a", DB: &fakeReplica{}})
+	assert.Error(t, err)
+}
+
+func TestBalancer_RoundRobin(t *testing.T) {
+	a, b := &fakeReplica{}, &fakeReplica{}
+	balancer := newTestBalancer(t, BALANCE_ROUND_ROBIN, a, b)
+
+	for range 4 {
+		assert.NoError(t, balancer.GetContext(context.Background(), nil, "SELECT 1 FROM dual"))
+	}
+
+	assert.Equal(t, int64(2), a.reads.Load())
+	assert.Equal(t, int64(2), b.reads.Load())
+}
+
+func TestBalancer_LeastInFlight(t *testing.T) {
+	a, b := &fakeReplica{block: make(chan struct{})}, &fakeReplica{}
+	balancer := newTestBalancer(t, BALANCE_LEAST_IN_FLIGHT, a, b)
+
+	done := make(chan struct{})
+	go func() {
+		_ = balancer.GetContext(context.Background(), nil, "SELECT 1 FROM dual")
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return a.reads.Load() == 1 }, time.Second, time.Millisecond)
+
+	for range 2 {
+		assert.NoError(t, balancer.GetContext(context.Background(), nil, "SELECT 1 FROM dual"))
+	}
+	assert.Equal(t, int64(2), b.reads.Load(), "a is busy")
+
+	close(a.block)
+	<-done
+}
+
+func TestBalancer_EjectsAndReadmits(t *testing.T) {
+	a, b := &fakeReplica{}, &fakeReplica{}
+	a.down.Store(true)
+	balancer := newTestBalancer(t, BALANCE_ROUND_ROBIN, a, b)
+
+	for range 2 {
+		assert.NoError(t, balancer.GetContext(context.Background(), nil, "SELECT 1 FROM dual"))
+	}
+	assert.Equal(t, int64(0), a.reads.Load(), "ejected on the first ping")
+	assert.Equal(t, []ReplicaState{{Name: "a"}, {Name: "b", Healthy: true}}, balancer.Replicas())
+
+	b.down.Store(true)
+	balancer.ping()
+	assert.False(t, balancer.Available())
+	err := balancer.GetContext(context.Background(), nil, "SELECT 1 FROM dual")
+	assert.ErrorIs(t, err, ErrNoReplicaAvailable)
+	var unavailable response.RetryAfterError
+	if assert.ErrorAs(t, err, &unavailable) {
+		assert.Equal(t, balancer.config.PingInterval, unavailable.RetryAfter())
+	}
+	assert.Error(t, balancer.Ping())
+
+	a.down.Store(false)
+	balancer.ping()
+	assert.True(t, balancer.Available())
+	assert.NoError(t, balancer.GetContext(context.Background(), nil, "SELECT 1 FROM dual"))
+	assert.Equal(t, int64(1), a.reads.Load())
+}
+
+func TestBalancer_SkipsOpenBreaker(t *testing.T) {
+	a, b := &fakeReplica{}, &fakeReplica{}
+	breaker := NewBreaker("a", BreakerConfig{FailureRate: 0.5, MinRequests: 1})
+	_ = breaker.Run(func() error { return driver.ErrBadConn })
+	balancer := newTestBalancer(t, BALANCE_ROUND_ROBIN, WithSlaveBreaker(a, breaker), b)
+
+	for range 2 {
+		assert.NoError(t, balancer.GetContext(context.Background(), nil, "SELECT 1 FROM dual"))
+	}
+
+	assert.Equal(t, int64(0), a.reads.Load())
+	assert.Equal(t, int64(2), b.reads.Load())
+}
+
+func TestBalancer_PingTimeoutOfWrappedReplica(t *testing.T) {
+	a, b := &fakeReplica{}, &fakeReplica{}
+	wrapped := WithSlaveBreaker(WithSlaveStatementCache(a, "a", 1), NewBreaker("a", BreakerConfig{FailureRate: 0.5}))
+	balancer, err := NewBalancer(BalancerConfig{PingInterval: time.Hour, PingTimeout: 10 * time.Millisecond},
+		Replica{Name: "a", DB: wrapped}, Replica{Name: "b", DB: b})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = balancer.Close() })
+
+	a.hung.Store(true)
+	done := make(chan struct{})
+	go func() {
+		balancer.ping()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a hung replica blocks the ping round past PingTimeout")
+	}
+	assert.Equal(t, []ReplicaState{{Name: "a"}, {Name: "b", Healthy: true}}, balancer.Replicas())
+}
@@ -0,0 +1,397 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godror/godror"
+	"github.com/jmoiron/sqlx"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+)
+
+// ErrDatabaseUnavailable is returned without calling the database while its circuit breaker is open
+var ErrDatabaseUnavailable = errors.New("database unavailable")
+
+// DatabaseUnavailableError is the ErrDatabaseUnavailable of one database, which may be tried again after RetryAfter
+type DatabaseUnavailableError struct {
+	Database string
+	Retry    time.Duration
+}
+
+func (e *DatabaseUnavailableError) Error() string {
+	return fmt.Sprintf("%s %v, retry after %v", e.Database, ErrDatabaseUnavailable, e.Retry)
+}
+
+func (e *DatabaseUnavailableError) Is(target error) bool {
+	return target == ErrDatabaseUnavailable
+}
+
+// RetryAfter is how long the breaker stays open
+func (e *DatabaseUnavailableError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// BreakerState is the state of a Breaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails every call with ErrDatabaseUnavailable until BreakerConfig.OpenTimeout elapses
+	BreakerOpen
+	// BreakerHalfOpen lets BreakerConfig.HalfOpenTrials calls through to decide whether to close again
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+const (
+	DEFAULT_BREAKER_MIN_REQUESTS     = 20
+	DEFAULT_BREAKER_WINDOW           = 10 * time.Second
+	DEFAULT_BREAKER_OPEN_TIMEOUT     = 30 * time.Second
+	DEFAULT_BREAKER_HALF_OPEN_TRIALS = 3
+)
+
+// BreakerConfig configures when a Breaker opens and how it recovers
+type BreakerConfig struct {
+	// FailureRate of the calls of a Window opening the breaker, between 0 and 1
+	FailureRate float64
+	// MinRequests in a Window before the failure rate is considered
+	MinRequests int
+	Window      time.Duration
+	// OpenTimeout before the breaker lets trial calls through
+	OpenTimeout time.Duration
+	// HalfOpenTrials that must succeed to close the breaker, one failure opens it again
+	HalfOpenTrials int
+	// IsFailure tells the errors of an unavailable database from the errors of a query, e.g. a constraint
+	// violation. By default only a bad connection is a failure. A cancelled or timed out call, see IsCancellation,
+	// is never counted: a slow database is still available.
+	IsFailure func(error) bool
+}
+
+// Breaker is a failure-rate circuit breaker guarding one database
+type Breaker struct {
+	name   string
+	config BreakerConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	trials      int
+	successes   int
+}
+
+// NewBreaker returns a closed breaker of the database called name
+func NewBreaker(name string, config BreakerConfig) *Breaker {
+	if config.MinRequests <= 0 {
+		config.MinRequests = DEFAULT_BREAKER_MIN_REQUESTS
+	}
+	if config.Window <= 0 {
+		config.Window = DEFAULT_BREAKER_WINDOW
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = DEFAULT_BREAKER_OPEN_TIMEOUT
+	}
+	if config.HalfOpenTrials <= 0 {
+		config.HalfOpenTrials = DEFAULT_BREAKER_HALF_OPEN_TRIALS
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = IsUnavailable
+	}
+	b := &Breaker{name: name, config: config, now: time.Now}
+	b.windowStart = b.now()
+	database.SetBreakerState(name, int(BreakerClosed))
+	return b
+}
+
+// ORA_USER_CANCEL is the ORA code of a call cancelled by the client, e.g. when its context ends
+const ORA_USER_CANCEL = 1013
+
+// IsUnavailable reports whether err means the connection to the database is lost
+func IsUnavailable(err error) bool {
+	if IsCancellation(err) {
+		return false
+	}
+	return errors.Is(err, driver.ErrBadConn) || godror.IsBadConn(err)
+}
+
+// IsCancellation reports whether err ended a call which was cancelled or timed out, by its context or by
+// ORA-01013, which says nothing of the availability of the database
+func IsCancellation(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if oraErr, ok := godror.AsOraErr(err); ok {
+		return oraErr.Code() == ORA_USER_CANCEL
+	}
+	return err != nil && strings.Contains(err.Error(), fmt.Sprintf("ORA-%05d", ORA_USER_CANCEL))
+}
+
+// Name of the database guarded by b
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state of b
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && !b.now().Before(b.openedAt.Add(b.config.OpenTimeout)) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Run calls fn unless b is open, in which case a DatabaseUnavailableError is returned right away.
+// A call ending with a cancellation, see IsCancellation, counts neither as a success nor as a failure.
+func (b *Breaker) Run(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	if IsCancellation(err) {
+		b.release()
+		return err
+	}
+	b.done(err != nil && b.config.IsFailure(err))
+	return err
+}
+
+// release ends a call allowed by b without counting it
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen {
+		b.trials--
+	}
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+
+	switch b.state {
+	case BreakerOpen:
+		reopen := b.openedAt.Add(b.config.OpenTimeout)
+		if now.Before(reopen) {
+			return &DatabaseUnavailableError{Database: b.name, Retry: reopen.Sub(now)}
+		}
+		b.setState(BreakerHalfOpen)
+		b.trials, b.successes = 0, 0
+		fallthrough
+	case BreakerHalfOpen:
+		if b.trials >= b.config.HalfOpenTrials-b.successes {
+			return &DatabaseUnavailableError{Database: b.name, Retry: b.config.OpenTimeout}
+		}
+		b.trials++
+	default:
+		if now.Sub(b.windowStart) >= b.config.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+	}
+	return nil
+}
+
+func (b *Breaker) done(failure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerHalfOpen:
+		b.trials--
+		if failure {
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenTrials {
+			b.setState(BreakerClosed)
+			b.windowStart, b.requests, b.failures = b.now(), 0, 0
+		}
+	case BreakerClosed:
+		b.requests++
+		if failure {
+			b.failures++
+		}
+		if b.requests >= b.config.MinRequests && float64(b.failures)/float64(b.requests) >= b.config.FailureRate {
+			b.open()
+		}
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.setState(BreakerOpen)
+}
+
+func (b *Breaker) setState(state BreakerState) {
+	if b.state != state {
+		slog.Warn(fmt.Sprintf("circuit breaker of %s database %s", b.name, state))
+	}
+	b.state = state
+	database.SetBreakerState(b.name, int(state))
+}
+
+// BreakerDB is a database guarded by a Breaker, see WithMasterBreaker and WithSlaveBreaker
+type BreakerDB interface {
+	Breaker() *Breaker
+}
+
+type masterDBBreaker struct {
+	MasterDB
+	breaker *Breaker
+}
+
+// WithMasterBreaker returns db failing fast with ErrDatabaseUnavailable while breaker is open.
+// Rows of QueryRowxContext and QueryRowContext report their error on Scan, so they are not guarded.
+func WithMasterBreaker(db MasterDB, breaker *Breaker) MasterDB {
+	return &masterDBBreaker{MasterDB: db, breaker: breaker}
+}
+
+func (m *masterDBBreaker) Breaker() *Breaker {
+	return m.breaker
+}
+
+func (m *masterDBBreaker) Unwrap() DB {
+	return m.MasterDB
+}
+
+// PingContext pings db within ctx, not guarded by the breaker
+func (m *masterDBBreaker) PingContext(ctx context.Context) error {
+	return pingContext(ctx, m.MasterDB)
+}
+
+// Available reports whether the breaker lets queries through
+func (m *masterDBBreaker) Available() bool {
+	return m.breaker.State() != BreakerOpen
+}
+
+func (m *masterDBBreaker) BeginTxx(ctx context.Context, opts *sql.TxOptions) (tx *sqlx.Tx, err error) {
+	err = m.breaker.Run(func() error {
+		tx, err = m.MasterDB.BeginTxx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+func (m *masterDBBreaker) Beginx() (tx *sqlx.Tx, err error) {
+	err = m.breaker.Run(func() error {
+		tx, err = m.MasterDB.Beginx()
+		return err
+	})
+	return tx, err
+}
+
+func (m *masterDBBreaker) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	err = m.breaker.Run(func() error {
+		result, err = m.MasterDB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (m *masterDBBreaker) PreparexContext(ctx context.Context, query string) (stmt MasterStatement, err error) {
+	err = m.breaker.Run(func() error {
+		stmt, err = m.MasterDB.PreparexContext(ctx, query)
+		return err
+	})
+	return stmt, err
+}
+
+func (m *masterDBBreaker) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	err = m.breaker.Run(func() error {
+		rows, err = m.MasterDB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (m *masterDBBreaker) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return m.breaker.Run(func() error {
+		return m.MasterDB.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func (m *masterDBBreaker) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return m.breaker.Run(func() error {
+		return m.MasterDB.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+type slaveDBBreaker struct {
+	SlaveDB
+	breaker *Breaker
+}
+
+// WithSlaveBreaker returns db failing fast with ErrDatabaseUnavailable while breaker is open.
+// Rows of QueryRowxContext report their error on Scan, so they are not guarded.
+func WithSlaveBreaker(db SlaveDB, breaker *Breaker) SlaveDB {
+	return &slaveDBBreaker{SlaveDB: db, breaker: breaker}
+}
+
+func (s *slaveDBBreaker) Breaker() *Breaker {
+	return s.breaker
+}
+
+func (s *slaveDBBreaker) Unwrap() DB {
+	return s.SlaveDB
+}
+
+// PingContext pings db within ctx, not guarded by the breaker
+func (s *slaveDBBreaker) PingContext(ctx context.Context) error {
+	return pingContext(ctx, s.SlaveDB)
+}
+
+// Available reports whether the breaker lets queries through
+func (s *slaveDBBreaker) Available() bool {
+	return s.breaker.State() != BreakerOpen
+}
+
+func (s *slaveDBBreaker) PreparexContext(ctx context.Context, query string) (stmt SlaveStatement, err error) {
+	err = s.breaker.Run(func() error {
+		stmt, err = s.SlaveDB.PreparexContext(ctx, query)
+		return err
+	})
+	return stmt, err
+}
+
+func (s *slaveDBBreaker) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return s.breaker.Run(func() error {
+		return s.SlaveDB.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+func (s *slaveDBBreaker) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return s.breaker.Run(func() error {
+		return s.SlaveDB.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func (s *slaveDBBreaker) QueryxContext(ctx context.Context, query string, args ...interface{}) (rows *sqlx.Rows, err error) {
+	err = s.breaker.Run(func() error {
+		rows, err = s.SlaveDB.QueryxContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
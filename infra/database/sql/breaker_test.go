@@ -0,0 +1,147 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errQuery = errors.New("ORA-00001: unique constraint violated")
+
+// newTestBreaker returns a breaker opening when half of at least 4 calls fail, on a clock moved by the returned func
+func newTestBreaker() (*Breaker, func(time.Duration)) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker("test", BreakerConfig{
+		FailureRate:    0.5,
+		MinRequests:    4,
+		Window:         time.Minute,
+		OpenTimeout:    10 * time.Second,
+		HalfOpenTrials: 2,
+	})
+	b.now = func() time.Time { return now }
+	b.windowStart = now
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+func run(b *Breaker, err error) error {
+	return b.Run(func() error { return err })
+}
+
+func TestBreaker_OpensOnFailureRate(t *testing.T) {
+	b, _ := newTestBreaker()
+
+	assert.NoError(t, run(b, nil))
+	assert.ErrorIs(t, run(b, errQuery), errQuery, "query errors are not failures")
+	assert.ErrorIs(t, run(b, driver.ErrBadConn), driver.ErrBadConn)
+	assert.Equal(t, BreakerClosed, b.State(), "below MinRequests")
+	assert.ErrorIs(t, run(b, driver.ErrBadConn), driver.ErrBadConn)
+
+	assert.Equal(t, BreakerOpen, b.State())
+	called := false
+	err := b.Run(func() error { called = true; return nil })
+	var unavailable *DatabaseUnavailableError
+	assert.ErrorAs(t, err, &unavailable)
+	assert.ErrorIs(t, err, ErrDatabaseUnavailable)
+	assert.Equal(t, 10*time.Second, unavailable.RetryAfter())
+	assert.False(t, called)
+}
+
+func TestBreaker_WindowResetsCounts(t *testing.T) {
+	b, advance := newTestBreaker()
+	for range 3 {
+		_ = run(b, driver.ErrBadConn)
+	}
+
+	advance(time.Minute)
+	_ = run(b, driver.ErrBadConn)
+
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestBreaker_HalfOpenCloses(t *testing.T) {
+	b, advance := newTestBreaker()
+	for range 4 {
+		_ = run(b, driver.ErrBadConn)
+	}
+
+	advance(10 * time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.NoError(t, run(b, nil))
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.NoError(t, run(b, nil))
+
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestBreaker_HalfOpenLimitsTrials(t *testing.T) {
+	b, advance := newTestBreaker()
+	for range 4 {
+		_ = run(b, driver.ErrBadConn)
+	}
+	advance(10 * time.Second)
+
+	var third error
+	_ = b.Run(func() error {
+		_ = b.Run(func() error {
+			third = run(b, nil)
+			return nil
+		})
+		return nil
+	})
+
+	assert.ErrorIs(t, third, ErrDatabaseUnavailable, "only HalfOpenTrials calls in flight")
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	b, advance := newTestBreaker()
+	for range 4 {
+		_ = run(b, driver.ErrBadConn)
+	}
+
+	advance(10 * time.Second)
+	_ = run(b, driver.ErrBadConn)
+
+	assert.Equal(t, BreakerOpen, b.State())
+	advance(5 * time.Second)
+	assert.ErrorIs(t, run(b, nil), ErrDatabaseUnavailable)
+}
+
+func TestBreaker_TimeoutsDoNotOpen(t *testing.T) {
+	b, advance := newTestBreaker()
+	cancelled := errors.New("dpiStmt_execute: ORA-01013: user requested cancel of current operation")
+
+	for range 4 {
+		assert.ErrorIs(t, run(b, context.DeadlineExceeded), context.DeadlineExceeded)
+		assert.ErrorIs(t, run(b, context.Canceled), context.Canceled)
+		assert.ErrorIs(t, run(b, cancelled), cancelled)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+
+	assert.NoError(t, run(b, nil))
+	assert.NoError(t, run(b, nil))
+	_ = run(b, driver.ErrBadConn)
+	assert.Equal(t, BreakerClosed, b.State(), "timeouts are not counted as requests either")
+
+	for range 4 {
+		_ = run(b, driver.ErrBadConn)
+	}
+	advance(10 * time.Second)
+	_ = run(b, context.DeadlineExceeded)
+	assert.Equal(t, BreakerHalfOpen, b.State(), "a timed out trial neither closes nor reopens")
+	assert.NoError(t, run(b, nil))
+	assert.NoError(t, run(b, nil))
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestIsUnavailable(t *testing.T) {
+	assert.True(t, IsUnavailable(driver.ErrBadConn))
+	assert.True(t, IsUnavailable(fmt.Errorf("query: %w", driver.ErrBadConn)))
+	assert.False(t, IsUnavailable(context.DeadlineExceeded))
+	assert.False(t, IsUnavailable(errors.New("ORA-01013: user requested cancel of current operation")))
+	assert.False(t, IsUnavailable(errQuery))
+}
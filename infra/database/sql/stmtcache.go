@@ -0,0 +1,255 @@
+package sql
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+)
+
+// StatementCacheDB is a MasterDB reusing its prepared statements, see WithMasterStatementCache
+type StatementCacheDB interface {
+	// TxStmtx returns the cached statement of query bound to tx with tx.StmtxContext. done must be
+	// called once the statement is no longer used, it closes the bound statement.
+	//
+	// The binding reuses the statement when it was already prepared on the connection of tx, and
+	// otherwise prepares it on that connection once, kept by the cached statement for the next
+	// transactions there. So a statement is prepared at most once per connection, plus once on a
+	// pool connection when a transaction misses the cache.
+	TxStmtx(ctx context.Context, tx *sqlx.Tx, query string) (stmt *sqlx.Stmt, done func(), err error)
+}
+
+// AsStatementCache returns the StatementCacheDB of db, looking through the wrappers of db, e.g. a circuit breaker
+func AsStatementCache(db DB) (StatementCacheDB, bool) {
+	for db != nil {
+		if cache, ok := db.(StatementCacheDB); ok {
+			return cache, true
+		}
+		wrapper, ok := db.(interface{ Unwrap() DB })
+		if !ok {
+			return nil, false
+		}
+		db = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// statementCache is a bounded LRU of prepared statements keyed by their SQL text.
+// A statement evicted while in use is closed once its last user releases it.
+type statementCache[S Statement] struct {
+	name    string
+	size    int
+	prepare func(ctx context.Context, query string) (S, error)
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cachedStatement[S Statement] struct {
+	query   string
+	stmt    S
+	refs    int
+	evicted bool
+}
+
+func newStatementCache[S Statement](name string, size int, prepare func(ctx context.Context, query string) (S, error)) *statementCache[S] {
+	return &statementCache[S]{
+		name:    name,
+		size:    size,
+		prepare: prepare,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// acquire returns the statement of query, prepared on a miss, which must be released after use
+func (c *statementCache[S]) acquire(ctx context.Context, query string) (*cachedStatement[S], error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[query]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*cachedStatement[S])
+		entry.refs++
+		c.mu.Unlock()
+		database.RecordStatementCache(c.name, database.CACHE_HIT)
+		return entry, nil
+	}
+	c.mu.Unlock()
+
+	database.RecordStatementCache(c.name, database.CACHE_MISS)
+	stmt, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[query]; ok {
+		// prepared concurrently, keep the cached one
+		c.closeStatement(query, stmt)
+		entry := elem.Value.(*cachedStatement[S])
+		entry.refs++
+		return entry, nil
+	}
+
+	entry := &cachedStatement[S]{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+	return entry, nil
+}
+
+// release gives entry back, closing it when it was evicted and this was its last user
+func (c *statementCache[S]) release(entry *cachedStatement[S]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		c.closeStatement(entry.query, entry.stmt)
+	}
+}
+
+// run calls fn with the statement of query
+func (c *statementCache[S]) run(ctx context.Context, query string, fn func(stmt S) error) error {
+	entry, err := c.acquire(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer c.release(entry)
+	return fn(entry.stmt)
+}
+
+// evict removes elem from the cache, c.mu must be held
+func (c *statementCache[S]) evict(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cachedStatement[S])
+	delete(c.entries, entry.query)
+	entry.evicted = true
+	database.RecordStatementCacheEviction(c.name)
+	if entry.refs == 0 {
+		c.closeStatement(entry.query, entry.stmt)
+	}
+}
+
+// close evicts every statement
+func (c *statementCache[S]) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+}
+
+func (c *statementCache[S]) closeStatement(query string, stmt S) {
+	if err := stmt.Close(); err != nil {
+		slog.Warn(fmt.Sprintf("closing statement cached by %s failed: %v, query= %s", c.name, err, query))
+	}
+}
+
+type masterDBStatementCache struct {
+	MasterDB
+	cache *statementCache[MasterStatement]
+}
+
+// WithMasterStatementCache returns db keeping up to size prepared statements, the least recently used being
+// closed first, for ExecContext and GetContext. A size of 0 or less returns db as is.
+func WithMasterStatementCache(db MasterDB, name string, size int) MasterDB {
+	if size <= 0 {
+		return db
+	}
+	return &masterDBStatementCache{MasterDB: db, cache: newStatementCache(name, size, db.PreparexContext)}
+}
+
+func (m *masterDBStatementCache) Unwrap() DB {
+	return m.MasterDB
+}
+
+func (m *masterDBStatementCache) PingContext(ctx context.Context) error {
+	return pingContext(ctx, m.MasterDB)
+}
+
+func (m *masterDBStatementCache) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	err = m.cache.run(ctx, query, func(stmt MasterStatement) error {
+		result, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+	return result, err
+}
+
+func (m *masterDBStatementCache) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return m.cache.run(ctx, query, func(stmt MasterStatement) error {
+		return stmt.GetContext(ctx, dest, args...)
+	})
+}
+
+func (m *masterDBStatementCache) TxStmtx(ctx context.Context, tx *sqlx.Tx, query string) (*sqlx.Stmt, func(), error) {
+	entry, err := m.cache.acquire(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	stmt, ok := entry.stmt.(*sqlx.Stmt)
+	if !ok {
+		m.cache.release(entry)
+		return nil, nil, errors.New("cached statement cannot be bound to a transaction")
+	}
+
+	txStmt := tx.StmtxContext(ctx, stmt)
+	return txStmt, func() {
+		txStmt.Close()
+		m.cache.release(entry)
+	}, nil
+}
+
+// Close closes the cached statements, then db
+func (m *masterDBStatementCache) Close() error {
+	m.cache.close()
+	return m.MasterDB.Close()
+}
+
+type slaveDBStatementCache struct {
+	SlaveDB
+	cache *statementCache[SlaveStatement]
+}
+
+// WithSlaveStatementCache returns db keeping up to size prepared statements, the least recently used being
+// closed first, for SelectContext and GetContext. Rows of QueryxContext outlive the call, so it is not cached.
+// A size of 0 or less returns db as is.
+func WithSlaveStatementCache(db SlaveDB, name string, size int) SlaveDB {
+	if size <= 0 {
+		return db
+	}
+	return &slaveDBStatementCache{SlaveDB: db, cache: newStatementCache(name, size, db.PreparexContext)}
+}
+
+func (s *slaveDBStatementCache) Unwrap() DB {
+	return s.SlaveDB
+}
+
+func (s *slaveDBStatementCache) PingContext(ctx context.Context) error {
+	return pingContext(ctx, s.SlaveDB)
+}
+
+func (s *slaveDBStatementCache) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return s.cache.run(ctx, query, func(stmt SlaveStatement) error {
+		return stmt.SelectContext(ctx, dest, args...)
+	})
+}
+
+func (s *slaveDBStatementCache) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return s.cache.run(ctx, query, func(stmt SlaveStatement) error {
+		return stmt.GetContext(ctx, dest, args...)
+	})
+}
+
+// Close closes the cached statements, then db
+func (s *slaveDBStatementCache) Close() error {
+	s.cache.close()
+	return s.SlaveDB.Close()
+}
@@ -0,0 +1,153 @@
+package sql
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+)
+
+// fakeStatement is a SlaveStatement recording whether it was closed, SelectContext blocking while block is set
+type fakeStatement struct {
+	SlaveStatement
+	closed  atomic.Bool
+	started chan struct{}
+	block   chan struct{}
+}
+
+func (f *fakeStatement) Close() error {
+	f.closed.Store(true)
+	return nil
+}
+
+func (f *fakeStatement) SelectContext(ctx context.Context, dest interface{}, args ...interface{}) error {
+	if f.block != nil {
+		close(f.started)
+		<-f.block
+	}
+	return nil
+}
+
+func (f *fakeStatement) GetContext(ctx context.Context, dest interface{}, args ...interface{}) error {
+	return nil
+}
+
+// fakePreparer is a SlaveDB recording the statements it prepared, the one of blocked blocking on SelectContext
+type fakePreparer struct {
+	SlaveDB
+	prepared []*fakeStatement
+	blocked  string
+	started  chan struct{}
+	block    chan struct{}
+}
+
+func (f *fakePreparer) PreparexContext(ctx context.Context, query string) (SlaveStatement, error) {
+	stmt := &fakeStatement{}
+	if query == f.blocked {
+		stmt.started, stmt.block = f.started, f.block
+	}
+	f.prepared = append(f.prepared, stmt)
+	return stmt, nil
+}
+
+func (f *fakePreparer) Close() error {
+	return nil
+}
+
+func TestWithSlaveStatementCache_Disabled(t *testing.T) {
+	db := &fakePreparer{}
+	assert.Same(t, db, WithSlaveStatementCache(db, "disabled", 0))
+}
+
+func TestSlaveStatementCache_Reuses(t *testing.T) {
+	db := &fakePreparer{}
+	cached := WithSlaveStatementCache(db, "reuses", 2)
+	ctx := context.Background()
+	hits, misses := cacheLookups(t, "reuses", database.CACHE_HIT), cacheLookups(t, "reuses", database.CACHE_MISS)
+
+	require.NoError(t, cached.SelectContext(ctx, nil, "SELECT 1 FROM dual"))
+	require.NoError(t, cached.GetContext(ctx, nil, "SELECT 1 FROM dual"))
+	require.NoError(t, cached.SelectContext(ctx, nil, "SELECT 2 FROM dual"))
+
+	assert.Len(t, db.prepared, 2)
+	assert.Equal(t, 1.0, cacheLookups(t, "reuses", database.CACHE_HIT)-hits)
+	assert.Equal(t, 2.0, cacheLookups(t, "reuses", database.CACHE_MISS)-misses)
+}
+
+func TestSlaveStatementCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	db := &fakePreparer{}
+	cached := WithSlaveStatementCache(db, "evicts", 2)
+	ctx := context.Background()
+
+	for _, query := range []string{"SELECT 1 FROM dual", "SELECT 2 FROM dual", "SELECT 1 FROM dual", "SELECT 3 FROM dual"} {
+		require.NoError(t, cached.SelectContext(ctx, nil, query))
+	}
+
+	require.Len(t, db.prepared, 3)
+	assert.False(t, db.prepared[0].closed.Load(), "used last")
+	assert.True(t, db.prepared[1].closed.Load(), "least recently used")
+	assert.False(t, db.prepared[2].closed.Load())
+
+	require.NoError(t, cached.Close())
+	assert.True(t, db.prepared[0].closed.Load())
+	assert.True(t, db.prepared[2].closed.Load())
+}
+
+func TestSlaveStatementCache_ClosesEvictedAfterUse(t *testing.T) {
+	db := &fakePreparer{blocked: "SELECT 1 FROM dual", started: make(chan struct{}), block: make(chan struct{})}
+	cached := WithSlaveStatementCache(db, "in use", 1)
+
+	done := make(chan error)
+	go func() {
+		done <- cached.SelectContext(context.Background(), nil, "SELECT 1 FROM dual")
+	}()
+	<-db.started
+
+	require.NoError(t, cached.GetContext(context.Background(), nil, "SELECT 2 FROM dual"))
+	assert.False(t, db.prepared[0].closed.Load(), "evicted while in use")
+
+	close(db.block)
+	require.NoError(t, <-done)
+	assert.True(t, db.prepared[0].closed.Load())
+}
+
+func TestAsStatementCache(t *testing.T) {
+	_, ok := AsStatementCache(WithMasterBreaker(&fakeMaster{}, NewBreaker("uncached", BreakerConfig{})))
+	assert.False(t, ok)
+
+	cached := WithMasterStatementCache(&fakeMaster{}, "cached", 10)
+	cache, ok := AsStatementCache(WithMasterBreaker(cached, NewBreaker("cached", BreakerConfig{})))
+	assert.True(t, ok)
+	assert.Same(t, cached, cache)
+}
+
+// fakeMaster is a MasterDB preparing nothing
+type fakeMaster struct {
+	MasterDB
+}
+
+// cacheLookups returns the lookups in the statement cache name with result
+func cacheLookups(t *testing.T, name, result string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "oracle_statement_cache_lookups_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["database"] == name && labels["result"] == result {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
@@ -13,6 +13,10 @@ var JAKARTA_LOCATION *time.Location
 const (
 	ACCEPT_LANG   = contextKey("Accept-Language")
 	AUTHORIZATION = contextKey("Authorization")
+	ACTOR         = contextKey("Actor")
+
+	READ_YOUR_WRITES_HEADER = "X-Read-Your-Writes"
+	READ_YOUR_WRITES_COOKIE = "read_your_writes"
 
 	COMMA                  = ","
 	FROM                   = " FROM "
@@ -47,11 +51,12 @@ const (
 	LANG_EN                = "EN"
 	EMPTY_STR              = ""
 	PAGE                   = "page"
+	CURSOR                 = "cursor"
+	INCLUDE_DELETED        = "includeDeleted"
 	ORDER_BY_REQ           = "orderBy"
 	ORDER_TYPE_REQ         = "orderType"
 	COUNT_COL              = "COUNT(*) as count"
 	UPDATED_DATE           = "updated_date"
-	ISSUED_DATE            = "issued_date"
 	CREATED_DATE           = "created_date"
 	TRANSACTION_FROM       = "transactionStart"
 	TRANSACTION_TO         = "transactionEnd"
@@ -60,17 +65,11 @@ const (
 	SERVICE_NAME           = "my-go-oracle-app"
 	REQUEST_DATE_START     = "requestDateStart"
 	REQUEST_DATE_END       = "requestDateEnd"
-	REQUEST_DATE           = "request_date"
-	ARRIVAL_DATE           = "ta.travel_start"
-	REFUND_DATE            = "ac.created_date"
-	EXPIRED_DATE           = "td.expired_at"
-	CLAIM_DATE             = "ic.claim_date"
 	SUBJECT_KEY            = "subject"
 	DELETED_COLUMN         = "is_deleted"
 	UNIQUE_IDENTIFIER      = "0712"
 	UNIQUE_IDENTIFIER_INT  = 712
 	INDONESIAN_RUPIAH      = "IDR"
-	CLAIM_NOTIF_DATE       = "fcn.created_date"
 
 	TD_ISSUED_DATE = "td.issued_date"
 )
@@ -1,7 +1,10 @@
 package response
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/render"
@@ -19,6 +22,7 @@ type Response struct {
 	IsStringCode       bool           `json:"-"`
 	CodeRender         interface{}    `json:"code"`
 	OverrideStatusText map[int]string `json:"-"`
+	RetryAfter         time.Duration  `json:"-"`
 }
 
 func NewResponse() Response {
@@ -51,8 +55,23 @@ func (e Error) Error() string {
 	return e.Msg
 }
 
+// RetryAfterError is an error of a dependency which is temporarily unavailable, e.g. a database
+// behind an open circuit breaker. It is rendered as 503 with a Retry-After header.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// TimeoutError is an error of a dependency which did not answer in time, e.g. a query cancelled
+// by its deadline. It is rendered as 504.
+type TimeoutError interface {
+	error
+	IsTimeout() bool
+}
+
 // SetError set the response to return the given error.
-// code is http status code, http.StatusInternalServerError is the default value
+// code is http status code, http.StatusInternalServerError is the default value.
+// A RetryAfterError is always http.StatusServiceUnavailable, a TimeoutError http.StatusGatewayTimeout.
 func (res *Response) SetError(err error, code ...int) {
 
 	cerr, ok := err.(*Error)
@@ -60,6 +79,17 @@ func (res *Response) SetError(err error, code ...int) {
 		code = []int{cerr.Code}
 	}
 
+	var unavailable RetryAfterError
+	if errors.As(err, &unavailable) {
+		code = []int{http.StatusServiceUnavailable}
+		res.RetryAfter = unavailable.RetryAfter()
+	}
+
+	var timeout TimeoutError
+	if errors.As(err, &timeout) && timeout.IsTimeout() {
+		code = []int{http.StatusGatewayTimeout}
+	}
+
 	if len(code) > 0 {
 		res.Code = code[0]
 	} else {
@@ -86,6 +116,10 @@ func (res *Response) Render(w http.ResponseWriter, r *http.Request, statusCode .
 	res.ServerTime = time.Now().Unix()
 	render.Status(r, res.Code)
 
+	if res.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+	}
+
 	if len(statusCode) > 0 {
 		render.Status(r, statusCode[0])
 	} else {
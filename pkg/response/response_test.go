@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -174,6 +175,39 @@ func TestSetError(t *testing.T) {
 
 }
 
+type unavailableError struct{}
+
+func (unavailableError) Error() string             { return "database unavailable" }
+func (unavailableError) RetryAfter() time.Duration { return 1500 * time.Millisecond }
+
+func TestSetError_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := Response{}
+		resp.SetError(fmt.Errorf("find members: %w", unavailableError{}), http.StatusInternalServerError)
+		resp.Render(w, r)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get("Retry-After"))
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "query timed out" }
+func (timeoutError) IsTimeout() bool { return true }
+
+func TestSetError_Timeout(t *testing.T) {
+	resp := Response{}
+	resp.SetError(fmt.Errorf("find members: %w", timeoutError{}), http.StatusInternalServerError)
+
+	require.Equal(t, http.StatusGatewayTimeout, resp.Code)
+	require.Equal(t, http.StatusGatewayTimeout, resp.Error.Code)
+}
+
 func TestAddOverrideStatus(t *testing.T) {
 	response := Response{}
 	response.AddOverrideStatus(200, "test")
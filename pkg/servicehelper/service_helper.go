@@ -5,26 +5,19 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
 	"oracle.com/oracle/my-go-oracle-app/pkg/helpers"
 	"oracle.com/oracle/my-go-oracle-app/service"
 )
 
-const startDateSuffix = " 00:00:00"
-const endDateSuffix = " 23:59:59"
-
-// list of param keys that needs to be appended by certain value.
-var appendList = map[string]string{}
-
 // GetFilterParamFromRequest will parse request url query and make corresponding filterparam based
 func GetFilterParamFromRequest(r *http.Request, mapFilter map[string]service.FilterParam) []service.FilterParam {
 	var resp []service.FilterParam
 	for key, val := range mapFilter {
 		str := r.URL.Query().Get(key)
 		if str != "" {
-			val.Value = preprocessInput(key, str)
+			val.Value = str
 
 			if ok := helpers.StringExists([]string{constants.IN, constants.NOT_IN}, val.Operand); ok {
 				arrString := trimspaceArrString(str)
@@ -50,22 +43,6 @@ func trimspaceArrString(str string) []string {
 	return arrString
 }
 
-// parse will parse input to utc value for time value. if not time then will return as is
-func preprocessInput(key, input string) string {
-	v := appendList[key]
-	if v == "" {
-		return input
-	}
-
-	// if time already included hh:mm:ss parse, if not add 00:00:00 or 23:59:59 then parse
-	t, err := time.ParseInLocation(constants.DATE_TIME_FORMAT, input, constants.JAKARTA_LOCATION)
-	if err != nil {
-		input += v
-		t, _ = time.ParseInLocation(constants.DATE_TIME_FORMAT, input, constants.JAKARTA_LOCATION)
-	}
-	return t.UTC().Format(constants.DATE_TIME_FORMAT)
-}
-
 // GetStatusCode Validate status code
 func GetStatusCode(statusCode string) (status string, err error) {
 	status, ok := constants.StatusName[strings.ToLower(statusCode)]
@@ -76,9 +53,17 @@ func GetStatusCode(statusCode string) (status string, err error) {
 	return
 }
 
-// GelSqlParameterFromRequest will parse request url query and make corresponding sqlparamater
-func GelSqlParameterFromRequest(r *http.Request, mapFilter map[string]service.FilterParam, mapOrder map[string]string) service.SqlParameter {
+// GelSqlParameterFromRequest will parse request url query and make corresponding sqlparamater.
+// Only the fields of mapFilter, dateFilters and mapOrder reach the query, an orderType other than asc/desc
+// is rejected with a service.IdentifierError and a date range dateFilters rejects with a service.DateFilterError.
+func GelSqlParameterFromRequest(r *http.Request, mapFilter map[string]service.FilterParam, mapOrder map[string]string,
+	dateFilters *service.DateFilters) (service.SqlParameter, error) {
 	filterParam := GetFilterParamFromRequest(r, mapFilter)
+	dateParam, err := dateFilters.Parse(r.URL.Query())
+	if err != nil {
+		return service.SqlParameter{}, err
+	}
+	filterParam = append(filterParam, dateParam...)
 
 	limit, _ := strconv.Atoi(r.URL.Query().Get(constants.LIMIT))
 	if limit < 1 {
@@ -95,6 +80,10 @@ func GelSqlParameterFromRequest(r *http.Request, mapFilter map[string]service.Fi
 	if orderType == "" {
 		orderType = constants.ASC
 	}
+	orderType, err = service.ValidateSortDirection(orderType)
+	if err != nil {
+		return service.SqlParameter{}, err
+	}
 
 	orderBy := []string{}
 	order := r.URL.Query().Get(constants.ORDER_BY_REQ)
@@ -102,10 +91,54 @@ func GelSqlParameterFromRequest(r *http.Request, mapFilter map[string]service.Fi
 		orderBy = append(orderBy, fmt.Sprintf("%s %s", val, orderType))
 	}
 
-	return service.SqlParameter{
+	param := service.SqlParameter{
 		Params:  filterParam,
 		Limit:   limit,
 		Offset:  offset,
 		OrderBy: orderBy,
 	}
+	if IsIncludeDeleted(r) {
+		param = param.WithDeleted()
+	}
+	return param, nil
+}
+
+// IsIncludeDeleted reports whether the request asks for soft-deleted rows with includeDeleted=true
+func IsIncludeDeleted(r *http.Request) bool {
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get(constants.INCLUDE_DELETED))
+	return includeDeleted
+}
+
+// GetKeysetFromRequest returns the keyset pagination requested with the cursor query param, nil when absent.
+// An empty cursor starts from the first page. The order follows orderBy/orderType like GelSqlParameterFromRequest;
+// a cursor produced for another order is rejected.
+func GetKeysetFromRequest(r *http.Request, idColumn string, mapOrder map[string]string) (*service.Keyset, error) {
+	query := r.URL.Query()
+	if !query.Has(constants.CURSOR) {
+		return nil, nil
+	}
+
+	keyset := &service.Keyset{
+		IDColumn: idColumn,
+		Desc:     strings.EqualFold(query.Get(constants.ORDER_TYPE_REQ), constants.DESC),
+	}
+	order := query.Get(constants.ORDER_BY_REQ)
+	if val, ok := mapOrder[order]; ok {
+		keyset.OrderKey = order
+		keyset.SortColumn = val
+	}
+
+	cursor := query.Get(constants.CURSOR)
+	if cursor == "" {
+		return keyset, nil
+	}
+	after, err := service.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if after.OrderKey != keyset.OrderKey || after.Desc != keyset.Desc {
+		return nil, fmt.Errorf("%w: cursor was issued for another order", service.ErrInvalidCursor)
+	}
+	keyset.After = &after
+	return keyset, nil
 }
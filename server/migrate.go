@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	config "oracle.com/oracle/my-go-oracle-app/configs"
+	"oracle.com/oracle/my-go-oracle-app/infra/database/migrate"
+	"oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+	"oracle.com/oracle/my-go-oracle-app/source/migrations"
+)
+
+const migrateUsage = "usage: migrate up|down|status|goto N"
+
+// Migrate runs the migrate command of the binary against the master database:
+// up applies the pending migrations, down reverts the last one, goto N moves to version N
+// and status lists the migrations with their state.
+func Migrate(config *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	masterDB, err := openMasterDB(config)
+	if err != nil {
+		return fmt.Errorf("init master DB failed: %w", err)
+	}
+	defer masterDB.Close()
+
+	migrator, err := newMigrator(config, masterDB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		return migrator.Up(ctx)
+	case args[0] == "down" && len(args) == 1:
+		return migrator.Down(ctx)
+	case args[0] == "goto" && len(args) == 2:
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %s: %w", args[1], err)
+		}
+		return migrator.Goto(ctx, version)
+	case args[0] == "status" && len(args) == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printStatus(statuses)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// migrateUp applies the pending migrations before the server starts
+func migrateUp(config *config.Config, masterDB sql.MasterDB) error {
+	migrator, err := newMigrator(config, masterDB)
+	if err != nil {
+		return err
+	}
+	return migrator.Up(context.Background())
+}
+
+func newMigrator(config *config.Config, masterDB sql.MasterDB) (*migrate.Migrator, error) {
+	var fsys fs.FS = migrations.FS
+	if config.MigrationsDir != "" {
+		fsys = os.DirFS(config.MigrationsDir)
+	}
+	return migrate.New(masterDB, fsys, migrate.WithLockTimeout(config.MigrateLockTimeout))
+}
+
+func printStatus(statuses []migrate.Status) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := ""
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", status.Version, status.Name, status.State(), appliedAt)
+	}
+	return w.Flush()
+}
@@ -8,6 +8,7 @@ import (
 	"oracle.com/oracle/my-go-oracle-app/api"
 	httpapi "oracle.com/oracle/my-go-oracle-app/api/http"
 	config "oracle.com/oracle/my-go-oracle-app/configs"
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
 	"oracle.com/oracle/my-go-oracle-app/infra/database/sql"
 	"oracle.com/oracle/my-go-oracle-app/service"
 	"oracle.com/oracle/my-go-oracle-app/service/member"
@@ -17,13 +18,27 @@ import (
 func InitHttp(config *config.Config) error {
 	// baseRepo := getBaseRepository(config)
 	baseRepo := getBaseRepository(config)
+	service.SetCursorSecret(config.CursorSecret)
+	service.SetQueryLog(service.QueryLog{
+		Debug:         config.LogQueries,
+		SlowThreshold: config.SlowQueryThreshold,
+		SampleSize:    config.SlowQuerySampleSize,
+	})
 
-	memberRepo := member.NewMemberRepository(baseRepo)
+	memberBaseRepo := member.WithHistory(baseRepo)
+	memberRepo := member.NewMemberRepository(memberBaseRepo)
 	memberService := member.NewMemberService(memberRepo)
 
+	// backfills run through the member history, so their updates are audited
+	backfills := service.NewBackfills(&memberBaseRepo)
+	if err := backfills.Register(member.JSONBackfill(config.BackfillChunkSize, config.BackfillThrottle)); err != nil {
+		return err
+	}
+
 	httpserver := httpapi.Server{
 		Cfg:           config,
 		MemberService: memberService,
+		Backfills:     backfills,
 		HealthCheck: api.HealthChecker{
 			Master: baseRepo.MasterDB,
 			Slave:  baseRepo.SlaveDB,
@@ -34,48 +49,118 @@ func InitHttp(config *config.Config) error {
 }
 
 func getBaseRepository(config *config.Config) service.BaseRepository {
-
-	var dbMasterURL, dbSlaveURL string
-	if config.OracleLibDir == "" {
-		dbMasterURL = fmt.Sprintf("%s/%s@%s:%s/%s", config.OracleMasterUsername, config.OracleMasterPassword, config.OracleMasterHost, config.OracleMasterPort, config.OracleMasterDatabase)
-		dbSlaveURL = fmt.Sprintf("%s/%s@%s:%s/%s", config.OracleSlaveUsername, config.OracleSlavePassword, config.OracleSlaveHost, config.OracleSlavePort, config.OracleSlaveDatabase)
-	} else {
-		masterConn := fmt.Sprintf("%s:%s/%s", config.OracleMasterHost, config.OracleMasterPort, config.OracleMasterDatabase)
-		slaveConn := fmt.Sprintf("%s:%s/%s", config.OracleSlaveHost, config.OracleSlavePort, config.OracleSlaveDatabase)
-		dbMasterURL = fmt.Sprintf(`user="%s"
-                                password="%s"
-                                connectString="%s"
-                                libDir="%s"`,
-			config.OracleMasterUsername,
-			config.OracleMasterPassword,
-			masterConn,
-			config.OracleLibDir,
-		)
-		dbSlaveURL = fmt.Sprintf(`user="%s"
-                                password="%s"
-                                connectString="%s"
-                                libDir="%s"`,
-			config.OracleSlaveUsername,
-			config.OracleSlavePassword,
-			slaveConn,
-			config.OracleLibDir,
-		)
+	slaveConns := config.OracleSlaveHosts
+	if len(slaveConns) == 0 {
+		slaveConns = []string{fmt.Sprintf("%s:%s/%s", config.OracleSlaveHost, config.OracleSlavePort, config.OracleSlaveDatabase)}
 	}
 	//init db config
 
-	masterDB, err := sql.OpenMasterDB("godror", dbMasterURL, config.OracleMaxOpenConnection, config.OracleMaxIdleConnection, config.OracleConnMaxIdleTime, config.OracleConnMaxLifeTime)
+	masterDB, err := openMasterDB(config)
 	if err != nil {
 		slog.Error(fmt.Sprintf("init master DB failed: %v", err))
 		os.Exit(1)
 	}
-	slaveDB, err := sql.OpenSlaveDB("godror", dbSlaveURL, config.OracleMaxOpenConnection, config.OracleMaxIdleConnection, config.OracleConnMaxIdleTime, config.OracleConnMaxLifeTime)
-	if err != nil {
-		slog.Error(fmt.Sprintf("init slave DB failed: %v", err))
-		os.Exit(1)
+	if config.MigrateOnStartup {
+		if err := migrateUp(config, masterDB); err != nil {
+			slog.Error(fmt.Sprintf("migrate on startup failed: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	retryPolicy := service.RetryPolicy{
+		MaxRetries:     config.OracleRetryMaxRetries,
+		Backoff:        config.OracleRetryBackoff,
+		MaxBackoff:     config.OracleRetryMaxBackoff,
+		Jitter:         config.OracleRetryJitter,
+		TransientCodes: config.OracleRetryTransientCodes,
+	}
+
+	breakerConfig := sql.BreakerConfig{
+		FailureRate:    config.OracleBreakerFailureRate,
+		MinRequests:    config.OracleBreakerMinRequests,
+		Window:         config.OracleBreakerWindow,
+		OpenTimeout:    config.OracleBreakerOpenTimeout,
+		HalfOpenTrials: config.OracleBreakerHalfOpenTrials,
+		// only a lost connection or a failover fails, timeouts and cancellations are not counted
+		IsFailure: func(err error) bool {
+			return sql.IsUnavailable(err) || retryPolicy.IsTransient(err)
+		},
+	}
+	// statements are cached under the breakers, so a cached statement failing counts as a failure
+	masterDB = sql.WithMasterStatementCache(masterDB, database.TARGET_MASTER, config.OracleStatementCacheSize)
+	withBreakers := config.OracleBreakerFailureRate > 0
+	if withBreakers {
+		masterDB = sql.WithMasterBreaker(masterDB, sql.NewBreaker(database.TARGET_MASTER, breakerConfig))
+	}
+
+	// every replica gets its own breaker, so the balancer skips the failing ones
+	replicas := make([]sql.Replica, 0, len(slaveConns))
+	for _, slaveConn := range slaveConns {
+		dbSlaveURL := dataSourceName(config, config.OracleSlaveUsername, config.OracleSlavePassword, slaveConn)
+		slaveDB, err := sql.OpenSlaveDB("godror", dbSlaveURL, config.OracleMaxOpenConnection, config.OracleMaxIdleConnection, config.OracleConnMaxIdleTime, config.OracleConnMaxLifeTime)
+		if err != nil && (slaveDB == nil || len(slaveConns) == 1) {
+			slog.Error(fmt.Sprintf("init slave DB %s failed: %v", slaveConn, err))
+			os.Exit(1)
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("slave DB %s not reachable, ejected until it answers: %v", slaveConn, err))
+		}
+
+		name := database.TARGET_SLAVE
+		if len(slaveConns) > 1 {
+			name = fmt.Sprintf("%s %s", database.TARGET_SLAVE, slaveConn)
+		}
+		slaveDB = sql.WithSlaveStatementCache(slaveDB, name, config.OracleStatementCacheSize)
+		if withBreakers {
+			slaveDB = sql.WithSlaveBreaker(slaveDB, sql.NewBreaker(name, breakerConfig))
+		}
+		replicas = append(replicas, sql.Replica{Name: name, DB: slaveDB})
+	}
+
+	slaveDB := replicas[0].DB
+	if len(replicas) > 1 {
+		slaveDB, err = sql.NewBalancer(sql.BalancerConfig{
+			Policy:       config.OracleSlaveBalancer,
+			PingInterval: config.OracleSlavePingInterval,
+			PingTimeout:  config.OracleSlavePingTimeout,
+		}, replicas...)
+		if err != nil {
+			slog.Error(fmt.Sprintf("init slave DB balancer failed: %v", err))
+			os.Exit(1)
+		}
 	}
 
 	return service.BaseRepository{
 		MasterDB: masterDB,
 		SlaveDB:  slaveDB,
+	}.WithRetry(retryPolicy).WithReadRouting(service.ReadRouting{Fallback: config.OracleReadFallback}).WithTimeouts(service.Timeouts{
+		Read:   config.OracleTimeoutRead,
+		Write:  config.OracleTimeoutWrite,
+		Count:  config.OracleTimeoutCount,
+		Export: config.OracleTimeoutExport,
+		Margin: config.OracleTimeoutMargin,
+	})
+}
+
+// openMasterDB opens the master database, without breaker nor statement cache
+func openMasterDB(config *config.Config) (sql.MasterDB, error) {
+	masterConn := fmt.Sprintf("%s:%s/%s", config.OracleMasterHost, config.OracleMasterPort, config.OracleMasterDatabase)
+	dbMasterURL := dataSourceName(config, config.OracleMasterUsername, config.OracleMasterPassword, masterConn)
+	return sql.OpenMasterDB("godror", dbMasterURL, config.OracleMaxOpenConnection, config.OracleMaxIdleConnection, config.OracleConnMaxIdleTime, config.OracleConnMaxLifeTime)
+}
+
+// dataSourceName returns the godror DSN of username on connectString, host:port/database
+func dataSourceName(config *config.Config, username, password, connectString string) string {
+	if config.OracleLibDir == "" {
+		return fmt.Sprintf("%s/%s@%s", username, password, connectString)
 	}
+	return fmt.Sprintf(`user="%s"
+                                password="%s"
+                                connectString="%s"
+                                libDir="%s"`,
+		username,
+		password,
+		connectString,
+		config.OracleLibDir,
+	)
 }
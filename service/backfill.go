@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+const (
+	BACKFILL_DEFAULT_CHUNK_SIZE = 500
+
+	BACKFILL_IDLE      = "idle"
+	BACKFILL_RUNNING   = "running"
+	BACKFILL_PAUSED    = "paused"
+	BACKFILL_CANCELLED = "cancelled"
+	BACKFILL_COMPLETED = "completed"
+	BACKFILL_FAILED    = "failed"
+)
+
+var (
+	// ErrBackfillNotFound is returned for a backfill which is not registered
+	ErrBackfillNotFound = errors.New("BACKFILL_NOT_FOUND")
+	// ErrBackfillState is returned when a backfill cannot be started, paused or cancelled in its current state
+	ErrBackfillState = errors.New("BACKFILL_INVALID_STATE")
+
+	// errBackfillDryRun rolls back the transaction of a chunk in dry-run mode
+	errBackfillDryRun = errors.New("backfill dry run")
+)
+
+// Backfill rewrites the rows of a table in chunks ordered by primary key. Every chunk is locked, transformed,
+// updated and checkpointed into MIGRATION_FLAG in one transaction, so a backfill stopped or crashed resumes
+// after the last chunk committed.
+type Backfill struct {
+	// Name identifies the backfill, its checkpoints are recorded under it as ACTION
+	Name  string
+	Table string
+	// PrimaryKey is the numeric column the chunks are ordered by, ID when empty
+	PrimaryKey string
+	// ChunkSize is how many rows are rewritten per transaction, BACKFILL_DEFAULT_CHUNK_SIZE when not positive
+	ChunkSize int
+	// Throttle is the pause between two chunks, leaving room for the production load
+	Throttle time.Duration
+	// Transform returns the updates of the rows of a chunk. It must be idempotent: a chunk whose commit was
+	// lost is transformed again, and so are the rows already rewritten when the backfill is started over.
+	Transform BackfillTransform
+}
+
+// BackfillRow is a row of a chunk, its columns upper-cased and its JSON documents as json.RawMessage
+type BackfillRow struct {
+	Id     int64
+	Values map[string]interface{}
+}
+
+// BackfillUpdate sets Values on the row Id of the chunk. The values are bound as given, e.g. a string for
+// a VARCHAR2 or CLOB column and a []byte for a BLOB.
+type BackfillUpdate struct {
+	Id     int64
+	Values []Value
+}
+
+// BackfillTransform returns the updates of rows, the rows left out are not updated
+type BackfillTransform func(ctx context.Context, rows []BackfillRow) ([]BackfillUpdate, error)
+
+// backfillChunk is the outcome of a chunk, LastId is 0 when no row is left
+type backfillChunk struct {
+	LastId  int64
+	Rows    int64
+	Updated int64
+}
+
+// backfillChunk rewrites the rows following after. In dry-run mode the updates are run, so they are checked
+// against the constraints, then rolled back and no checkpoint is recorded.
+//
+// The rows are updated through the history of the table when it is audited. A row having a VERSION column
+// gets it incremented, so an update based on the version read before the backfill conflicts instead of
+// overwriting it.
+func (r *BaseRepository) backfillChunk(ctx context.Context, backfill Backfill, after int64, dryRun bool) (chunk backfillChunk, err error) {
+	pk := backfill.PrimaryKey
+	err = r.WithTransaction(ctx, func(ctx context.Context) error {
+		var last sql.NullInt64
+		if err := r.GetOperationsMasterConn(ctx, &last, fmt.Sprintf(backfillBoundQuery, pk, backfill.Table), after, backfill.ChunkSize); err != nil {
+			return err
+		}
+		if !last.Valid {
+			return nil
+		}
+
+		locked := HistoryTable{TableName: backfill.Table, PrimaryKey: pk}
+		snapshot, err := r.snapshot(ctx, backfill.Table, locked, fmt.Sprintf(backfillChunkConditional, pk), []interface{}{after, last.Int64})
+		if err != nil {
+			return err
+		}
+		rows := make([]BackfillRow, 0, len(snapshot.ids))
+		for _, id := range snapshot.ids {
+			rows = append(rows, BackfillRow{Id: id, Values: snapshot.values[id]})
+		}
+
+		updates, err := backfill.Transform(ctx, rows)
+		if err != nil {
+			return fmt.Errorf("backfill %s: transform of %s %d to %d: %w", backfill.Name, backfill.Table, after, last.Int64, err)
+		}
+		updated, err := r.backfillUpdates(ctx, backfill, snapshot, updates)
+		if err != nil {
+			return err
+		}
+
+		chunk = backfillChunk{LastId: last.Int64, Rows: int64(len(rows)), Updated: updated}
+		if dryRun {
+			return errBackfillDryRun
+		}
+		_, err = r.WriteOrUpdateOperation(ctx, createMigrationFlagQuery, nil, backfill.Table, backfill.Name, last.Int64, backfill.ChunkSize, time.Now())
+		return err
+	})
+	if errors.Is(err, errBackfillDryRun) {
+		err = nil
+	}
+	return chunk, err
+}
+
+func (r *BaseRepository) backfillUpdates(ctx context.Context, backfill Backfill, snapshot rowSnapshot, updates []BackfillUpdate) (int64, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	ids := make([]int64, 0, len(updates))
+	for _, update := range updates {
+		if _, ok := snapshot.values[update.Id]; !ok {
+			return 0, fmt.Errorf("backfill %s: row %d is not in the chunk", backfill.Name, update.Id)
+		}
+		ids = append(ids, update.Id)
+	}
+
+	chunk := SqlParameter{
+		TableName: backfill.Table,
+		Params:    []FilterParam{MakeFilterParam(backfill.PrimaryKey, constants.IN, ids)},
+	}
+	return r.audited(ctx, chunk, constants.ACTION_UPDATE, func(ctx context.Context) (updated int64, err error) {
+		for _, update := range updates {
+			sqlParameter := SqlParameter{
+				TableName: backfill.Table,
+				Values:    update.Values,
+				Params:    []FilterParam{MakeFilterParam(backfill.PrimaryKey, constants.EQUAL, update.Id)},
+			}
+			if version, ok := snapshot.values[update.Id][VERSION_COLUMN]; ok {
+				if sqlParameter.Version, err = snapshotId(version); err != nil {
+					return updated, fmt.Errorf("backfill %s: version of row %d: %w", backfill.Name, update.Id, err)
+				}
+			}
+			if err = r.Validate(sqlParameter); err != nil {
+				return updated, err
+			}
+			n, err := r.updateRows(ctx, sqlParameter)
+			if err != nil {
+				return updated, err
+			}
+			updated += n
+		}
+		return updated, nil
+	})
+}
+
+// backfillCheckpoint returns the last primary key checkpointed by backfill, 0 when it never committed a chunk
+func (r *BaseRepository) backfillCheckpoint(ctx context.Context, backfill Backfill) (lastId int64, err error) {
+	err = r.GetOperationsMasterConn(ctx, &lastId, findLastUpdateIdMigrationFlagQuery, backfill.Table, backfill.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return lastId, err
+}
+
+// BackfillProgress is the state of a backfill. Remaining is estimated when the backfill is started.
+type BackfillProgress struct {
+	Name      string     `json:"name" example:"member_json"`
+	Table     string     `json:"table" example:"MEMBER"`
+	State     string     `json:"state" example:"running"`
+	DryRun    bool       `json:"dryRun"`
+	LastId    int64      `json:"lastId" example:"1500"`
+	Chunks    int64      `json:"chunks" example:"3"`
+	Processed int64      `json:"processed" example:"1500"`
+	Updated   int64      `json:"updated" example:"1200"`
+	Remaining int64      `json:"remaining" example:"98500"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Backfills runs the backfills registered on a repository in the background, one run per backfill at a time
+type Backfills struct {
+	repository *BaseRepository
+	mu         sync.Mutex
+	jobs       map[string]*backfillJob
+}
+
+type backfillJob struct {
+	backfill Backfill
+	progress BackfillProgress
+	cancel   context.CancelFunc
+	// resume is closed to resume a paused run, nil when the run is not paused
+	resume chan struct{}
+	// done is closed when the run returns, nil before the first run
+	done chan struct{}
+}
+
+// NewBackfills returns the backfills of repository, whose history and schema apply to the updates
+func NewBackfills(repository *BaseRepository) *Backfills {
+	return &Backfills{repository: repository, jobs: map[string]*backfillJob{}}
+}
+
+// Register adds backfill, to be started with Start
+func (b *Backfills) Register(backfill Backfill) error {
+	if backfill.Name == "" || backfill.Table == "" || backfill.Transform == nil {
+		return errors.New("backfill needs a name, a table and a transform")
+	}
+	if backfill.PrimaryKey == "" {
+		backfill.PrimaryKey = "ID"
+	}
+	if backfill.ChunkSize <= 0 {
+		backfill.ChunkSize = BACKFILL_DEFAULT_CHUNK_SIZE
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.jobs[backfill.Name]; ok {
+		return fmt.Errorf("backfill %s is already registered", backfill.Name)
+	}
+	b.jobs[backfill.Name] = &backfillJob{
+		backfill: backfill,
+		progress: BackfillProgress{Name: backfill.Name, Table: backfill.Table, State: BACKFILL_IDLE},
+	}
+	return nil
+}
+
+// Progress returns the progress of every backfill sorted by name
+func (b *Backfills) Progress() []BackfillProgress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	progress := make([]BackfillProgress, 0, len(b.jobs))
+	for _, job := range b.jobs {
+		progress = append(progress, job.progress)
+	}
+	slices.SortFunc(progress, func(a, b BackfillProgress) int { return strings.Compare(a.Name, b.Name) })
+	return progress
+}
+
+// Start runs the backfill name from its last checkpoint, or resumes it when it is paused.
+// A paused backfill is resumed in the mode it was started in.
+func (b *Backfills) Start(name string, dryRun bool) (BackfillProgress, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, ok := b.jobs[name]
+	if !ok {
+		return BackfillProgress{}, fmt.Errorf("%w: %s", ErrBackfillNotFound, name)
+	}
+
+	switch {
+	case job.progress.State == BACKFILL_PAUSED:
+		if job.progress.DryRun != dryRun {
+			return job.progress, fmt.Errorf("%w: %s is paused with dryRun=%t", ErrBackfillState, name, job.progress.DryRun)
+		}
+		close(job.resume)
+		job.resume = nil
+		job.progress.State = BACKFILL_RUNNING
+		return job.progress, nil
+	case job.progress.State == BACKFILL_RUNNING:
+		return job.progress, fmt.Errorf("%w: %s is already running", ErrBackfillState, name)
+	case job.done != nil && !isClosed(job.done):
+		return job.progress, fmt.Errorf("%w: %s is still stopping", ErrBackfillState, name)
+	}
+
+	now := time.Now()
+	ctx, cancel := context.WithCancel(SetActorInContext(context.Background(), "backfill "+name))
+	job.cancel, job.done = cancel, make(chan struct{})
+	job.progress = BackfillProgress{
+		Name:      name,
+		Table:     job.backfill.Table,
+		State:     BACKFILL_RUNNING,
+		DryRun:    dryRun,
+		StartedAt: &now,
+		UpdatedAt: &now,
+	}
+	go b.run(ctx, job, dryRun)
+	return job.progress, nil
+}
+
+// Pause suspends the backfill name once its current chunk is committed
+func (b *Backfills) Pause(name string) (BackfillProgress, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, ok := b.jobs[name]
+	if !ok {
+		return BackfillProgress{}, fmt.Errorf("%w: %s", ErrBackfillNotFound, name)
+	}
+	if job.progress.State != BACKFILL_RUNNING {
+		return job.progress, fmt.Errorf("%w: %s is %s", ErrBackfillState, name, job.progress.State)
+	}
+	job.resume = make(chan struct{})
+	job.progress.State = BACKFILL_PAUSED
+	return job.progress, nil
+}
+
+// Cancel stops the backfill name, rolling back its current chunk. Started again, it resumes from its last checkpoint.
+func (b *Backfills) Cancel(name string) (BackfillProgress, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, ok := b.jobs[name]
+	if !ok {
+		return BackfillProgress{}, fmt.Errorf("%w: %s", ErrBackfillNotFound, name)
+	}
+	if job.progress.State != BACKFILL_RUNNING && job.progress.State != BACKFILL_PAUSED {
+		return job.progress, fmt.Errorf("%w: %s is %s", ErrBackfillState, name, job.progress.State)
+	}
+	job.cancel()
+	job.resume = nil
+	job.progress.State = BACKFILL_CANCELLED
+	return job.progress, nil
+}
+
+// run processes the chunks of job until none is left, it is cancelled or a chunk fails
+func (b *Backfills) run(ctx context.Context, job *backfillJob, dryRun bool) {
+	defer close(job.done)
+	defer job.cancel()
+	backfill := job.backfill
+
+	err := b.chunks(ctx, job, dryRun)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	job.progress.UpdatedAt = &now
+	switch {
+	case job.progress.State == BACKFILL_CANCELLED:
+		slog.InfoContext(ctx, fmt.Sprintf("backfill %s cancelled after %s %d", backfill.Name, backfill.Table, job.progress.LastId))
+	case err != nil:
+		job.progress.State = BACKFILL_FAILED
+		job.progress.Error = err.Error()
+		slog.ErrorContext(ctx, fmt.Sprintf("backfill %s failed after %s %d: %v", backfill.Name, backfill.Table, job.progress.LastId, err))
+	default:
+		job.progress.State = BACKFILL_COMPLETED
+		job.progress.Remaining = 0
+		slog.InfoContext(ctx, fmt.Sprintf("backfill %s completed: %d rows processed, %d updated", backfill.Name, job.progress.Processed, job.progress.Updated))
+	}
+}
+
+func (b *Backfills) chunks(ctx context.Context, job *backfillJob, dryRun bool) error {
+	backfill := job.backfill
+	after, err := b.repository.backfillCheckpoint(ctx, backfill)
+	if err != nil {
+		return err
+	}
+	var remaining int64
+	query := fmt.Sprintf(backfillRemainingQuery, backfill.PrimaryKey, backfill.Table)
+	if err = b.repository.GetOperationsMasterConn(WithOperationClass(ctx, OPERATION_COUNT), &remaining, query, after); err != nil {
+		return err
+	}
+	b.update(job, func(progress *BackfillProgress) {
+		progress.LastId, progress.Remaining = after, remaining
+	})
+
+	for {
+		if err = b.waitResumed(ctx, job); err != nil {
+			return err
+		}
+		chunk, err := b.repository.backfillChunk(ctx, backfill, after, dryRun)
+		if err != nil {
+			return err
+		}
+		if chunk.LastId == 0 {
+			return nil
+		}
+		after = chunk.LastId
+		b.update(job, func(progress *BackfillProgress) {
+			progress.LastId = chunk.LastId
+			progress.Chunks++
+			progress.Processed += chunk.Rows
+			progress.Updated += chunk.Updated
+			progress.Remaining = max(progress.Remaining-chunk.Rows, 0)
+		})
+		slog.InfoContext(ctx, fmt.Sprintf("backfill %s: %s up to %d, %d rows processed, %d updated, dryRun=%t", backfill.Name, backfill.Table, chunk.LastId, chunk.Rows, chunk.Updated, dryRun))
+
+		if backfill.Throttle > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backfill.Throttle):
+			}
+		}
+	}
+}
+
+func (b *Backfills) update(job *backfillJob, fn func(progress *BackfillProgress)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fn(&job.progress)
+	now := time.Now()
+	job.progress.UpdatedAt = &now
+}
+
+// waitResumed blocks while job is paused
+func (b *Backfills) waitResumed(ctx context.Context, job *backfillJob) error {
+	b.mu.Lock()
+	resume := job.resume
+	b.mu.Unlock()
+	if resume == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resume:
+		return nil
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
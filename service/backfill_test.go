@@ -0,0 +1,239 @@
+package service_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+// setupBackfill serves MEMBER rows 1 to 5 from fdb, checkpoint being the last one recorded in MIGRATION_FLAG
+func setupBackfill(t *testing.T, checkpoint int64) (*service.Backfills, *fakeDB) {
+	master, fdb := newFakeMasterDB(t)
+	repo := service.BaseRepository{MasterDB: master}.WithHistory("MEMBER", memberHistory)
+
+	ids := []int64{1, 2, 3, 4, 5}
+	row := func(id int64) []driver.Value { return []driver.Value{id, int64(3), fmt.Sprintf(`{"n":%d}`, id)} }
+	fdb.results = func(query string, args []driver.NamedValue) ([]string, [][]driver.Value) {
+		switch {
+		case strings.Contains(query, "FROM MIGRATION_FLAG"):
+			if checkpoint == 0 {
+				return []string{"LAST_UPDATE_ID"}, nil
+			}
+			return []string{"LAST_UPDATE_ID"}, [][]driver.Value{{checkpoint}}
+		case strings.HasPrefix(query, "SELECT COUNT(*)"):
+			after := argInt(args[0])
+			return []string{"COUNT(*)"}, [][]driver.Value{{int64(len(ids) - slices.IndexFunc(ids, func(id int64) bool { return id > after }))}}
+		case strings.HasPrefix(query, "SELECT MAX(ID)"):
+			after, size := argInt(args[0]), argInt(args[1])
+			var last driver.Value
+			for _, id := range ids {
+				if id > after && size > 0 {
+					last, size = id, size-1
+				}
+			}
+			return []string{"MAX(ID)"}, [][]driver.Value{{last}}
+		case strings.Contains(query, " IN ("):
+			var rows [][]driver.Value
+			for _, arg := range args {
+				rows = append(rows, row(argInt(arg)))
+			}
+			return []string{"ID", "VERSION", "INFO"}, rows
+		default:
+			var rows [][]driver.Value
+			for _, id := range ids {
+				if id > argInt(args[0]) && id <= argInt(args[1]) {
+					rows = append(rows, row(id))
+				}
+			}
+			return []string{"ID", "VERSION", "INFO"}, rows
+		}
+	}
+
+	backfills := service.NewBackfills(&repo)
+	return backfills, fdb
+}
+
+func argInt(arg driver.NamedValue) int64 {
+	switch v := arg.Value.(type) {
+	case int:
+		return int64(v)
+	default:
+		return v.(int64)
+	}
+}
+
+// reshapeOdd updates the INFO of the odd rows
+func reshapeOdd(ctx context.Context, rows []service.BackfillRow) ([]service.BackfillUpdate, error) {
+	var updates []service.BackfillUpdate
+	for _, row := range rows {
+		if row.Id%2 == 1 {
+			updates = append(updates, service.BackfillUpdate{Id: row.Id, Values: []service.Value{{Field: "INFO", Value: `{}`}}})
+		}
+	}
+	return updates, nil
+}
+
+// checkpoints returns the LAST_UPDATE_ID inserted into MIGRATION_FLAG
+func checkpoints(fdb *fakeDB) []int64 {
+	fdb.mu.Lock()
+	defer fdb.mu.Unlock()
+	var checkpoints []int64
+	for i, query := range fdb.log {
+		if strings.HasPrefix(query, "INSERT INTO MIGRATION_FLAG") {
+			checkpoints = append(checkpoints, argInt(fdb.args[i][2]))
+		}
+	}
+	return checkpoints
+}
+
+func countStatements(fdb *fakeDB, prefix string) int {
+	count := 0
+	for _, query := range fdb.statements() {
+		if strings.HasPrefix(query, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func waitBackfill(t *testing.T, backfills *service.Backfills, state string) service.BackfillProgress {
+	var progress service.BackfillProgress
+	require.Eventually(t, func() bool {
+		progress = backfills.Progress()[0]
+		return progress.State == state
+	}, time.Second, time.Millisecond, "backfill %s", state)
+	return progress
+}
+
+func TestBackfills_Run(t *testing.T) {
+	backfills, fdb := setupBackfill(t, 0)
+	require.NoError(t, backfills.Register(service.Backfill{Name: "reshape", Table: "MEMBER", ChunkSize: 2, Transform: reshapeOdd}))
+
+	_, err := backfills.Start("reshape", false)
+	require.NoError(t, err)
+	progress := waitBackfill(t, backfills, service.BACKFILL_COMPLETED)
+
+	assert.Equal(t, int64(5), progress.LastId)
+	assert.Equal(t, int64(3), progress.Chunks)
+	assert.Equal(t, int64(5), progress.Processed)
+	assert.Equal(t, int64(3), progress.Updated)
+	assert.Equal(t, []int64{2, 4, 5}, checkpoints(fdb))
+	assert.Contains(t, fdb.statements(), "SELECT * FROM MEMBER WHERE ID > :1 AND ID <= :2 ORDER BY ID FOR UPDATE")
+	assert.Contains(t, fdb.statements(), "UPDATE MEMBER SET INFO=:1,VERSION=VERSION+1 WHERE ID = :2 AND VERSION = :3", "versioned rows are bumped")
+	assert.Equal(t, 3, countStatements(fdb, "INSERT INTO MEMBER_HISTORY"))
+	assert.Equal(t, 4, countStatements(fdb, "COMMIT"), "a transaction per chunk and the last empty one")
+}
+
+func TestBackfills_ResumeFromCheckpoint(t *testing.T) {
+	backfills, fdb := setupBackfill(t, 3)
+	require.NoError(t, backfills.Register(service.Backfill{Name: "reshape", Table: "MEMBER", ChunkSize: 2, Transform: reshapeOdd}))
+
+	started, err := backfills.Start("reshape", false)
+	require.NoError(t, err)
+	assert.Equal(t, service.BACKFILL_RUNNING, started.State)
+	progress := waitBackfill(t, backfills, service.BACKFILL_COMPLETED)
+
+	assert.Equal(t, int64(2), progress.Processed)
+	assert.Equal(t, int64(1), progress.Updated)
+	assert.Equal(t, []int64{5}, checkpoints(fdb))
+}
+
+func TestBackfills_DryRun(t *testing.T) {
+	backfills, fdb := setupBackfill(t, 0)
+	require.NoError(t, backfills.Register(service.Backfill{Name: "reshape", Table: "MEMBER", ChunkSize: 2, Transform: reshapeOdd}))
+
+	_, err := backfills.Start("reshape", true)
+	require.NoError(t, err)
+	progress := waitBackfill(t, backfills, service.BACKFILL_COMPLETED)
+
+	assert.True(t, progress.DryRun)
+	assert.Equal(t, int64(3), progress.Updated, "updates are run")
+	assert.Empty(t, checkpoints(fdb))
+	assert.Equal(t, 3, countStatements(fdb, "ROLLBACK"))
+}
+
+func TestBackfills_TransformError(t *testing.T) {
+	backfills, fdb := setupBackfill(t, 0)
+	errInvalid := errors.New("invalid document")
+	require.NoError(t, backfills.Register(service.Backfill{Name: "reshape", Table: "MEMBER", ChunkSize: 2,
+		Transform: func(ctx context.Context, rows []service.BackfillRow) ([]service.BackfillUpdate, error) {
+			if rows[0].Id > 2 {
+				return nil, errInvalid
+			}
+			return nil, nil
+		},
+	}))
+
+	_, err := backfills.Start("reshape", false)
+	require.NoError(t, err)
+	progress := waitBackfill(t, backfills, service.BACKFILL_FAILED)
+
+	assert.Equal(t, "backfill reshape: transform of MEMBER 2 to 4: invalid document", progress.Error)
+	assert.Equal(t, int64(2), progress.LastId)
+	assert.Equal(t, []int64{2}, checkpoints(fdb))
+}
+
+func TestBackfills_PauseAndCancel(t *testing.T) {
+	backfills, fdb := setupBackfill(t, 0)
+	entered, proceed := make(chan int64, 5), make(chan struct{})
+	require.NoError(t, backfills.Register(service.Backfill{Name: "reshape", Table: "MEMBER", ChunkSize: 2,
+		Transform: func(ctx context.Context, rows []service.BackfillRow) ([]service.BackfillUpdate, error) {
+			entered <- rows[0].Id
+			<-proceed
+			return nil, nil
+		},
+	}))
+
+	_, err := backfills.Start("reshape", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), <-entered)
+
+	paused, err := backfills.Pause("reshape")
+	require.NoError(t, err)
+	assert.Equal(t, service.BACKFILL_PAUSED, paused.State)
+	proceed <- struct{}{}
+	require.Eventually(t, func() bool { return backfills.Progress()[0].Chunks == 1 }, time.Second, time.Millisecond)
+	assert.Empty(t, entered, "no chunk while paused")
+
+	_, err = backfills.Start("reshape", true)
+	assert.ErrorIs(t, err, service.ErrBackfillState, "resumed in the mode it was started in")
+	_, err = backfills.Start("reshape", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), <-entered)
+
+	cancelled, err := backfills.Cancel("reshape")
+	require.NoError(t, err)
+	assert.Equal(t, service.BACKFILL_CANCELLED, cancelled.State)
+	close(proceed)
+	require.Eventually(t, func() bool {
+		_, err = backfills.Start("reshape", false)
+		return err == nil
+	}, time.Second, time.Millisecond, "started again once stopped")
+	waitBackfill(t, backfills, service.BACKFILL_COMPLETED)
+	assert.Equal(t, int64(2), checkpoints(fdb)[0])
+}
+
+func TestBackfills_Errors(t *testing.T) {
+	backfills, _ := setupBackfill(t, 0)
+	require.NoError(t, backfills.Register(service.Backfill{Name: "reshape", Table: "MEMBER", Transform: reshapeOdd}))
+
+	assert.Error(t, backfills.Register(service.Backfill{Name: "reshape", Table: "MEMBER", Transform: reshapeOdd}))
+	assert.Error(t, backfills.Register(service.Backfill{Name: "no table", Transform: reshapeOdd}))
+
+	_, err := backfills.Start("unknown", false)
+	assert.ErrorIs(t, err, service.ErrBackfillNotFound)
+	_, err = backfills.Pause("reshape")
+	assert.ErrorIs(t, err, service.ErrBackfillState)
+	_, err = backfills.Cancel("reshape")
+	assert.ErrorIs(t, err, service.ErrBackfillState)
+}
@@ -11,10 +11,11 @@ type MandatoryRequest struct {
 }
 
 type BaseEntity struct {
-	Id          int64        `db:"ID" json:"id" example:"12345"`
-	CreatedDate time.Time    `db:"CREATED_DATE"`
+	Id          int64        `db:"ID,pk" json:"id" example:"12345"`
+	CreatedDate time.Time    `db:"CREATED_DATE,noupdate"`
 	UpdatedDate sql.NullTime `db:"UPDATED_DATE"`
-	IsDeleted   string       `db:"IS_DELETED"`
+	IsDeleted   string       `db:"IS_DELETED,noupdate"`
+	Version     int64        `db:"VERSION,version" json:"version"`
 }
 
 type SqlParameter struct {
@@ -29,6 +30,9 @@ type SqlParameter struct {
 	// filter for WHERE clause
 	Params []FilterParam `json:"params,omitempty"`
 
+	// nested AND / OR / NOT conditions, ANDed with Params
+	Where *Condition `json:"where,omitempty"`
+
 	// Order By Clause (column ASC / DESC)
 	OrderBy []string `json:"order,omitempty"`
 
@@ -52,6 +56,18 @@ type SqlParameter struct {
 	// New: List of joins
 	Joins []JoinClause `json:"joins,omitempty"`
 
+	// Keyset pagination, replaces Offset and OrderBy when set
+	Keyset *Keyset `json:"-"`
+
+	// Soft-deleted rows returned by reads, see WithDeleted and OnlyDeleted
+	Deleted DeletedScope `json:"-"`
+
+	// Version expected by an update, 0 disables the optimistic lock. See VersionConflictError.
+	Version int64 `json:"-"`
+
+	// incrementVersion makes an update without an expected Version increment VERSION all the same
+	incrementVersion bool
+
 	SelectField string `json:"-"` //optional,if want to put here new field first need adding comma  e.g. ,name
 }
 
@@ -74,6 +90,9 @@ type FilterParam struct {
 	Field   string      `json:"field"`
 	Operand string      `json:"operand"`
 	Value   interface{} `json:"value"`
+	// JSON path of the array searched by CONTAINS / NOT_CONTAINS, e.g. $.dataCategories.
+	// When empty the column is searched with Oracle Text and needs a CONTEXT index.
+	Path string `json:"path,omitempty"`
 }
 
 type JoinClause struct {
@@ -84,10 +103,11 @@ type JoinClause struct {
 	Conditions []FilterParam `json:"conditions,omitempty"` // optional
 }
 type Pagination struct {
-	LastDate  string `json:"last_date,omitempty" example:"2021-05-31T08:20:02Z"`
-	TotalData int64  `json:"totalData,omitempty"`
-	NextPage  bool   `json:"nextPage"`
-	LastID    int64  `json:"lastId,omitempty"`
+	LastDate   string `json:"last_date,omitempty" example:"2021-05-31T08:20:02Z"`
+	TotalData  int64  `json:"totalData,omitempty"`
+	NextPage   bool   `json:"nextPage"`
+	LastID     int64  `json:"lastId,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 func MakePagination(count int64, params SqlParameter, lenData int) Pagination {
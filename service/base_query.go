@@ -1,8 +1,23 @@
 package service
 
 const (
-	findHistoryQuery                   = `SELECT id, old_value, new_value, status, action, created_date, created_by FROM %s WHERE %s = ? ORDER BY id desc limit ?`
-	insertHistoryQuery                 = `INSERT INTO %s ( %s, old_value, new_value, status, action, created_by, created_date, store_id ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	createMigrationFlagQuery           = `INSERT INTO migration_flag ( table_name, action, last_update_id, ` + "`limit`" + `) VALUES (?, ?, ?, ?)`
-	findLastUpdateIdMigrationFlagQuery = `SELECT * FROM migration_flag WHERE table_name = ? ORDER BY id desc limit 1`
+	// upsertBlock inserts the row, or updates it when the insert hits the unique key of an existing one, and
+	// reports which in the same statement. %[4]s is the UPDATE, followed by "; " when there is one.
+	upsertBlock = `BEGIN INSERT INTO %[1]s (%[2]s) VALUES (%[3]s); :` + upsertInsertedBind + ` := 1; ` +
+		`EXCEPTION WHEN DUP_VAL_ON_INDEX THEN %[4]s:` + upsertInsertedBind + ` := 0; END;`
+	// upsertUpdate re-raises the duplicate when no row matches the keys, i.e. another unique key was violated
+	upsertUpdate       = `UPDATE %s t SET %s WHERE %s; IF SQL%%ROWCOUNT = 0 THEN RAISE; END IF; `
+	upsertInsertedBind = "inserted"
+	upsertBindPrefix   = "v"
+
+	findHistoryQuery                   = `SELECT ID, OLD_VALUE, NEW_VALUE, ACTION, CREATED_BY, CREATED_DATE FROM %s WHERE %s = :1 ORDER BY ID DESC OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY`
+	countHistoryQuery                  = `SELECT COUNT(*) FROM %s WHERE %s = :1`
+	insertHistoryQuery                 = `INSERT INTO %s (%s, OLD_VALUE, NEW_VALUE, ACTION, CREATED_BY, CREATED_DATE) VALUES (:1, :2, :3, :4, :5, :6)`
+	snapshotQuery                      = `SELECT * FROM %s`
+	createMigrationFlagQuery           = `INSERT INTO MIGRATION_FLAG (TABLE_NAME, ACTION, LAST_UPDATE_ID, "LIMIT", CREATED_DATE) VALUES (:1, :2, :3, :4, :5)`
+	findLastUpdateIdMigrationFlagQuery = `SELECT LAST_UPDATE_ID FROM MIGRATION_FLAG WHERE TABLE_NAME = :1 AND ACTION = :2 ORDER BY ID DESC FETCH FIRST 1 ROWS ONLY`
+	// FOR UPDATE cannot be combined with FETCH FIRST, the chunk is bounded first then locked by range
+	backfillBoundQuery       = `SELECT MAX(%[1]s) FROM (SELECT %[1]s FROM %[2]s WHERE %[1]s > :1 ORDER BY %[1]s FETCH FIRST :2 ROWS ONLY)`
+	backfillChunkConditional = ` WHERE %[1]s > :1 AND %[1]s <= :2 ORDER BY %[1]s FOR UPDATE`
+	backfillRemainingQuery   = `SELECT COUNT(*) FROM %[2]s WHERE %[1]s > :1`
 )
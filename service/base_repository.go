@@ -5,15 +5,17 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"reflect"
 	"runtime"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/jmoiron/sqlx"
 
 	"oracle.com/oracle/my-go-oracle-app/infra/database"
 	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
 	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+	"oracle.com/oracle/my-go-oracle-app/pkg/helpers"
 )
 
 const logName = "[Base Repository][Operation]"
@@ -25,6 +27,27 @@ const (
 type BaseRepository struct {
 	MasterDB oracle.MasterDB
 	SlaveDB  oracle.SlaveDB
+
+	// histories are the audited tables keyed by name, see WithHistory
+	histories map[string]HistoryTable
+
+	// schema allows the identifiers of generated statements, see WithSchema
+	schema *Schema
+
+	// retry retries operations failing with a transient error, see WithRetry
+	retry *retryer
+
+	// routing sends reads to MasterDB when SlaveDB cannot serve them, see WithReadRouting
+	routing *ReadRouting
+
+	// timeouts bound each operation, see WithTimeouts
+	timeouts *Timeouts
+
+	// softDeletes are the tables whose reads are scoped by IS_DELETED, see WithSoftDelete
+	softDeletes map[string]bool
+
+	// versioned are the tables whose soft deletes and restores increment VERSION, see WithVersionColumn
+	versioned map[string]bool
 }
 
 type BaseRepositoryInterface interface {
@@ -35,24 +58,21 @@ type BaseRepositoryInterface interface {
 
 // SelectWithParameter can return multiple row. dest must be pointer to a slice
 func (r *BaseRepository) SelectOperations(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	slog.InfoContext(ctx, fmt.Sprintf("query= %v, paramValue=%v,", query, args))
-	newContext := database.StartMetrics(ctx, database.Event{
-		Name: GetLastFuncCallerName(),
-	})
-
-	// Log incoming context deadline information to help debug timeouts
-	if dl, ok := ctx.Deadline(); ok {
-		slog.InfoContext(ctx, fmt.Sprintf("context deadline=%v, remaining=%v", dl, time.Until(dl)))
-	} else {
-		slog.InfoContext(ctx, "context has no deadline")
-	}
+	ctx = logQuery(ctx, query, args)
+	ctx, cancel := r.withTimeout(ctx, OPERATION_READ)
+	defer cancel()
+	operation := GetLastFuncCallerName()
 
 	var err error
 	txConn := ctx.Value(constants.CONTEXT_TRANSACTION)
 	if txConn == nil {
-		err = r.SlaveDB.SelectContext(newContext, dest, query, args...)
+		err = r.read(ctx, operation, dest, func(ctx context.Context, db reader) error {
+			return db.SelectContext(ctx, dest, query, args...)
+		})
 	} else {
-		err = txConn.(*sqlx.Tx).SelectContext(newContext, dest, query, args...)
+		err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) error {
+			return r.inTx(txConn).SelectContext(ctx, dest, query, args...)
+		})
 	}
 
 	if err != nil {
@@ -64,24 +84,21 @@ func (r *BaseRepository) SelectOperations(ctx context.Context, dest interface{},
 
 // SelectWithParameter can return multiple row. dest must be pointer to a slice
 func (r *BaseRepository) GetOperations(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	slog.InfoContext(ctx, fmt.Sprintf("query= %v, paramValue=%v,", query, args))
-	newContext := database.StartMetrics(ctx, database.Event{
-		Name: GetLastFuncCallerName(),
-	})
-
-	// Log incoming context deadline information to help debug timeouts
-	if dl, ok := ctx.Deadline(); ok {
-		slog.InfoContext(ctx, fmt.Sprintf("context deadline=%v, remaining=%v", dl, time.Until(dl)))
-	} else {
-		slog.InfoContext(ctx, "context has no deadline")
-	}
+	ctx = logQuery(ctx, query, args)
+	ctx, cancel := r.withTimeout(ctx, OPERATION_READ)
+	defer cancel()
+	operation := GetLastFuncCallerName()
 
 	var err error
 	txConn := ctx.Value(constants.CONTEXT_TRANSACTION)
 	if txConn == nil {
-		err = r.SlaveDB.GetContext(newContext, dest, query, args...)
+		err = r.read(ctx, operation, dest, func(ctx context.Context, db reader) error {
+			return db.GetContext(ctx, dest, query, args...)
+		})
 	} else {
-		err = txConn.(*sqlx.Tx).GetContext(newContext, dest, query, args...)
+		err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) error {
+			return r.inTx(txConn).GetContext(ctx, dest, query, args...)
+		})
 	}
 
 	if err != nil {
@@ -92,20 +109,24 @@ func (r *BaseRepository) GetOperations(ctx context.Context, dest interface{}, qu
 
 // SelectWithParameter can return multiple row. dest must be pointer to a slice
 func (r *BaseRepository) GetOperationsMasterConn(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
-	slog.InfoContext(ctx, fmt.Sprintf("query= %v, paramValue=%v,", query, args))
-	newContext := database.StartMetrics(ctx, database.Event{
-		Name: GetLastFuncCallerName(),
-	})
+	ctx = logQuery(ctx, query, args)
+	ctx, cancel := r.withTimeout(ctx, OPERATION_READ)
+	defer cancel()
+	operation := GetLastFuncCallerName()
 	txConn := ctx.Value(constants.CONTEXT_TRANSACTION)
 	if txConn != nil {
-		err = txConn.(*sqlx.Tx).GetContext(newContext, dest, query, args...)
+		err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) error {
+			return r.inTx(txConn).GetContext(ctx, dest, query, args...)
+		})
 		if err != nil {
 			return err
 		}
 
 		return nil
 	}
-	err = r.MasterDB.GetContext(newContext, dest, query, args...)
+	err = r.retrying(ctx, operation, database.TARGET_MASTER, func(ctx context.Context) error {
+		return r.MasterDB.GetContext(ctx, dest, query, args...)
+	})
 
 	if err != nil {
 		return err
@@ -117,18 +138,24 @@ func (r *BaseRepository) GetOperationsMasterConn(ctx context.Context, dest inter
 
 // SelectWithParameter can return multiple row. dest must be pointer to a slice
 func (r *BaseRepository) SelectWithParameter(ctx context.Context, dest interface{}, param SqlParameter) error {
-	query, args := r.GenerateQuerySelectWithParams("", param)
-	slog.InfoContext(ctx, fmt.Sprintf("query= %v, paramValue=%v,", query, args))
-	newContext := database.StartMetrics(ctx, database.Event{
-		Name: GetLastFuncCallerName(),
-	})
+	query, args, err := r.BuildQuerySelect("", param)
+	if err != nil {
+		return err
+	}
+	ctx = logQuery(ctx, query, args)
+	ctx, cancel := r.withTimeout(ctx, OPERATION_READ)
+	defer cancel()
+	operation := GetLastFuncCallerName()
 
-	var err error
 	txConn := ctx.Value(constants.CONTEXT_TRANSACTION)
 	if txConn == nil {
-		err = r.SlaveDB.SelectContext(newContext, dest, query, args...)
+		err = r.read(ctx, operation, dest, func(ctx context.Context, db reader) error {
+			return db.SelectContext(ctx, dest, query, args...)
+		})
 	} else {
-		err = txConn.(*sqlx.Tx).SelectContext(newContext, dest, query, args...)
+		err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) error {
+			return r.inTx(txConn).SelectContext(ctx, dest, query, args...)
+		})
 	}
 
 	if err != nil {
@@ -142,17 +169,23 @@ func (r *BaseRepository) SelectWithParameter(ctx context.Context, dest interface
 
 // GetWithParameter will return only 1 row. dest must be pointer to a struct
 func (r *BaseRepository) GetWithParameter(ctx context.Context, dest interface{}, param SqlParameter) error {
-	query, args := r.GenerateQuerySelectWithParams("", param)
-	slog.InfoContext(ctx, fmt.Sprintf("query= %v, paramValue=%v,", query, args))
-	newContext := database.StartMetrics(ctx, database.Event{
-		Name: GetLastFuncCallerName(),
-	})
-	var err error
+	query, args, err := r.BuildQuerySelect("", param)
+	if err != nil {
+		return err
+	}
+	ctx = logQuery(ctx, query, args)
+	ctx, cancel := r.withTimeout(ctx, OPERATION_READ)
+	defer cancel()
+	operation := GetLastFuncCallerName()
 	txConn := ctx.Value(constants.CONTEXT_TRANSACTION)
 	if txConn == nil {
-		err = r.SlaveDB.GetContext(newContext, dest, query, args...)
+		err = r.read(ctx, operation, dest, func(ctx context.Context, db reader) error {
+			return db.GetContext(ctx, dest, query, args...)
+		})
 	} else {
-		err = txConn.(*sqlx.Tx).GetContext(newContext, dest, query, args...)
+		err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) error {
+			return r.inTx(txConn).GetContext(ctx, dest, query, args...)
+		})
 	}
 
 	if err != nil {
@@ -165,7 +198,10 @@ func (r *BaseRepository) GetWithParameter(ctx context.Context, dest interface{},
 
 // WriteOrUpdateOperation will execute query.
 func (r *BaseRepository) WriteOrUpdateOperation(ctx context.Context, query string, returnedID *int64, args ...interface{}) (int64, error) {
-	slog.InfoContext(ctx, fmt.Sprintf("query= %v, paramValue=%v, returnedID=%v", query, args, returnedID))
+	ctx = logQuery(ctx, query, args)
+	ctx, cancel := r.withTimeout(ctx, OPERATION_WRITE)
+	defer cancel()
+	operation := GetLastFuncCallerName()
 
 	var (
 		result sql.Result
@@ -181,15 +217,22 @@ func (r *BaseRepository) WriteOrUpdateOperation(ctx context.Context, query strin
 		// --- Special Handling for Oracle RETURNING INTO ---
 		if txConn == nil {
 			// Use ExecContext for the master database connection
-			_, err = r.MasterDB.ExecContext(ctx, query, args...)
+			err = r.retryingWrite(ctx, operation, database.TARGET_MASTER, query, args, func(ctx context.Context) (err error) {
+				_, err = r.MasterDB.ExecContext(ctx, query, args...)
+				return err
+			})
 		} else {
 			// Use ExecContext for the transaction connection
-			_, err = txConn.(*sqlx.Tx).ExecContext(ctx, query, args...)
+			err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) (err error) {
+				_, err = r.inTx(txConn).ExecContext(ctx, query, args...)
+				return err
+			})
 		}
 
 		if err != nil {
 			return 0, err
 		}
+		markWrite(ctx)
 
 		// For Oracle's RETURNING INTO clause, the ID value was set by godror through the `sql.Out` parameter
 		// Return 1 row affected since the INSERT was successful and the ID was captured
@@ -199,14 +242,21 @@ func (r *BaseRepository) WriteOrUpdateOperation(ctx context.Context, query strin
 		// --- Standard ExecContext for UPDATE, DELETE, or simple INSERT ---
 
 		if txConn == nil {
-			result, err = r.MasterDB.ExecContext(ctx, query, args...)
+			err = r.retryingWrite(ctx, operation, database.TARGET_MASTER, query, args, func(ctx context.Context) (err error) {
+				result, err = r.MasterDB.ExecContext(ctx, query, args...)
+				return err
+			})
 		} else {
-			result, err = txConn.(*sqlx.Tx).ExecContext(ctx, query, args...)
+			err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) (err error) {
+				result, err = r.inTx(txConn).ExecContext(ctx, query, args...)
+				return err
+			})
 		}
 
 		if err != nil {
 			return 0, err
 		}
+		markWrite(ctx)
 
 		// Return RowsAffected for standard UPDATE, DELETE, and simple INSERTs.
 		return result.RowsAffected()
@@ -214,7 +264,10 @@ func (r *BaseRepository) WriteOrUpdateOperation(ctx context.Context, query strin
 }
 
 func (r *BaseRepository) WriteOrUpdateOperation2(ctx context.Context, query string, args ...interface{}) (int64, error) {
-	slog.InfoContext(ctx, fmt.Sprintf("query= %v, paramValue=%v,", query, args))
+	ctx = logQuery(ctx, query, args)
+	ctx, cancel := r.withTimeout(ctx, OPERATION_WRITE)
+	defer cancel()
+	operation := GetLastFuncCallerName()
 
 	var (
 		result sql.Result
@@ -223,15 +276,22 @@ func (r *BaseRepository) WriteOrUpdateOperation2(ctx context.Context, query stri
 
 	txConn := ctx.Value(constants.CONTEXT_TRANSACTION)
 	if txConn == nil {
-		result, err = r.MasterDB.ExecContext(ctx, query, args...)
+		err = r.retryingWrite(ctx, operation, database.TARGET_MASTER, query, args, func(ctx context.Context) (err error) {
+			result, err = r.MasterDB.ExecContext(ctx, query, args...)
+			return err
+		})
 	} else {
-		result, err = txConn.(*sqlx.Tx).ExecContext(ctx, query, args...)
+		err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) (err error) {
+			result, err = r.inTx(txConn).ExecContext(ctx, query, args...)
+			return err
+		})
 	}
 
 	if err != nil {
 
 		return 0, err
 	}
+	markWrite(ctx)
 
 	if strings.HasPrefix(strings.ToUpper(query), "INSERT") {
 		return result.LastInsertId()
@@ -240,6 +300,16 @@ func (r *BaseRepository) WriteOrUpdateOperation2(ctx context.Context, query stri
 }
 
 func (r *BaseRepository) Insert(ctx context.Context, sqlParameter SqlParameter) (int64, error) {
+	if err := r.Validate(sqlParameter); err != nil {
+		return 0, err
+	}
+	if history, ok := r.historyOf(sqlParameter.TableName); ok {
+		if _, err := r.InsertReturning(ctx, sqlParameter, history.PrimaryKey); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+
 	sql, args := r.GenerateQueryInsert(sqlParameter)
 
 	res, err := r.WriteOrUpdateOperation(ctx, sql, nil, args...)
@@ -247,24 +317,60 @@ func (r *BaseRepository) Insert(ctx context.Context, sqlParameter SqlParameter)
 	return res, err
 }
 
-func (r *BaseRepository) Update(ctx context.Context, sqlParameter SqlParameter) (int64, error) {
-	sql, args := r.GenerateQueryUpdate(sqlParameter)
+// InsertReturning inserts a row and returns the value generated for primaryKey with RETURNING ... INTO
+func (r *BaseRepository) InsertReturning(ctx context.Context, sqlParameter SqlParameter, primaryKey string) (int64, error) {
+	if err := r.Validate(SqlParameter{TableName: sqlParameter.TableName, Values: append(slices.Clip(sqlParameter.Values), Value{Field: primaryKey})}); err != nil {
+		return 0, err
+	}
+	return r.auditedInsert(ctx, sqlParameter.TableName, func(ctx context.Context) (int64, error) {
+		var returnedID int64
+		b := NewBinds()
+		query := r.GenerateQueryInsertWithBinds(b, sqlParameter)
+		query += fmt.Sprintf(" RETURNING %s INTO %s", primaryKey, b.Bind(sql.Out{Dest: &returnedID}))
 
-	res, err := r.WriteOrUpdateOperation(ctx, sql, nil, args...)
+		_, err := r.WriteOrUpdateOperation(ctx, query, &returnedID, b.Args()...)
+		return returnedID, err
+	})
+}
 
-	return res, err
+// Update updates the rows matching sqlParameter.Params. When sqlParameter.Version is set and no row
+// is affected, a VersionConflictError (or sql.ErrNoRows when the row does not exist) is returned.
+// Soft-deleted rows are scoped out like reads, see WithSoftDelete: they are brought back with Restore only.
+func (r *BaseRepository) Update(ctx context.Context, sqlParameter SqlParameter) (int64, error) {
+	return r.update(ctx, sqlParameter, constants.ACTION_UPDATE)
+}
+
+// update runs the UPDATE, recording action into the history of audited tables
+func (r *BaseRepository) update(ctx context.Context, sqlParameter SqlParameter, action string) (int64, error) {
+	if err := r.Validate(sqlParameter); err != nil {
+		return 0, err
+	}
+	if deleted, ok := r.deletedFilter(sqlParameter); ok {
+		sqlParameter.Params = append(slices.Clip(sqlParameter.Params), deleted)
+	}
+	return r.audited(ctx, sqlParameter, action, func(ctx context.Context) (int64, error) {
+		return r.updateRows(ctx, sqlParameter)
+	})
 }
 
-func (r *BaseRepository) Delete(ctx context.Context, sqlParameter SqlParameter) (int64, error) {
-	sql := fmt.Sprintf("DELETE FROM %s", sqlParameter.TableName)
-	conditional, args := r.GenerateConditional(sqlParameter)
-	sql += conditional
+func (r *BaseRepository) updateRows(ctx context.Context, sqlParameter SqlParameter) (int64, error) {
+	sql, args := r.GenerateQueryUpdate(sqlParameter)
+
 	res, err := r.WriteOrUpdateOperation(ctx, sql, nil, args...)
+	if err == nil && res == 0 && sqlParameter.Version != 0 {
+		return 0, r.versionConflict(ctx, sqlParameter)
+	}
 
 	return res, err
 }
 
 func (r *BaseRepository) GenerateQueryInsert(sqlParameter SqlParameter) (string, []interface{}) {
+	b := NewBinds()
+	return r.GenerateQueryInsertWithBinds(b, sqlParameter), b.Args()
+}
+
+// GenerateQueryInsertWithBinds generates INSERT INTO ... VALUES (...) binding the values through b
+func (r *BaseRepository) GenerateQueryInsertWithBinds(b *Binds, sqlParameter SqlParameter) string {
 	var s strings.Builder
 	s.WriteString("INSERT INTO ")
 	s.WriteString(sqlParameter.TableName)
@@ -275,37 +381,142 @@ func (r *BaseRepository) GenerateQueryInsert(sqlParameter SqlParameter) (string,
 			s.WriteString(constants.COMMA)
 		}
 	}
-	var args []interface{}
 	s.WriteString(") VALUES (")
 	for i := 0; i < len(sqlParameter.Values); i++ {
-		s.WriteString("?")
-		args = append(args, sqlParameter.Values[i].Value)
+		s.WriteString(b.Bind(sqlParameter.Values[i].Value))
 		if i < len(sqlParameter.Values)-1 {
 			s.WriteString(constants.COMMA)
 		}
 	}
 	s.WriteString(")")
-	return s.String(), args
+	return s.String()
 }
 
 func (r *BaseRepository) GenerateQueryUpdate(sqlParameter SqlParameter) (string, []interface{}) {
+	b := NewBinds()
+	return r.GenerateQueryUpdateWithBinds(b, sqlParameter), b.Args()
+}
+
+// GenerateQueryUpdateWithBinds generates UPDATE ... SET ... WHERE ..., the SET values are bound before the conditions
+func (r *BaseRepository) GenerateQueryUpdateWithBinds(b *Binds, sqlParameter SqlParameter) string {
 	var s strings.Builder
 	s.WriteString("UPDATE ")
 	s.WriteString(sqlParameter.TableName)
 	s.WriteString(" SET ")
-	var args []interface{}
 	for i := 0; i < len(sqlParameter.Values); i++ {
 		s.WriteString(sqlParameter.Values[i].Field)
-		args = append(args, sqlParameter.Values[i].Value)
-		s.WriteString("=?")
+		s.WriteString("=")
+		s.WriteString(b.Bind(sqlParameter.Values[i].Value))
 		if i < len(sqlParameter.Values)-1 {
 			s.WriteString(constants.COMMA)
 		}
 	}
 
-	conditional, conditionalArgs := r.GenerateConditional(sqlParameter)
-	s.WriteString(conditional)
-	return s.String(), append(args, conditionalArgs...)
+	if sqlParameter.Version != 0 || sqlParameter.incrementVersion {
+		set, condition := versionCheck(sqlParameter)
+		if len(sqlParameter.Values) != 0 {
+			s.WriteString(constants.COMMA)
+		}
+		s.WriteString(set)
+		if sqlParameter.Version != 0 {
+			sqlParameter.Params = append(slices.Clip(sqlParameter.Params), condition)
+		}
+	}
+
+	s.WriteString(r.GenerateConditionalWithBinds(b, sqlParameter))
+	return s.String()
+}
+
+// Upsert inserts the values of sqlParameter or, when a row already has the same keyFields, sets updates on it,
+// and reports whether it was inserted. nil updates sets every value which is not a key. The VERSION of the
+// existing row is incremented, never set, see GenerateQueryUpsert.
+// keyFields must be the columns of a unique key: the row is inserted and, when the key already exists,
+// updated instead in the same PL/SQL block, so both are atomic. Of concurrent upserts of a new key, one
+// inserts while the others wait for it to commit then update, reporting inserted false; none fails with
+// ORA-00001. A duplicate on another unique key is returned as is.
+func (r *BaseRepository) Upsert(ctx context.Context, sqlParameter SqlParameter, updates []Value, keyFields []string) (inserted bool, err error) {
+	if err := r.Validate(sqlParameter); err != nil {
+		return false, err
+	}
+	query, args, err := r.GenerateQueryUpsert(sqlParameter, updates, keyFields)
+	if err != nil {
+		return false, err
+	}
+
+	keyParameter := SqlParameter{TableName: sqlParameter.TableName}
+	for _, value := range sqlParameter.Values {
+		if helpers.StringExists(keyFields, value.Field) {
+			keyParameter.Params = append(keyParameter.Params, MakeFilterParam(value.Field, constants.EQUAL, value.Value))
+		}
+	}
+
+	var insertedFlag int64
+	args = append(args, sql.Named(upsertInsertedBind, sql.Out{Dest: &insertedFlag}))
+	_, err = r.audited(ctx, keyParameter, constants.ACTION_UPDATE, func(ctx context.Context) (int64, error) {
+		return r.WriteOrUpdateOperation(ctx, query, nil, args...)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return insertedFlag == 1, nil
+}
+
+// GenerateQueryUpsert generates the PL/SQL block run by Upsert: an INSERT of the values of sqlParameter whose
+// DUP_VAL_ON_INDEX handler UPDATEs the row matching keyFields with updates, nil updating every value which is
+// not a key. Keys and VERSION are never set by the UPDATE, VERSION is incremented when sqlParameter has one.
+// The block sets the :inserted out bind, which is left to the caller. Binds are named (:v1..:vN) so an update
+// of an inserted value reuses its bind.
+func (r *BaseRepository) GenerateQueryUpsert(sqlParameter SqlParameter, updates []Value, keyFields []string) (string, []interface{}, error) {
+	if len(keyFields) == 0 {
+		return "", nil, fmt.Errorf("upsert %s: key fields are mandatory", sqlParameter.TableName)
+	}
+	for _, key := range keyFields {
+		if !slices.ContainsFunc(sqlParameter.Values, func(value Value) bool { return value.Field == key }) {
+			return "", nil, fmt.Errorf("upsert %s: key field %s has no value", sqlParameter.TableName, key)
+		}
+	}
+	if updates == nil {
+		updates = sqlParameter.Values
+	}
+
+	b := NewNamedBinds(upsertBindPrefix)
+	var columns, binds, keys, set []string
+	versioned := false
+	for _, value := range sqlParameter.Values {
+		bind := b.Bind(value.Value)
+		columns = append(columns, value.Field)
+		binds = append(binds, bind)
+		if helpers.StringExists(keyFields, value.Field) {
+			keys = append(keys, fmt.Sprintf("t.%s = %s", value.Field, bind))
+		}
+		versioned = versioned || value.Field == VERSION_COLUMN
+	}
+	for _, value := range updates {
+		if helpers.StringExists(keyFields, value.Field) || value.Field == VERSION_COLUMN {
+			continue
+		}
+		i := slices.IndexFunc(sqlParameter.Values, func(inserted Value) bool {
+			return inserted.Field == value.Field && reflect.DeepEqual(inserted.Value, value.Value)
+		})
+		bind := ""
+		if i >= 0 {
+			bind = binds[i]
+		} else {
+			bind = b.Bind(value.Value)
+		}
+		set = append(set, fmt.Sprintf("t.%s = %s", value.Field, bind))
+	}
+	if versioned {
+		set = append(set, fmt.Sprintf("t.%s = t.%s + 1", VERSION_COLUMN, VERSION_COLUMN))
+	}
+
+	var update string
+	if len(set) > 0 {
+		update = fmt.Sprintf(upsertUpdate, sqlParameter.TableName, strings.Join(set, constants.COMMA), strings.Join(keys, constants.AND))
+	}
+	query := fmt.Sprintf(upsertBlock, sqlParameter.TableName, strings.Join(columns, constants.COMMA), strings.Join(binds, constants.COMMA), update)
+	return query, b.Args(), nil
 }
 
 func (r *BaseRepository) GenerateQuerySelectFrom(sqlParameter SqlParameter) string {
@@ -322,65 +533,64 @@ func (r *BaseRepository) GenerateQuerySelectFrom(sqlParameter SqlParameter) stri
 	return sql
 }
 
+// GenerateConditional generates the WHERE clause of sqlParameter: Params joined with AND, then the Where tree
+// and the keyset condition. Binds are numbered :1, :2, ... in the order of the returned args.
 func (r *BaseRepository) GenerateConditional(sqlParameter SqlParameter) (string, []interface{}) {
-	var sql strings.Builder
-	var args []interface{}
+	b := NewBinds()
+	return r.GenerateConditionalWithBinds(b, sqlParameter), b.Args()
+}
+
+// GenerateConditionalWithBinds generates the WHERE clause of sqlParameter binding the values through b
+func (r *BaseRepository) GenerateConditionalWithBinds(b *Binds, sqlParameter SqlParameter) string {
+	var conditions []string
 	if len(sqlParameter.Params) != 0 {
-		sql.WriteString(constants.WHERE)
-		for i := 0; i < len(sqlParameter.Params); i++ {
-			if sqlParameter.Params[i].Operand == "" {
-				sqlParameter.Params[i].Operand = "="
-			}
-			switch sqlParameter.Params[i].Operand {
-			case constants.IN:
-				tempQuery := fmt.Sprintf("%s %s (:%d)", sqlParameter.Params[i].Field, constants.IN, i)
-				tempQuery, tempArgs, _ := sqlx.In(tempQuery, sqlParameter.Params[i].Value)
-				sql.WriteString(tempQuery)
-				args = append(args, tempArgs...)
-			case constants.REVERSE_IN:
-				sql.WriteString(fmt.Sprintf("? %s (%s)", constants.IN, sqlParameter.Params[i].Field))
-				args = append(args, sqlParameter.Params[i].Value)
-			case constants.MULTIPLE_LIKE:
-				or, orarg := r.generateOrClause(strings.Split(sqlParameter.Params[i].Field, ","), constants.LIKE, sqlParameter.Params[i].Value)
-				sql.WriteString(or)
-				args = append(args, orarg...)
-			case constants.MULTIPLE_EQUAL:
-				or, orarg := r.generateOrClause(strings.Split(sqlParameter.Params[i].Field, ","), constants.EQUAL, sqlParameter.Params[i].Value)
-				sql.WriteString(or)
-				args = append(args, orarg...)
-			case constants.IN_LIKE_STRING:
-				or, orarg := r.generateInLikeClause(sqlParameter.Params[i].Field, sqlParameter.Params[i].Value)
-				sql.WriteString(or)
-				args = append(args, orarg...)
-			case constants.IS_NULL:
-				sql.WriteString(fmt.Sprintf("%s %s", sqlParameter.Params[i].Field, sqlParameter.Params[i].Operand))
-			case constants.NOT_IN:
-				tempQuery := fmt.Sprintf("%s %s (:%d)", sqlParameter.Params[i].Field, constants.NOT_IN, i)
-				tempQuery, tempArgs, _ := sqlx.In(tempQuery, sqlParameter.Params[i].Value)
-				sql.WriteString(tempQuery)
-				args = append(args, tempArgs...)
-			default:
-				sql.WriteString(fmt.Sprintf("%s %s :%d", sqlParameter.Params[i].Field, sqlParameter.Params[i].Operand, i))
-				args = append(args, sqlParameter.Params[i].Value)
-			}
-			if i < len(sqlParameter.Params)-1 {
-				sql.WriteString(constants.AND)
-			}
+		conditions = append(conditions, b.and(sqlParameter.Params))
+	}
+	if sqlParameter.Where != nil {
+		if where := b.condition(*sqlParameter.Where); where != "" {
+			conditions = append(conditions, where)
 		}
 	}
+	if sqlParameter.Keyset != nil && sqlParameter.Keyset.After != nil {
+		conditions = append(conditions, sqlParameter.Keyset.condition(b))
+	}
 
-	return sql.String(), args
+	if len(conditions) == 0 {
+		return ""
+	}
+	return constants.WHERE + strings.Join(conditions, constants.AND)
+}
+
+// BuildQuerySelect validates sqlParameter against the schema of r, see WithSchema, then generates its select
+// like GenerateQuerySelectWithParams. query is written as is and must not come from a request.
+func (r *BaseRepository) BuildQuerySelect(query string, sqlParameter SqlParameter) (string, []interface{}, error) {
+	if err := r.Validate(sqlParameter); err != nil {
+		return "", nil, err
+	}
+	sql, args := r.GenerateQuerySelectWithParams(query, sqlParameter)
+	return sql, args, nil
 }
 
 func (r *BaseRepository) GenerateQuerySelectWithParams(query string, sqlParameter SqlParameter) (string, []interface{}) {
+	b := NewBinds()
+	return r.GenerateQuerySelectWithBinds(b, query, sqlParameter), b.Args()
+}
+
+// GenerateQuerySelectWithBinds generates the select of sqlParameter binding join conditions, WHERE clause
+// and pagination through b, in that order
+func (r *BaseRepository) GenerateQuerySelectWithBinds(b *Binds, query string, sqlParameter SqlParameter) string {
 	var sql strings.Builder
-	var argsJoin []interface{}
 	if query == "" {
 		query = r.GenerateQuerySelectFrom(sqlParameter)
 	}
 	sql.WriteString(query)
-	totalParams := len(sqlParameter.Params)
-	totalOrder := len(sqlParameter.OrderBy)
+	if sqlParameter.Keyset != nil {
+		sqlParameter.OrderBy = sqlParameter.Keyset.orderBy()
+		sqlParameter.Offset = 0
+	}
+	if deleted, ok := r.deletedFilter(sqlParameter); ok {
+		sqlParameter.Params = append(slices.Clip(sqlParameter.Params), deleted)
+	}
 	if len(sqlParameter.Joins) > 0 {
 		for _, join := range sqlParameter.Joins {
 			sql.WriteString(fmt.Sprintf(" %s JOIN %s", join.JoinType, join.Table))
@@ -389,18 +599,14 @@ func (r *BaseRepository) GenerateQuerySelectWithParams(query string, sqlParamete
 			}
 			sql.WriteString(fmt.Sprintf(" ON %s", join.On))
 
-			for i, cond := range join.Conditions {
-				// to be: AND io.tablename IN (?, ?)
-				sqlCond, condArgs := buildCondition(cond, i)
-				sql.WriteString(" AND " + sqlCond)
-
-				argsJoin = append(argsJoin, condArgs...)
+			for _, cond := range join.Conditions {
+				// to be: AND io.tablename IN (:1, :2)
+				sql.WriteString(constants.AND + b.filter(cond))
 			}
 		}
 	}
 
-	conditional, args := r.GenerateConditional(sqlParameter)
-	sql.WriteString(conditional)
+	sql.WriteString(r.GenerateConditionalWithBinds(b, sqlParameter))
 
 	if len(sqlParameter.GroupBy) != 0 {
 		sql.WriteString(constants.GROUP_GY)
@@ -425,35 +631,11 @@ func (r *BaseRepository) GenerateQuerySelectWithParams(query string, sqlParamete
 	}
 
 	if sqlParameter.Limit != 0 {
-		sql.WriteString(fmt.Sprintf(" OFFSET :%d ROWS", totalParams+totalOrder+1))
-		args = append(args, sqlParameter.Offset)
-
-		sql.WriteString(fmt.Sprintf(" FETCH NEXT :%d ROWS ONLY", totalParams+totalOrder+2))
-		args = append(args, sqlParameter.Limit)
+		sql.WriteString(fmt.Sprintf(" OFFSET %s ROWS", b.Bind(sqlParameter.Offset)))
+		sql.WriteString(fmt.Sprintf(" FETCH NEXT %s ROWS ONLY", b.Bind(sqlParameter.Limit)))
 	}
 
-	if len(argsJoin) > 0 {
-		args = append(argsJoin, args...)
-	}
-
-	return sql.String(), args
-}
-
-func buildCondition(param FilterParam, i int) (string, []interface{}) {
-	switch v := param.Value.(type) {
-	case []string:
-		placeholders := make([]string, len(v))
-		args := make([]interface{}, len(v))
-		for i, val := range v {
-			placeholders[i] = fmt.Sprintf(":%d", i)
-			args[i] = val
-		}
-		return fmt.Sprintf("%s %s (%s)", param.Field, param.Operand, strings.Join(placeholders, ", ")), args
-	case string:
-		return fmt.Sprintf("%s %s :%d", param.Field, param.Operand, i), []interface{}{v}
-	default:
-		return fmt.Sprintf("%s %s :%d", param.Field, param.Operand, i), []interface{}{param.Value}
-	}
+	return sql.String()
 }
 
 func MakeFilterParam(field, operand string, val interface{}) FilterParam {
@@ -474,6 +656,9 @@ func New(tx oracle.TransactionDB) *TxDb {
 	}
 }
 
+// ExecuteWithTx runs fn with a raw transaction handle.
+//
+// Deprecated: the TxDb handle is not understood by repository methods, use WithTransaction instead.
 func (r *BaseRepository) ExecuteWithTx(ctx context.Context, fn func(*TxDb) error) error {
 	tx, err := r.MasterDB.BeginTxx(ctx, nil)
 	if err != nil {
@@ -500,47 +685,6 @@ func (r *BaseRepository) SlaveClose() {
 	r.SlaveDB.Close()
 }
 
-// generateOrClause will generatate or clause
-func (r *BaseRepository) generateOrClause(listCol []string, operator string, arg interface{}) (string, []interface{}) {
-	count := len(listCol)
-	var s strings.Builder
-	var args []interface{}
-	s.WriteString("(")
-	for i := range listCol {
-		s.WriteString(listCol[i])
-		s.WriteString(" ")
-		s.WriteString(operator)
-		s.WriteString(" ?")
-		args = append(args, arg)
-		if i < count-1 {
-			s.WriteString(constants.OR)
-		}
-	}
-	s.WriteString(")")
-	return s.String(), args
-}
-
-func (r *BaseRepository) generateInLikeClause(listCol string, arg interface{}) (string, []interface{}) {
-	var s strings.Builder
-	var args []interface{}
-	str, _ := arg.(string)
-	argString := strings.Split(str, ",")
-	count := len(argString)
-
-	s.WriteString("(")
-	for i := range argString {
-		s.WriteString(listCol)
-		s.WriteString(" LIKE ?")
-		args = append(args, "%"+argString[i]+"%")
-		if i < count-1 {
-			s.WriteString(constants.OR)
-		}
-	}
-	s.WriteString(")")
-
-	return s.String(), args
-}
-
 // SetDBConnInContext set db connection in context
 func SetTxConnInContext(ctx context.Context, tx *sqlx.Tx) context.Context {
 	return context.WithValue(ctx, constants.CONTEXT_TRANSACTION, tx)
@@ -575,153 +719,19 @@ func GetLastFuncCallerName() (name string) {
 	return
 }
 
-type TimeFilter struct {
-	IssuedStart      time.Time
-	IssuedEnd        time.Time
-	RequestStart     time.Time
-	RequestEnd       time.Time
-	ArrivalTimeStart time.Time
-	ArrivalTimeEnd   time.Time
-	RefundDateStart  time.Time
-	RefundDateEnd    time.Time
-	ExpiredStart     time.Time
-	ExpiredEnd       time.Time
-	ClaimStart       time.Time
-	ClaimEnd         time.Time
-	ClaimNotifStart  time.Time
-	ClaimNotifEnd    time.Time
-}
-
-func GetTimeFilter(params SqlParameter) TimeFilter {
-	var resp TimeFilter
-	for i := range params.Params {
-		if params.Params[i].Field == constants.ISSUED_DATE && params.Params[i].Operand == constants.GREATER_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.IssuedStart = t
-
-		}
-		if params.Params[i].Field == constants.ISSUED_DATE && params.Params[i].Operand == constants.LESS_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.IssuedEnd = t
-		}
-
-		if params.Params[i].Field == constants.REQUEST_DATE && params.Params[i].Operand == constants.GREATER_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.RequestStart = t
-		}
-		if params.Params[i].Field == constants.REQUEST_DATE && params.Params[i].Operand == constants.LESS_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.RequestEnd = t
-		}
-
-		if params.Params[i].Field == constants.ARRIVAL_DATE && params.Params[i].Operand == constants.GREATER_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.ArrivalTimeStart = t
-		}
-
-		if params.Params[i].Field == constants.ARRIVAL_DATE && params.Params[i].Operand == constants.LESS_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.ArrivalTimeEnd = t
-		}
-
-		if params.Params[i].Field == constants.REFUND_DATE && params.Params[i].Operand == constants.GREATER_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.RefundDateStart = t
-		}
-
-		if params.Params[i].Field == constants.REFUND_DATE && params.Params[i].Operand == constants.LESS_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.RefundDateEnd = t
-		}
-
-		if params.Params[i].Field == constants.EXPIRED_DATE && params.Params[i].Operand == constants.GREATER_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.ExpiredStart = t
-		}
-
-		if params.Params[i].Field == constants.EXPIRED_DATE && params.Params[i].Operand == constants.LESS_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.ExpiredEnd = t
-		}
-
-		if params.Params[i].Field == constants.CLAIM_DATE && params.Params[i].Operand == constants.GREATER_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.ClaimStart = t
-		}
-
-		if params.Params[i].Field == constants.CLAIM_DATE && params.Params[i].Operand == constants.LESS_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.ClaimEnd = t
-		}
-
-		if params.Params[i].Field == constants.CLAIM_NOTIF_DATE && params.Params[i].Operand == constants.GREATER_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.ClaimNotifStart = t
-		}
-
-		if params.Params[i].Field == constants.CLAIM_NOTIF_DATE && params.Params[i].Operand == constants.LESS_THAN_EQUAL {
-			var t time.Time
-			t, _ = time.Parse(constants.DATE_TIME_FORMAT, fmt.Sprintf("%v", params.Params[i].Value))
-			resp.ClaimNotifEnd = t
-		}
-
-	}
-
-	return resp
-}
-
-func IsTimeFilterValid(filter TimeFilter, max int) error {
-	emptyTime := time.Time{}
-	validIssued := filter.IssuedEnd != emptyTime && filter.IssuedStart != emptyTime
-	onRangeIssued := validIssued && filter.IssuedStart.Add(24*time.Hour*time.Duration(max)).Unix() >= filter.IssuedEnd.Unix()
-
-	validRequest := filter.RequestEnd != emptyTime && filter.RequestStart != emptyTime
-	onRangeRequest := validRequest && filter.RequestStart.Add(24*time.Hour*time.Duration(max)).Unix() >= filter.RequestEnd.Unix()
-
-	validArrival := filter.ArrivalTimeEnd != emptyTime && filter.ArrivalTimeStart != emptyTime
-	onRangeArrival := validArrival && filter.ArrivalTimeStart.Add(24*time.Hour*time.Duration(max)).Unix() >= filter.ArrivalTimeEnd.Unix()
-
-	validRefund := filter.RefundDateEnd != emptyTime && filter.RefundDateStart != emptyTime
-	onRangeRefund := validRefund && filter.ArrivalTimeStart.Add(24*time.Hour*time.Duration(max)).Unix() >= filter.ArrivalTimeEnd.Unix()
-
-	validExpire := filter.ExpiredEnd != emptyTime && filter.ExpiredStart != emptyTime
-	onRangeExpire := validExpire && filter.ExpiredStart.Add(24*time.Hour*time.Duration(max)).Unix() >= filter.ExpiredEnd.Unix()
-
-	validClaim := filter.ClaimEnd != emptyTime && filter.ClaimStart != emptyTime
-	onRangeClaim := validClaim && filter.ClaimStart.Add(24*time.Hour*time.Duration(max)).Unix() >= filter.ClaimEnd.Unix()
-
-	validClaimNotif := filter.ClaimNotifEnd != emptyTime && filter.ClaimNotifStart != emptyTime
-	onRangeClaimNotif := validClaimNotif && filter.ClaimNotifStart.Add(24*time.Hour*time.Duration(max)).Unix() >= filter.ClaimNotifEnd.Unix()
-
-	if !validRequest && !validIssued && !validArrival && !validRefund && !validExpire && !validClaim && !validClaimNotif {
-		return fmt.Errorf("date filter is mandatory")
-	}
-
-	if !onRangeArrival && !onRangeIssued && !onRangeRefund && !onRangeRequest && !onRangeExpire && !onRangeClaim &&
-		!onRangeClaimNotif && max > 0 {
-		return fmt.Errorf("max range is %d days", max)
-	}
-
-	return nil
-}
-
-func (b *BaseRepository) PreparexContext(ctx context.Context, query string) (oracle.MasterStatement, error) {
+func (b *BaseRepository) PreparexContext(ctx context.Context, query string) (stmt oracle.MasterStatement, err error) {
+	operation := GetLastFuncCallerName()
 	txConn := ctx.Value(constants.CONTEXT_TRANSACTION)
 	if txConn == nil {
-		return b.MasterDB.PreparexContext(ctx, query)
+		err = observe(ctx, operation, database.TARGET_MASTER, func(ctx context.Context) (err error) {
+			stmt, err = b.MasterDB.PreparexContext(ctx, query)
+			return err
+		})
+		return stmt, err
 	}
-	return txConn.(*sqlx.Tx).PreparexContext(ctx, query)
+	err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) (err error) {
+		stmt, err = txConn.(*sqlx.Tx).PreparexContext(ctx, query)
+		return err
+	})
+	return stmt, err
 }
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// BindStyle is how Binds writes the placeholders of a statement
+type BindStyle int
+
+const (
+	// PositionalBinds writes :1, :2, ... bound in the order of the args
+	PositionalBinds BindStyle = iota
+	// NamedBinds writes :<prefix>1, :<prefix>2, ... bound by name with sql.Named args,
+	// so a PL/SQL block can reference the same bind more than once
+	NamedBinds
+)
+
+// DEFAULT_BIND_PREFIX names the binds of NewNamedBinds when no prefix is given
+const DEFAULT_BIND_PREFIX = "b"
+
+// Binds numbers the bind variables of one statement sequentially in the order they are written
+// and collects the matching args. Every generator of a statement writes through the same Binds,
+// so its clauses (SET, joins, WHERE, pagination, RETURNING) never reuse or skip a number.
+type Binds struct {
+	style  BindStyle
+	prefix string
+	args   []interface{}
+}
+
+// NewBinds returns positional binds :1, :2, ...
+func NewBinds() *Binds {
+	return &Binds{style: PositionalBinds}
+}
+
+// NewNamedBinds returns named binds :<prefix>1, :<prefix>2, ...
+func NewNamedBinds(prefix string) *Binds {
+	if prefix == "" {
+		prefix = DEFAULT_BIND_PREFIX
+	}
+	return &Binds{style: NamedBinds, prefix: prefix}
+}
+
+// Bind adds value to the args and returns its placeholder
+func (b *Binds) Bind(value interface{}) string {
+	n := len(b.args) + 1
+	if b.style == NamedBinds {
+		name := fmt.Sprintf("%s%d", b.prefix, n)
+		b.args = append(b.args, sql.Named(name, value))
+		return ":" + name
+	}
+	b.args = append(b.args, value)
+	return fmt.Sprintf(":%d", n)
+}
+
+// Args returns the values bound so far, in placeholder order
+func (b *Binds) Args() []interface{} {
+	return b.args
+}
+
+// Len returns how many values are bound
+func (b *Binds) Len() int {
+	return len(b.args)
+}
@@ -0,0 +1,192 @@
+package service_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files of testdata/binds")
+
+// assertGolden compares the statement and its args with testdata/binds/<name>.golden
+func assertGolden(t *testing.T, name, query string, args []interface{}) {
+	t.Helper()
+	var s strings.Builder
+	s.WriteString(query)
+	s.WriteString("\n")
+	for i, arg := range args {
+		s.WriteString(fmt.Sprintf("%d: %#v\n", i+1, arg))
+	}
+
+	path := filepath.Join("testdata", "binds", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(s.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run go test -update to create it", err)
+	}
+	assert.Equal(t, string(golden), s.String())
+}
+
+// assertSequentialBinds checks positional binds are :1..:N in order of appearance, one per arg
+func assertSequentialBinds(t *testing.T, query string, args []interface{}) {
+	t.Helper()
+	binds := regexp.MustCompile(`:(\d+)\b`).FindAllStringSubmatch(query, -1)
+	assert.Len(t, binds, len(args))
+	for i, bind := range binds {
+		assert.Equal(t, fmt.Sprint(i+1), bind[1])
+	}
+}
+
+var bindsDate = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func combinedSelect() service.SqlParameter {
+	where := service.Or(
+		service.MakeCondition("JSON_VALUE(m.INFO, '$.age')", constants.GREATER_THAN_EQUAL, 30),
+		service.Not(service.And(
+			service.MakeCondition("m.NAME", constants.IN_LIKE_STRING, "ann,bob"),
+			service.MakeCondition("m.ID", constants.NOT_IN, []int64{4, 5}),
+		)),
+	)
+	return service.SqlParameter{
+		TableName: "MEMBER m",
+		Columns:   []string{"m.ID", "m.NAME", "o.CODE"},
+		Joins: []service.JoinClause{{
+			JoinType:   "LEFT",
+			Table:      "MEMBER_ORDER",
+			Alias:      "o",
+			On:         "o.MEMBER_ID = m.ID",
+			Conditions: []service.FilterParam{service.MakeFilterParam("o.STATUS", constants.IN, []string{"NEW", "PAID"})},
+		}},
+		Params: []service.FilterParam{
+			service.MakeFilterParam("m.NAME,m.INFO", constants.MULTIPLE_LIKE, "%x%"),
+			service.MakeFilterParam("m.CREATED_DATE", constants.GREATER_THAN_EQUAL, bindsDate),
+			{Field: "m.POLICY", Operand: constants.CONTAINS, Value: "PII", Path: "$.dataCategories"},
+		},
+		Where:   &where,
+		OrderBy: []string{"m.ID asc"},
+		Limit:   10,
+		Offset:  20,
+	}
+}
+
+// softDeleteRepo scopes the reads of MEMBER by IS_DELETED
+func softDeleteRepo() *service.BaseRepository {
+	repo := service.BaseRepository{}.WithSoftDelete("MEMBER")
+	return &repo
+}
+
+func TestBinds_GoldenSelect(t *testing.T) {
+	repo := softDeleteRepo()
+
+	query, args := repo.GenerateQuerySelectWithParams("", combinedSelect())
+
+	assertSequentialBinds(t, query, args)
+	assertGolden(t, "select_combined", query, args)
+}
+
+func TestBinds_GoldenSelectKeyset(t *testing.T) {
+	repo := softDeleteRepo()
+	param := combinedSelect()
+	param.Keyset = &service.Keyset{
+		OrderKey:   "name",
+		SortColumn: "m.NAME",
+		IDColumn:   "m.ID",
+		After:      &service.Cursor{OrderKey: "name", SortValue: "Bob", ID: 9},
+	}
+
+	query, args := repo.GenerateQuerySelectWithParams("", param)
+
+	assertSequentialBinds(t, query, args)
+	assertGolden(t, "select_keyset", query, args)
+}
+
+func TestBinds_GoldenSelectNamed(t *testing.T) {
+	repo := softDeleteRepo()
+	b := service.NewNamedBinds("p")
+
+	query := repo.GenerateQuerySelectWithBinds(b, "", combinedSelect())
+
+	assertGolden(t, "select_named", query, b.Args())
+}
+
+func TestBinds_GoldenUpdate(t *testing.T) {
+	repo := &service.BaseRepository{}
+	where := service.Or(
+		service.MakeCondition("STATUS", constants.IS_NULL, nil),
+		service.MakeCondition("STATUS", constants.IN, []string{"NEW", "PAID"}),
+	)
+
+	query, args := repo.GenerateQueryUpdate(service.SqlParameter{
+		TableName: "PRODUCT",
+		Values: []service.Value{
+			{Field: "CODE", Value: "A1"},
+			{Field: "UPDATED_DATE", Value: bindsDate},
+		},
+		Params:  []service.FilterParam{service.MakeFilterParam("ID", constants.EQUAL, int64(7))},
+		Where:   &where,
+		Version: 3,
+	})
+
+	assertSequentialBinds(t, query, args)
+	assertGolden(t, "update_versioned", query, args)
+}
+
+func TestBinds_GoldenInsert(t *testing.T) {
+	repo := &service.BaseRepository{}
+
+	query, args := repo.GenerateQueryInsert(service.SqlParameter{
+		TableName: "PRODUCT",
+		Values: []service.Value{
+			{Field: "CODE", Value: "A1"},
+			{Field: "PRICE", Value: int64(10)},
+			{Field: "CREATED_DATE", Value: bindsDate},
+		},
+	})
+
+	assertSequentialBinds(t, query, args)
+	assertGolden(t, "insert", query, args)
+}
+
+func TestBinds_GoldenUpsert(t *testing.T) {
+	repo := &service.BaseRepository{}
+
+	query, args, err := repo.GenerateQueryUpsert(service.SqlParameter{
+		TableName: "PRODUCT",
+		Values: []service.Value{
+			{Field: "CODE", Value: "A1"},
+			{Field: "PRICE", Value: int64(10)},
+			{Field: "VERSION", Value: int64(1)},
+		},
+	}, nil, []string{"CODE"})
+
+	assert.NoError(t, err)
+	assertGolden(t, "upsert", query, args)
+}
+
+func TestBinds_PositionalAndNamed(t *testing.T) {
+	b := service.NewBinds()
+	assert.Equal(t, ":1", b.Bind("a"))
+	assert.Equal(t, ":2", b.Bind(2))
+	assert.Equal(t, []interface{}{"a", 2}, b.Args())
+
+	named := service.NewNamedBinds("")
+	assert.Equal(t, ":b1", named.Bind("a"))
+	assert.Equal(t, 1, named.Len())
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/godror/godror"
+)
+
+const DEFAULT_BATCH_SIZE = 500
+
+// BulkOption configures BulkInsert and BulkUpdate
+type BulkOption func(*bulkOptions)
+
+type bulkOptions struct {
+	batchSize       int
+	continueOnError bool
+}
+
+// WithBatchSize sets how many rows are bound into a single statement execution
+func WithBatchSize(size int) BulkOption {
+	return func(o *bulkOptions) {
+		if size > 0 {
+			o.batchSize = size
+		}
+	}
+}
+
+// ContinueOnError keeps the rows that succeeded in a failing batch (godror PartialBatch,
+// like FORALL SAVE EXCEPTIONS) and carries on with the next batches.
+// By default the first failing batch stops the operation.
+func ContinueOnError() BulkOption {
+	return func(o *bulkOptions) {
+		o.continueOnError = true
+	}
+}
+
+// BatchResult is the outcome of one array-bound statement execution
+type BatchResult struct {
+	// Offset is the index of the first row of the batch in the input
+	Offset       int   `json:"offset"`
+	Size         int   `json:"size"`
+	RowsAffected int64 `json:"rowsAffected"`
+	Err          error `json:"-"`
+}
+
+// RowError is the failure of a single input row
+type RowError struct {
+	Row int
+	Err error
+}
+
+// BulkResult reports per batch row counts and the rows which failed
+type BulkResult struct {
+	Batches    []BatchResult
+	FailedRows []RowError
+}
+
+// RowsAffected sums the affected rows of all batches
+func (b BulkResult) RowsAffected() int64 {
+	var total int64
+	for _, batch := range b.Batches {
+		total += batch.RowsAffected
+	}
+	return total
+}
+
+// BulkError is returned when at least one row failed. Rows are indexes into the input slice.
+type BulkError struct {
+	FailedRows []RowError
+}
+
+func (e *BulkError) Error() string {
+	rows := make([]string, 0, len(e.FailedRows))
+	for _, failed := range e.FailedRows {
+		rows = append(rows, fmt.Sprintf("row %d: %v", failed.Row, failed.Err))
+	}
+	return fmt.Sprintf("bulk operation failed on %d row(s): %s", len(e.FailedRows), strings.Join(rows, "; "))
+}
+
+func (e *BulkError) Unwrap() []error {
+	errs := make([]error, len(e.FailedRows))
+	for i, failed := range e.FailedRows {
+		errs[i] = failed.Err
+	}
+	return errs
+}
+
+// rowOffsetError is implemented by godror.OraErr, Offset is the failing row of an array DML
+type rowOffsetError interface {
+	error
+	Offset() int
+}
+
+// BulkInsert inserts rows with Oracle array binding, one round-trip per batch.
+// Every row must target the same table and fields in the same order.
+func (r *BaseRepository) BulkInsert(ctx context.Context, rows []SqlParameter, opts ...BulkOption) (BulkResult, error) {
+	return r.bulkExecute(ctx, rows, r.GenerateQueryInsert, opts...)
+}
+
+// BulkUpdate updates rows with Oracle array binding, one round-trip per batch.
+// Every row must generate the same statement, i.e. same table, values and filters, only bind values may differ.
+func (r *BaseRepository) BulkUpdate(ctx context.Context, rows []SqlParameter, opts ...BulkOption) (BulkResult, error) {
+	return r.bulkExecute(ctx, rows, r.GenerateQueryUpdate, opts...)
+}
+
+func (r *BaseRepository) bulkExecute(ctx context.Context, rows []SqlParameter, generate func(SqlParameter) (string, []interface{}), opts ...BulkOption) (result BulkResult, err error) {
+	options := bulkOptions{batchSize: DEFAULT_BATCH_SIZE}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	query, _ := generate(rows[0])
+	rowArgs := make([][]interface{}, len(rows))
+	for i := range rows {
+		if err := r.Validate(rows[i]); err != nil {
+			return result, fmt.Errorf("bulk row %d: %w", i, err)
+		}
+		rowQuery, args := generate(rows[i])
+		if rowQuery != query {
+			return result, fmt.Errorf("bulk row %d generates a different statement: %s", i, rowQuery)
+		}
+		rowArgs[i] = args
+	}
+
+	for offset := 0; offset < len(rows); offset += options.batchSize {
+		end := min(offset+options.batchSize, len(rows))
+		batch := BatchResult{Offset: offset, Size: end - offset}
+
+		args, err := arrayBinds(rowArgs[offset:end])
+		if err != nil {
+			return result, err
+		}
+		if options.continueOnError {
+			args = append(args, godror.PartialBatch())
+		}
+
+		batch.RowsAffected, batch.Err = r.WriteOrUpdateOperation(ctx, query, nil, args...)
+		if batch.Err != nil {
+			failed := batchFailedRows(batch, batch.Err)
+			batch.RowsAffected = int64(batch.Size - len(failed))
+			if !options.continueOnError {
+				batch.RowsAffected = 0
+			}
+			result.FailedRows = append(result.FailedRows, failed...)
+			slog.WarnContext(ctx, fmt.Sprintf("bulk batch at offset %d failed: %v", offset, batch.Err))
+		}
+		result.Batches = append(result.Batches, batch)
+
+		if batch.Err != nil && !options.continueOnError {
+			break
+		}
+	}
+
+	if len(result.FailedRows) > 0 {
+		return result, &BulkError{FailedRows: result.FailedRows}
+	}
+	return result, nil
+}
+
+// batchFailedRows maps a batch error to input rows. When the failing row is unknown the whole batch is reported.
+func batchFailedRows(batch BatchResult, err error) []RowError {
+	var batchErrs *godror.BatchErrors
+	if errors.As(err, &batchErrs) {
+		failed := make([]RowError, 0, len(batchErrs.Errs))
+		for _, oe := range batchErrs.Errs {
+			failed = append(failed, RowError{Row: batch.Offset + oe.Offset(), Err: oe})
+		}
+		return failed
+	}
+
+	var rowErr rowOffsetError
+	if errors.As(err, &rowErr) {
+		return []RowError{{Row: batch.Offset + rowErr.Offset(), Err: err}}
+	}
+
+	failed := make([]RowError, batch.Size)
+	for i := range failed {
+		failed[i] = RowError{Row: batch.Offset + i, Err: err}
+	}
+	return failed
+}
+
+// arrayBinds transposes per row bind values into one typed slice per placeholder, as godror expects for array DML.
+// nil values are bound as the zero value of the column type, use sql.Null* types for nullable columns.
+func arrayBinds(rowArgs [][]interface{}) ([]interface{}, error) {
+	if len(rowArgs) == 0 {
+		return nil, nil
+	}
+	columns := len(rowArgs[0])
+	binds := make([]interface{}, columns)
+	for col := 0; col < columns; col++ {
+		var elemType reflect.Type
+		for _, args := range rowArgs {
+			if args[col] != nil {
+				elemType = reflect.TypeOf(args[col])
+				break
+			}
+		}
+		if elemType == nil {
+			elemType = reflect.TypeFor[string]()
+		}
+
+		slice := reflect.MakeSlice(reflect.SliceOf(elemType), len(rowArgs), len(rowArgs))
+		for i, args := range rowArgs {
+			if args[col] == nil {
+				continue
+			}
+			v := reflect.ValueOf(args[col])
+			if v.Type() != elemType {
+				return nil, fmt.Errorf("bulk bind %d: row %d has type %s, expected %s", col+1, i, v.Type(), elemType)
+			}
+			slice.Index(i).Set(v)
+		}
+		binds[col] = slice.Interface()
+	}
+	return binds, nil
+}
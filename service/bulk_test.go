@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+type rowErr struct {
+	offset int
+}
+
+func (e rowErr) Error() string {
+	return fmt.Sprintf("ORA-00001: unique constraint violated at %d", e.offset)
+}
+func (e rowErr) Offset() int { return e.offset }
+
+func bulkRows(n int) []service.SqlParameter {
+	rows := make([]service.SqlParameter, n)
+	for i := range rows {
+		rows[i] = service.SqlParameter{
+			TableName: "PRODUCT",
+			Values: []service.Value{
+				{Field: "CODE", Value: fmt.Sprintf("C%d", i)},
+				{Field: "PRICE", Value: int64(i)},
+			},
+		}
+	}
+	return rows
+}
+
+func TestBulkInsert_Batches(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := &service.BaseRepository{MasterDB: mockMaster}
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"INSERT INTO PRODUCT (CODE,PRICE) VALUES (:1,:2)",
+		[]interface{}{[]string{"C0", "C1"}, []int64{0, 1}},
+	).Return(mockResult{rowsAffected: 2}, nil).Once()
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"INSERT INTO PRODUCT (CODE,PRICE) VALUES (:1,:2)",
+		[]interface{}{[]string{"C2"}, []int64{2}},
+	).Return(mockResult{rowsAffected: 1}, nil).Once()
+
+	result, err := repo.BulkInsert(context.Background(), bulkRows(3), service.WithBatchSize(2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.RowsAffected())
+	assert.Equal(t, []service.BatchResult{
+		{Offset: 0, Size: 2, RowsAffected: 2},
+		{Offset: 2, Size: 1, RowsAffected: 1},
+	}, result.Batches)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestBulkInsert_StopsOnFailedBatch(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := &service.BaseRepository{MasterDB: mockMaster}
+	execErr := rowErr{offset: 1}
+
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, nil).Once()
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, execErr).Once()
+
+	result, err := repo.BulkInsert(context.Background(), bulkRows(6), service.WithBatchSize(2))
+
+	var bulkErr *service.BulkError
+	assert.ErrorAs(t, err, &bulkErr)
+	assert.ErrorIs(t, err, execErr)
+	assert.Equal(t, []service.RowError{{Row: 3, Err: execErr}}, bulkErr.FailedRows)
+	assert.Len(t, result.Batches, 2)
+	mockMaster.AssertNumberOfCalls(t, "ExecContext", 2)
+}
+
+func TestBulkUpdate_ContinueOnError(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := &service.BaseRepository{MasterDB: mockMaster}
+	execErr := errors.New("ORA-03113: end-of-file on communication channel")
+
+	rows := bulkRows(3)
+	for i := range rows {
+		rows[i].Params = []service.FilterParam{service.MakeFilterParam("ID", "=", int64(i+10))}
+	}
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"UPDATE PRODUCT SET CODE=:1,PRICE=:2 WHERE ID = :3",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 4 && assert.ObjectsAreEqual([]int64{10, 11}, args[2])
+		}),
+	).Return(mockResult{}, execErr).Once()
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"UPDATE PRODUCT SET CODE=:1,PRICE=:2 WHERE ID = :3",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 4 && assert.ObjectsAreEqual([]int64{12}, args[2])
+		}),
+	).Return(mockResult{rowsAffected: 1}, nil).Once()
+
+	result, err := repo.BulkUpdate(context.Background(), rows, service.WithBatchSize(2), service.ContinueOnError())
+
+	var bulkErr *service.BulkError
+	assert.ErrorAs(t, err, &bulkErr)
+	assert.Equal(t, []service.RowError{{Row: 0, Err: execErr}, {Row: 1, Err: execErr}}, bulkErr.FailedRows)
+	assert.Equal(t, int64(1), result.RowsAffected())
+	mockMaster.AssertExpectations(t)
+}
+
+func TestBulkInsert_InvalidRows(t *testing.T) {
+	repo := &service.BaseRepository{MasterDB: new(MockMasterDB)}
+
+	rows := bulkRows(2)
+	rows[1].Values = rows[1].Values[:1]
+	_, err := repo.BulkInsert(context.Background(), rows)
+	assert.ErrorContains(t, err, "bulk row 1 generates a different statement")
+
+	rows = bulkRows(2)
+	rows[1].Values[1].Value = "10"
+	_, err = repo.BulkInsert(context.Background(), rows)
+	assert.ErrorContains(t, err, "bulk bind 2: row 1 has type string, expected int64")
+}
+
+func TestRepository_BulkCreate(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"INSERT INTO PRODUCT (CODE,PRICE,CREATED_DATE,UPDATED_DATE,IS_DELETED,VERSION) VALUES (:1,:2,:3,:4,:5,:6)",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 6 && assert.ObjectsAreEqual([]string{"A1", "A2"}, args[0])
+		}),
+	).Return(mockResult{rowsAffected: 2}, nil)
+
+	result, err := repo.BulkCreate(context.Background(), []product{{Code: "A1"}, {Code: "A2"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.RowsAffected())
+	mockMaster.AssertExpectations(t)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+// ConditionLogic combines the children of a Condition
+type ConditionLogic string
+
+const (
+	LOGIC_AND ConditionLogic = "AND"
+	LOGIC_OR  ConditionLogic = "OR"
+	LOGIC_NOT ConditionLogic = "NOT"
+)
+
+// Condition is a node of a WHERE clause tree: a single filter when Filter is set,
+// otherwise its Conditions combined with Logic. NOT negates the AND of its children.
+type Condition struct {
+	Logic      ConditionLogic `json:"logic,omitempty"`
+	Filter     *FilterParam   `json:"filter,omitempty"`
+	Conditions []Condition    `json:"conditions,omitempty"`
+}
+
+// MakeCondition returns the leaf condition field operand val
+func MakeCondition(field, operand string, val interface{}) Condition {
+	filter := MakeFilterParam(field, operand, val)
+	return Condition{Filter: &filter}
+}
+
+// And is true when all conditions are
+func And(conditions ...Condition) Condition {
+	return Condition{Logic: LOGIC_AND, Conditions: conditions}
+}
+
+// Or is true when any of conditions is
+func Or(conditions ...Condition) Condition {
+	return Condition{Logic: LOGIC_OR, Conditions: conditions}
+}
+
+// Not negates condition
+func Not(condition Condition) Condition {
+	return Condition{Logic: LOGIC_NOT, Conditions: []Condition{condition}}
+}
+
+// and returns the filters joined with AND, without parentheses as flat Params always were
+func (b *Binds) and(filters []FilterParam) string {
+	sql := make([]string, len(filters))
+	for i, filter := range filters {
+		sql[i] = b.filter(filter)
+	}
+	return strings.Join(sql, constants.AND)
+}
+
+// condition returns the SQL of c, empty when c has no filter at all.
+// Groups of more than one condition are parenthesised so they can be nested safely.
+func (b *Binds) condition(c Condition) string {
+	sql, _ := b.group(c)
+	return sql
+}
+
+// group returns the SQL of c and whether it is already enclosed in parentheses
+func (b *Binds) group(c Condition) (string, bool) {
+	if c.Filter != nil {
+		return b.filter(*c.Filter), false
+	}
+
+	var (
+		children []string
+		grouped  bool
+	)
+	for _, child := range c.Conditions {
+		if sql, childGrouped := b.group(child); sql != "" {
+			children = append(children, sql)
+			grouped = childGrouped
+		}
+	}
+	if len(children) == 0 {
+		return "", false
+	}
+
+	separator := constants.AND
+	if c.Logic == LOGIC_OR {
+		separator = constants.OR
+	}
+	sql := children[0]
+	if len(children) > 1 {
+		sql, grouped = "("+strings.Join(children, separator)+")", true
+	}
+	if c.Logic == LOGIC_NOT {
+		if !grouped {
+			sql = "(" + sql + ")"
+		}
+		return "NOT " + sql, false
+	}
+	return sql, grouped
+}
+
+func (b *Binds) filter(param FilterParam) string {
+	if param.Operand == "" {
+		param.Operand = constants.EQUAL
+	}
+	switch param.Operand {
+	case constants.IN, constants.NOT_IN:
+		values, ok := sliceValues(param.Value)
+		if !ok {
+			return fmt.Sprintf("%s %s (%s)", param.Field, param.Operand, b.Bind(param.Value))
+		}
+		if len(values) == 0 {
+			// nothing is IN an empty list, everything is NOT IN it
+			if param.Operand == constants.IN {
+				return "1 = 0"
+			}
+			return "1 = 1"
+		}
+		binds := make([]string, len(values))
+		for i, value := range values {
+			binds[i] = b.Bind(value)
+		}
+		return fmt.Sprintf("%s %s (%s)", param.Field, param.Operand, strings.Join(binds, constants.COMMA))
+	case constants.REVERSE_IN:
+		return fmt.Sprintf("%s %s (%s)", b.Bind(param.Value), constants.IN, param.Field)
+	case constants.MULTIPLE_LIKE:
+		return b.anyColumn(strings.Split(param.Field, constants.COMMA), constants.LIKE, param.Value)
+	case constants.MULTIPLE_EQUAL:
+		return b.anyColumn(strings.Split(param.Field, constants.COMMA), constants.EQUAL, param.Value)
+	case constants.IN_LIKE_STRING:
+		str, _ := param.Value.(string)
+		var or []string
+		for _, value := range strings.Split(str, constants.COMMA) {
+			or = append(or, fmt.Sprintf("%s %s %s", param.Field, constants.LIKE, b.Bind("%"+value+"%")))
+		}
+		return "(" + strings.Join(or, constants.OR) + ")"
+	case constants.CONTAINS, constants.NOT_CONTAINS:
+		return b.contains(param)
+	case constants.IS_NULL:
+		return fmt.Sprintf("%s %s", param.Field, param.Operand)
+	default:
+		return fmt.Sprintf("%s %s %s", param.Field, param.Operand, b.Bind(param.Value))
+	}
+}
+
+// contains returns whether the JSON array at param.Path holds param.Value with JSON_EXISTS, the value bound
+// through PASSING; without a path, whether the CONTEXT-indexed column matches it with Oracle Text.
+func (b *Binds) contains(param FilterParam) string {
+	negate := param.Operand == constants.NOT_CONTAINS
+	if param.Path != "" {
+		sql := fmt.Sprintf(`JSON_EXISTS(%s, '%s[*]?(@ == $v)' PASSING %s AS "v")`,
+			param.Field, strings.ReplaceAll(param.Path, "'", "''"), b.Bind(param.Value))
+		if negate {
+			return "NOT " + sql
+		}
+		return sql
+	}
+
+	match := "> 0"
+	if negate {
+		match = "= 0"
+	}
+	return fmt.Sprintf("CONTAINS(%s, %s) %s", param.Field, b.Bind(textQuery(param.Value)), match)
+}
+
+// textQuery escapes value with braces so Oracle Text searches it as is,
+// operators and special characters included. A closing brace is escaped by doubling it.
+func textQuery(value interface{}) string {
+	return "{" + strings.ReplaceAll(fmt.Sprint(value), "}", "}}") + "}"
+}
+
+// anyColumn returns (col1 operator :n OR col2 operator :n+1 ...) binding value once per column
+func (b *Binds) anyColumn(columns []string, operator string, value interface{}) string {
+	or := make([]string, len(columns))
+	for i, column := range columns {
+		or[i] = fmt.Sprintf("%s %s %s", strings.TrimSpace(column), operator, b.Bind(value))
+	}
+	return "(" + strings.Join(or, constants.OR) + ")"
+}
+
+// sliceValues returns the elements of value when it is a slice or an array, []byte excepted
+func sliceValues(value interface{}) ([]interface{}, bool) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	values := make([]interface{}, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values, true
+}
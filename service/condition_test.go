@@ -0,0 +1,106 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+func TestGenerateConditional_Tree(t *testing.T) {
+	repo := &service.BaseRepository{}
+
+	param := service.SqlParameter{
+		Params: []service.FilterParam{service.MakeFilterParam("M.IS_DELETED", constants.EQUAL, "0")},
+		Where: &service.Condition{
+			Logic: service.LOGIC_AND,
+			Conditions: []service.Condition{
+				service.Or(
+					service.MakeCondition("M.NAME", constants.LIKE, "%john%"),
+					service.MakeCondition("M.ID", constants.IN, []int64{1, 2}),
+				),
+				service.Not(service.MakeCondition("M.STATUS", constants.EQUAL, "INACTIVE")),
+			},
+		},
+	}
+
+	query, args := repo.GenerateConditional(param)
+
+	assert.Equal(t, " WHERE M.IS_DELETED = :1 AND ((M.NAME LIKE :2 OR M.ID IN (:3,:4)) AND NOT (M.STATUS = :5))", query)
+	assert.Equal(t, []interface{}{"0", "%john%", int64(1), int64(2), "INACTIVE"}, args)
+}
+
+func TestGenerateConditional_TreeOnly(t *testing.T) {
+	repo := &service.BaseRepository{}
+	where := service.Not(service.Or(
+		service.MakeCondition("A", constants.IS_NULL, nil),
+		service.MakeCondition("B", constants.MULTIPLE_EQUAL, "x"),
+	))
+
+	query, args := repo.GenerateConditional(service.SqlParameter{Where: &where})
+
+	assert.Equal(t, " WHERE NOT (A IS NULL OR (B = :1))", query)
+	assert.Equal(t, []interface{}{"x"}, args)
+}
+
+func TestGenerateConditional_EmptyTree(t *testing.T) {
+	repo := &service.BaseRepository{}
+	where := service.And(service.Or(), service.Not(service.And()))
+
+	query, args := repo.GenerateConditional(service.SqlParameter{Where: &where})
+
+	assert.Empty(t, query)
+	assert.Empty(t, args)
+}
+
+func TestGenerateConditional_FlatOperands(t *testing.T) {
+	repo := &service.BaseRepository{}
+
+	query, args := repo.GenerateConditional(service.SqlParameter{Params: []service.FilterParam{
+		service.MakeFilterParam("ID", constants.IN, []string{"a", "b"}),
+		service.MakeFilterParam("NAME,CODE", constants.MULTIPLE_LIKE, "%x%"),
+		service.MakeFilterParam("TAG", constants.IN_LIKE_STRING, "p,q"),
+		service.MakeFilterParam("ID", constants.NOT_IN, []int64{}),
+		service.MakeFilterParam("PRICE", "", 10),
+	}})
+
+	assert.Equal(t, " WHERE ID IN (:1,:2) AND (NAME LIKE :3 OR CODE LIKE :4) AND (TAG LIKE :5 OR TAG LIKE :6) AND 1 = 1 AND PRICE = :7", query)
+	assert.Equal(t, []interface{}{"a", "b", "%x%", "%x%", "%p%", "%q%", 10}, args)
+}
+
+func TestGenerateQuerySelectWithParams_TreeBinds(t *testing.T) {
+	repo := &service.BaseRepository{}
+	where := service.Or(
+		service.MakeCondition("CODE", constants.EQUAL, "A"),
+		service.MakeCondition("CODE", constants.EQUAL, "B"),
+	)
+
+	query, args := repo.GenerateQuerySelectWithParams("", service.SqlParameter{
+		TableName: "PRODUCT",
+		Columns:   []string{"ID"},
+		Where:     &where,
+		Deleted:   service.IncludeDeleted,
+		Limit:     10,
+	})
+
+	assert.Equal(t, "SELECT ID FROM PRODUCT WHERE (CODE = :1 OR CODE = :2) OFFSET :3 ROWS FETCH NEXT :4 ROWS ONLY", query)
+	assert.Equal(t, []interface{}{"A", "B", 0, 10}, args)
+}
+
+func TestGenerateConditional_Contains(t *testing.T) {
+	repo := &service.BaseRepository{}
+
+	query, args := repo.GenerateConditional(service.SqlParameter{Params: []service.FilterParam{
+		service.MakeFilterParam("INFO", constants.CONTAINS, "tree house"),
+		service.MakeFilterParam("INFO", constants.NOT_CONTAINS, "a}b"),
+		{Field: "POLICY", Operand: constants.CONTAINS, Value: "PII", Path: "$.dataCategories"},
+		{Field: "POLICY", Operand: constants.NOT_CONTAINS, Value: "PHI", Path: "$.dataCategories"},
+	}})
+
+	assert.Equal(t, " WHERE CONTAINS(INFO, :1) > 0 AND CONTAINS(INFO, :2) = 0"+
+		` AND JSON_EXISTS(POLICY, '$.dataCategories[*]?(@ == $v)' PASSING :3 AS "v")`+
+		` AND NOT JSON_EXISTS(POLICY, '$.dataCategories[*]?(@ == $v)' PASSING :4 AS "v")`, query)
+	assert.Equal(t, []interface{}{"{tree house}", "{a}}b}", "PII", "PHI"}, args)
+}
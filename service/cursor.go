@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+const cursorSignatureSeparator = "."
+
+// ErrInvalidCursor is returned when a cursor is malformed, tampered with or signed with another secret
+var ErrInvalidCursor = errors.New("INVALID_CURSOR")
+
+var (
+	cursorSecretMu sync.RWMutex
+	cursorSecret   = randomCursorSecret()
+)
+
+// SetCursorSecret sets the key cursors are signed with. Without it a random key is used,
+// so cursors do not survive a restart and are not shared between instances.
+func SetCursorSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	cursorSecretMu.Lock()
+	defer cursorSecretMu.Unlock()
+	cursorSecret = []byte(secret)
+}
+
+func randomCursorSecret() []byte {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return secret
+}
+
+// Cursor is the position of the last row of a keyset page: its sort key and id.
+// OrderKey and Desc bind the cursor to the ordering it was produced for.
+type Cursor struct {
+	OrderKey  string      `json:"o,omitempty"`
+	Desc      bool        `json:"d,omitempty"`
+	SortValue interface{} `json:"s,omitempty"`
+	IsTime    bool        `json:"t,omitempty"`
+	ID        int64       `json:"id"`
+}
+
+// Keyset switches a select to keyset pagination: rows are ordered by SortColumn then IDColumn
+// and, when After is set, only rows after that position are returned. Offset is ignored.
+//
+// SortColumn is empty when ordering by the id only. Rows with a NULL sort value come last
+// whatever the direction, after the rows with a value.
+type Keyset struct {
+	OrderKey   string
+	SortColumn string
+	IDColumn   string
+	Desc       bool
+	After      *Cursor
+}
+
+// EncodeCursor returns the opaque, signed representation of c
+func EncodeCursor(c Cursor) (string, error) {
+	if t, ok := c.SortValue.(time.Time); ok {
+		c.SortValue = t.Format(time.RFC3339Nano)
+		c.IsTime = true
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + cursorSignatureSeparator + base64.RawURLEncoding.EncodeToString(signCursor(payload)), nil
+}
+
+// DecodeCursor verifies and decodes a cursor produced by EncodeCursor
+func DecodeCursor(cursor string) (c Cursor, err error) {
+	encoded, signature, ok := strings.Cut(cursor, cursorSignatureSeparator)
+	if !ok {
+		return c, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, signCursor(payload)) {
+		return c, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err = decoder.Decode(&c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	switch v := c.SortValue.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			c.SortValue = i
+		} else if f, err := v.Float64(); err == nil {
+			c.SortValue = f
+		}
+	case string:
+		if c.IsTime {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return c, ErrInvalidCursor
+			}
+			c.SortValue = t
+		}
+	}
+	return c, nil
+}
+
+func signCursor(payload []byte) []byte {
+	cursorSecretMu.RLock()
+	defer cursorSecretMu.RUnlock()
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Next returns the cursor pointing after the row with the given sort value and id
+func (k *Keyset) Next(sortValue interface{}, id int64) (string, error) {
+	c := Cursor{OrderKey: k.OrderKey, Desc: k.Desc, ID: id}
+	if k.hasSortColumn() {
+		c.SortValue = sortValue
+	}
+	return EncodeCursor(c)
+}
+
+func (k *Keyset) hasSortColumn() bool {
+	return k.SortColumn != "" && k.SortColumn != k.IDColumn
+}
+
+func (k *Keyset) orderBy() []string {
+	direction := constants.ASC
+	if k.Desc {
+		direction = constants.DESC
+	}
+	if !k.hasSortColumn() {
+		return []string{fmt.Sprintf("%s %s", k.IDColumn, direction)}
+	}
+	return []string{
+		fmt.Sprintf("%s %s NULLS LAST", k.SortColumn, direction),
+		fmt.Sprintf("%s %s", k.IDColumn, direction),
+	}
+}
+
+// condition returns the predicate selecting rows after k.After. Oracle has no row value
+// comparison, so (sort, id) > (:s, :id) is expanded to sort > :s OR (sort = :s AND id > :id),
+// or the NULL sort values ordered last. After a NULL sort value only the NULL ones remain.
+func (k *Keyset) condition(b *Binds) string {
+	if k.After == nil {
+		return ""
+	}
+	operand := constants.GREATER_THAN
+	if k.Desc {
+		operand = constants.LESS_THAN
+	}
+	if !k.hasSortColumn() {
+		return fmt.Sprintf("%s %s %s", k.IDColumn, operand, b.Bind(k.After.ID))
+	}
+	if k.After.SortValue == nil {
+		return fmt.Sprintf("(%s IS NULL AND %s %s %s)", k.SortColumn, k.IDColumn, operand, b.Bind(k.After.ID))
+	}
+	sortAfter := b.Bind(k.After.SortValue)
+	sortEqual := b.Bind(k.After.SortValue)
+	return fmt.Sprintf("(%s %s %s OR (%s = %s AND %s %s %s) OR %s IS NULL)",
+		k.SortColumn, operand, sortAfter,
+		k.SortColumn, sortEqual, k.IDColumn, operand, b.Bind(k.After.ID),
+		k.SortColumn)
+}
+
+// MakeKeysetPagination builds the pagination of a keyset page. hasNext is known by fetching one row more than the limit.
+func MakeKeysetPagination(keyset *Keyset, hasNext bool, lastSortValue interface{}, lastID int64) (page Pagination, err error) {
+	page.NextPage = hasNext
+	if !hasNext {
+		return page, nil
+	}
+	page.LastID = lastID
+	page.NextCursor, err = keyset.Next(lastSortValue, lastID)
+	return page, err
+}
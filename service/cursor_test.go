@@ -0,0 +1,100 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	cases := []service.Cursor{
+		{OrderKey: "name", SortValue: "Alice", ID: 7},
+		{OrderKey: "age", Desc: true, SortValue: int64(30), ID: 8},
+		{OrderKey: "created", SortValue: created, ID: 9},
+		{ID: 10},
+	}
+
+	for _, c := range cases {
+		encoded, err := service.EncodeCursor(c)
+		assert.NoError(t, err)
+
+		decoded, err := service.DecodeCursor(encoded)
+		assert.NoError(t, err)
+		if _, ok := c.SortValue.(time.Time); ok {
+			c.IsTime = true
+		}
+		assert.Equal(t, c, decoded)
+	}
+}
+
+func TestCursor_Tampered(t *testing.T) {
+	encoded, err := service.EncodeCursor(service.Cursor{OrderKey: "name", SortValue: "Alice", ID: 7})
+	assert.NoError(t, err)
+
+	other, err := service.EncodeCursor(service.Cursor{OrderKey: "name", SortValue: "Alice", ID: 1})
+	assert.NoError(t, err)
+
+	for _, cursor := range []string{"", "abc", encoded[:len(encoded)-2], other[:len(other)/2] + encoded[len(encoded)/2:]} {
+		_, err := service.DecodeCursor(cursor)
+		assert.ErrorIs(t, err, service.ErrInvalidCursor, cursor)
+	}
+}
+
+func TestGenerateQuerySelectWithParams_Keyset(t *testing.T) {
+	repo := &service.BaseRepository{}
+	param := service.SqlParameter{
+		TableName: "MEMBER m",
+		Columns:   []string{"M.ID", "M.NAME"},
+		Params:    []service.FilterParam{service.MakeFilterParam("M.IS_DELETED", "=", "0")},
+		OrderBy:   []string{"M.NAME asc"},
+		Limit:     10,
+		Offset:    20,
+		Deleted:   service.IncludeDeleted,
+		Keyset: &service.Keyset{
+			OrderKey:   "name",
+			SortColumn: "M.NAME",
+			IDColumn:   "M.ID",
+			Desc:       true,
+			After:      &service.Cursor{OrderKey: "name", Desc: true, SortValue: "Bob", ID: 5},
+		},
+	}
+
+	query, args := repo.GenerateQuerySelectWithParams("", param)
+	assert.Equal(t, "SELECT M.ID,M.NAME FROM MEMBER m WHERE M.IS_DELETED = :1 AND (M.NAME < :2 OR (M.NAME = :3 AND M.ID < :4) OR M.NAME IS NULL)"+
+		" ORDER BY M.NAME desc NULLS LAST,M.ID desc OFFSET :5 ROWS FETCH NEXT :6 ROWS ONLY", query)
+	assert.Equal(t, []interface{}{"0", "Bob", "Bob", int64(5), 0, 10}, args)
+
+	param.Keyset.After = &service.Cursor{OrderKey: "name", Desc: true, ID: 5}
+	query, args = repo.GenerateQuerySelectWithParams("", param)
+	assert.Equal(t, "SELECT M.ID,M.NAME FROM MEMBER m WHERE M.IS_DELETED = :1 AND (M.NAME IS NULL AND M.ID < :2)"+
+		" ORDER BY M.NAME desc NULLS LAST,M.ID desc OFFSET :3 ROWS FETCH NEXT :4 ROWS ONLY", query,
+		"after a NULL name only the NULL names remain")
+	assert.Equal(t, []interface{}{"0", int64(5), 0, 10}, args)
+
+	param.Params = nil
+	param.Keyset = &service.Keyset{IDColumn: "M.ID", After: &service.Cursor{ID: 5}}
+	query, args = repo.GenerateQuerySelectWithParams("", param)
+	assert.Equal(t, "SELECT M.ID,M.NAME FROM MEMBER m WHERE M.ID > :1 ORDER BY M.ID asc OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY", query)
+	assert.Equal(t, []interface{}{int64(5), 0, 10}, args)
+}
+
+func TestMakeKeysetPagination(t *testing.T) {
+	keyset := &service.Keyset{OrderKey: "name", SortColumn: "M.NAME", IDColumn: "M.ID"}
+
+	page, err := service.MakeKeysetPagination(keyset, true, "Carol", 12)
+	assert.NoError(t, err)
+	assert.True(t, page.NextPage)
+	assert.Equal(t, int64(12), page.LastID)
+
+	next, err := service.DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, service.Cursor{OrderKey: "name", SortValue: "Carol", ID: 12}, next)
+
+	page, err = service.MakeKeysetPagination(keyset, false, "Carol", 12)
+	assert.NoError(t, err)
+	assert.Equal(t, service.Pagination{}, page)
+}
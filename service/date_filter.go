@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+// ErrInvalidDateFilter is returned when a request gets a date-range filter wrong, see DateFilterError
+var ErrInvalidDateFilter = errors.New("INVALID_DATE_FILTER")
+
+// DateFilterError names the date-range filter a request got wrong. It matches ErrInvalidDateFilter.
+type DateFilterError struct {
+	Filter string
+	Reason string
+}
+
+func (e *DateFilterError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", ErrInvalidDateFilter, e.Filter, e.Reason)
+}
+
+func (e *DateFilterError) Unwrap() error {
+	return ErrInvalidDateFilter
+}
+
+// DateFilter is a date range a resource can be filtered on, bounded by the StartKey and EndKey query params
+type DateFilter struct {
+	Name     string
+	Field    string
+	StartKey string
+	EndKey   string
+	// Location the bounds without an offset are read in, UTC when nil
+	Location *time.Location
+	// MaxSpan is the widest range allowed, both bounds are then required. Zero is unlimited.
+	MaxSpan time.Duration
+	// Mandatory requires both bounds
+	Mandatory bool
+}
+
+// dateFilterLayouts are the accepted bounds; a date alone covers its whole day
+var dateFilterLayouts = []string{constants.DATE_TIME_FORMAT, time.RFC3339, constants.DATE_FORMAT}
+
+// DateFilters is the registry of the date-range filters of a resource
+type DateFilters struct {
+	filters []DateFilter
+}
+
+// NewDateFilters returns the registry of filters. Like regexp.MustCompile it panics on a filter missing
+// its name, field or keys, and on a name or query key declared twice.
+func NewDateFilters(filters ...DateFilter) *DateFilters {
+	names, keys := map[string]bool{}, map[string]bool{}
+	for _, f := range filters {
+		if f.Name == "" || f.Field == "" || f.StartKey == "" || f.EndKey == "" || f.MaxSpan < 0 {
+			panic(fmt.Sprintf("date filter %q: name, field and keys are required", f.Name))
+		}
+		if names[f.Name] || keys[f.StartKey] || keys[f.EndKey] || f.StartKey == f.EndKey {
+			panic(fmt.Sprintf("date filter %q: name or key declared twice", f.Name))
+		}
+		names[f.Name], keys[f.StartKey], keys[f.EndKey] = true, true, true
+	}
+	return &DateFilters{filters: filters}
+}
+
+// Parse returns the filter params of the date ranges in query, bound as time.Time. A start is inclusive;
+// an end is inclusive too, a date alone covering its whole day. nil has no filter.
+func (d *DateFilters) Parse(query url.Values) ([]FilterParam, error) {
+	if d == nil {
+		return nil, nil
+	}
+
+	var params []FilterParam
+	for _, f := range d.filters {
+		filterParams, err := f.parse(query)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, filterParams...)
+	}
+	return params, nil
+}
+
+func (f DateFilter) parse(query url.Values) ([]FilterParam, error) {
+	start, _, err := f.bound(query, f.StartKey)
+	if err != nil {
+		return nil, err
+	}
+	end, dateOnly, err := f.bound(query, f.EndKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var params []FilterParam
+	if !start.IsZero() {
+		params = append(params, FilterParam{Field: f.Field, Operand: constants.GREATER_THAN_EQUAL, Value: start})
+	}
+	if dateOnly {
+		// up to the next midnight, whatever the precision of the column
+		end = end.AddDate(0, 0, 1)
+		params = append(params, FilterParam{Field: f.Field, Operand: constants.LESS_THAN, Value: end})
+	} else if !end.IsZero() {
+		params = append(params, FilterParam{Field: f.Field, Operand: constants.LESS_THAN_EQUAL, Value: end})
+	}
+
+	both := !start.IsZero() && !end.IsZero()
+	switch {
+	case !both && f.Mandatory:
+		return nil, f.error("%s and %s are mandatory", f.StartKey, f.EndKey)
+	case !both && f.MaxSpan > 0:
+		if start.IsZero() && end.IsZero() {
+			return nil, nil
+		}
+		return nil, f.error("%s and %s are both needed, the range is at most %s", f.StartKey, f.EndKey, formatSpan(f.MaxSpan))
+	case both && (end.Before(start) || dateOnly && end.Equal(start)):
+		return nil, f.error("%s is after %s", f.StartKey, f.EndKey)
+	case both && f.MaxSpan > 0 && end.Sub(start) > f.MaxSpan:
+		return nil, f.error("the range is at most %s", formatSpan(f.MaxSpan))
+	}
+	return params, nil
+}
+
+// bound reads the bound of key, zero when absent. dateOnly reports a bound given without its time.
+func (f DateFilter) bound(query url.Values, key string) (t time.Time, dateOnly bool, err error) {
+	value := strings.TrimSpace(query.Get(key))
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	location := f.Location
+	if location == nil {
+		location = time.UTC
+	}
+	for _, layout := range dateFilterLayouts {
+		if t, err = time.ParseInLocation(layout, value, location); err == nil {
+			return t, layout == constants.DATE_FORMAT, nil
+		}
+	}
+	return time.Time{}, false, f.error("%s %q is not a date, expected %s or %s", key, value, constants.DATE_FORMAT, constants.DATE_TIME_FORMAT)
+}
+
+func (f DateFilter) error(format string, args ...interface{}) error {
+	return &DateFilterError{Filter: f.Name, Reason: fmt.Sprintf(format, args...)}
+}
+
+func formatSpan(span time.Duration) string {
+	if day := 24 * time.Hour; span%day == 0 {
+		return fmt.Sprintf("%d days", span/day)
+	}
+	return span.String()
+}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+func TestDateFilters_Parse(t *testing.T) {
+	filters := service.NewDateFilters(
+		service.DateFilter{Name: "created", Field: "M.CREATED_DATE", StartKey: "createdStart", EndKey: "createdEnd", Location: jakarta},
+		service.DateFilter{Name: "updated", Field: "M.UPDATED_DATE", StartKey: "updatedStart", EndKey: "updatedEnd"},
+	)
+
+	testCases := []struct {
+		name  string
+		query string
+		want  []service.FilterParam
+	}{
+		{name: "none", query: "name=John"},
+		{name: "dates", query: "createdStart=2024-01-01&createdEnd=2024-01-31", want: []service.FilterParam{
+			{Field: "M.CREATED_DATE", Operand: constants.GREATER_THAN_EQUAL, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, jakarta)},
+			{Field: "M.CREATED_DATE", Operand: constants.LESS_THAN, Value: time.Date(2024, 2, 1, 0, 0, 0, 0, jakarta)},
+		}},
+		{name: "timestamps", query: "createdStart=2024-01-01+08:00:00&createdEnd=2024-01-01T17:00:00Z", want: []service.FilterParam{
+			{Field: "M.CREATED_DATE", Operand: constants.GREATER_THAN_EQUAL, Value: time.Date(2024, 1, 1, 8, 0, 0, 0, jakarta)},
+			{Field: "M.CREATED_DATE", Operand: constants.LESS_THAN_EQUAL, Value: time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC)},
+		}},
+		{name: "open ended in utc", query: "updatedStart=2024-03-01", want: []service.FilterParam{
+			{Field: "M.UPDATED_DATE", Operand: constants.GREATER_THAN_EQUAL, Value: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		}},
+		{name: "same day", query: "createdStart=2024-01-01+10:00:00&createdEnd=2024-01-01", want: []service.FilterParam{
+			{Field: "M.CREATED_DATE", Operand: constants.GREATER_THAN_EQUAL, Value: time.Date(2024, 1, 1, 10, 0, 0, 0, jakarta)},
+			{Field: "M.CREATED_DATE", Operand: constants.LESS_THAN, Value: time.Date(2024, 1, 2, 0, 0, 0, 0, jakarta)},
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			got, err := filters.Parse(query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDateFilters_ParseErrors(t *testing.T) {
+	filters := service.NewDateFilters(
+		service.DateFilter{Name: "created", Field: "CREATED_DATE", StartKey: "createdStart", EndKey: "createdEnd", MaxSpan: 31 * 24 * time.Hour},
+		service.DateFilter{Name: "claim", Field: "CLAIM_DATE", StartKey: "claimStart", EndKey: "claimEnd", Mandatory: true},
+	)
+
+	testCases := []struct {
+		name   string
+		query  string
+		errMsg string
+	}{
+		{name: "mandatory", query: "createdStart=2024-01-01&createdEnd=2024-01-02",
+			errMsg: "INVALID_DATE_FILTER: claim: claimStart and claimEnd are mandatory"},
+		{name: "mandatory bound", query: "claimStart=2024-01-01",
+			errMsg: "INVALID_DATE_FILTER: claim: claimStart and claimEnd are mandatory"},
+		{name: "not a date", query: "claimStart=01/01/2024&claimEnd=2024-01-02",
+			errMsg: `INVALID_DATE_FILTER: claim: claimStart "01/01/2024" is not a date, expected 2006-01-02 or 2006-01-02 15:04:05`},
+		{name: "start after end", query: "claimStart=2024-01-02&claimEnd=2024-01-01",
+			errMsg: "INVALID_DATE_FILTER: claim: claimStart is after claimEnd"},
+		{name: "open ended with max span", query: "createdStart=2024-01-01&claimStart=2024-01-01&claimEnd=2024-01-02",
+			errMsg: "INVALID_DATE_FILTER: created: createdStart and createdEnd are both needed, the range is at most 31 days"},
+		{name: "max span", query: "createdStart=2024-01-01&createdEnd=2024-02-01&claimStart=2024-01-01&claimEnd=2024-01-02",
+			errMsg: "INVALID_DATE_FILTER: created: the range is at most 31 days"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			_, err = filters.Parse(query)
+			var filterErr *service.DateFilterError
+			require.True(t, errors.As(err, &filterErr), "got %v", err)
+			assert.ErrorIs(t, err, service.ErrInvalidDateFilter)
+			assert.EqualError(t, err, tc.errMsg)
+		})
+	}
+
+	query, _ := url.ParseQuery("createdStart=2024-01-01&createdEnd=2024-01-31&claimStart=2024-01-01&claimEnd=2024-01-01")
+	got, err := filters.Parse(query)
+	require.NoError(t, err, "31 days and a single day are within range")
+	assert.Len(t, got, 4)
+}
+
+func TestNewDateFilters_Invalid(t *testing.T) {
+	created := service.DateFilter{Name: "created", Field: "CREATED_DATE", StartKey: "createdStart", EndKey: "createdEnd"}
+	assert.Panics(t, func() { service.NewDateFilters(created, created) })
+	assert.Panics(t, func() {
+		service.NewDateFilters(created, service.DateFilter{Name: "other", Field: "UPDATED_DATE", StartKey: "createdStart", EndKey: "otherEnd"})
+	})
+	assert.Panics(t, func() {
+		service.NewDateFilters(service.DateFilter{Name: "created", StartKey: "createdStart", EndKey: "createdEnd"})
+	})
+
+	var none *service.DateFilters
+	got, err := none.Parse(url.Values{"createdStart": {"2024-01-01"}})
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
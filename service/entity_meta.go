@@ -0,0 +1,240 @@
+package service
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tagName            = "db"
+	tagOptionPK        = "pk"
+	tagOptionReadonly  = "readonly"
+	tagOptionNoUpdate  = "noupdate"
+	tagOptionVersion   = "version"
+	defaultPrimaryKey  = "ID"
+	tagOptionSeparator = ","
+)
+
+// Tabler is implemented by entities which declare their own table name.
+// Entities without it are mapped to the upper-cased struct name.
+type Tabler interface {
+	TableName() string
+}
+
+// EntityMeta describes how an entity struct maps to its table. It is derived
+// once per type from the `db` tags, including those of embedded structs such as BaseEntity.
+//
+// Supported tag options:
+//   - pk: marks the primary key column (defaults to ID when no field is marked)
+//   - readonly: column is selected but never inserted or updated
+//   - noupdate: column is inserted but never updated (e.g. CREATED_DATE)
+//   - version: optimistic lock column, checked and incremented by updates instead of being set
+type EntityMeta struct {
+	TableName     string
+	PrimaryKey    string
+	VersionColumn string
+	Columns       []string
+	fields        []fieldMeta
+}
+
+type fieldMeta struct {
+	column   string
+	index    []int
+	pk       bool
+	readonly bool
+	noUpdate bool
+	version  bool
+}
+
+var entityMetaCache sync.Map
+
+// EntityMetaOf returns the cached table mapping of T. T must be a struct type.
+func EntityMetaOf[T any]() *EntityMeta {
+	t := reflect.TypeFor[T]()
+	if meta, ok := entityMetaCache.Load(t); ok {
+		return meta.(*EntityMeta)
+	}
+
+	meta := &EntityMeta{
+		TableName: strings.ToUpper(t.Name()),
+	}
+	var zero T
+	if tabler, ok := any(zero).(Tabler); ok {
+		meta.TableName = tabler.TableName()
+	} else if tabler, ok := any(&zero).(Tabler); ok {
+		meta.TableName = tabler.TableName()
+	}
+
+	meta.fields = collectFields(t, nil)
+	for i := range meta.fields {
+		if meta.fields[i].pk {
+			meta.PrimaryKey = meta.fields[i].column
+		}
+	}
+	if meta.PrimaryKey == "" {
+		meta.PrimaryKey = defaultPrimaryKey
+		for i := range meta.fields {
+			if strings.EqualFold(meta.fields[i].column, defaultPrimaryKey) {
+				meta.fields[i].pk = true
+			}
+		}
+	}
+	for _, f := range meta.fields {
+		meta.Columns = append(meta.Columns, f.column)
+		if f.version {
+			meta.VersionColumn = f.column
+		}
+	}
+
+	actual, _ := entityMetaCache.LoadOrStore(t, meta)
+	return actual.(*EntityMeta)
+}
+
+// collectFields walks the struct fields of t, descending into embedded structs without a db tag.
+func collectFields(t reflect.Type, parent []int) []fieldMeta {
+	var fields []fieldMeta
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+		tag := sf.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				fields = append(fields, collectFields(sf.Type, index)...)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		parts := strings.Split(tag, tagOptionSeparator)
+		f := fieldMeta{
+			column: parts[0],
+			index:  index,
+		}
+		for _, opt := range parts[1:] {
+			switch strings.TrimSpace(opt) {
+			case tagOptionPK:
+				f.pk = true
+			case tagOptionReadonly:
+				f.readonly = true
+			case tagOptionNoUpdate:
+				f.noUpdate = true
+			case tagOptionVersion:
+				f.version = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// InsertValues returns column/value pairs for an INSERT. A zero primary key is
+// skipped so the database can generate it, a zero version is inserted as INITIAL_VERSION.
+// A zero IS_DELETED is inserted as NOT_DELETED_FLAG and a zero CREATED_DATE as now: the bound NULL
+// or zero time would override their database defaults. Every entity gets the same columns, see BulkCreate.
+func (m *EntityMeta) InsertValues(entity any) []Value {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	var values []Value
+	for _, f := range m.fields {
+		if f.readonly {
+			continue
+		}
+		fv := v.FieldByIndex(f.index)
+		if f.pk && fv.IsZero() {
+			continue
+		}
+		if fv.IsZero() {
+			switch {
+			case f.version:
+				values = append(values, Value{Field: f.column, Value: int64(INITIAL_VERSION)})
+				continue
+			case f.column == IS_DELETED_COLUMN:
+				values = append(values, Value{Field: f.column, Value: NOT_DELETED_FLAG})
+				continue
+			case f.column == CREATED_DATE_COLUMN:
+				values = append(values, Value{Field: f.column, Value: time.Now()})
+				continue
+			}
+		}
+		values = append(values, Value{Field: f.column, Value: fv.Interface()})
+	}
+	return values
+}
+
+// UpdateValues returns column/value pairs for an UPDATE, excluding the primary key,
+// readonly, noupdate and version columns.
+func (m *EntityMeta) UpdateValues(entity any) []Value {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	var values []Value
+	for _, f := range m.fields {
+		if f.pk || f.readonly || f.noUpdate || f.version {
+			continue
+		}
+		values = append(values, Value{Field: f.column, Value: v.FieldByIndex(f.index).Interface()})
+	}
+	return values
+}
+
+// SoftDeletable reports whether the entity has the IS_DELETED and UPDATED_DATE columns Delete sets
+func (m *EntityMeta) SoftDeletable() bool {
+	return slices.Contains(m.Columns, IS_DELETED_COLUMN) && slices.Contains(m.Columns, UPDATED_DATE_COLUMN)
+}
+
+// SetPrimaryKey assigns id to the primary key field of entity, which must be a pointer.
+func (m *EntityMeta) SetPrimaryKey(entity any, id int64) {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	for _, f := range m.fields {
+		if !f.pk {
+			continue
+		}
+		fv := v.FieldByIndex(f.index)
+		if fv.CanInt() {
+			fv.SetInt(id)
+		}
+		return
+	}
+}
+
+// PrimaryKeyValue returns the value of the primary key field of entity.
+func (m *EntityMeta) PrimaryKeyValue(entity any) any {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	for _, f := range m.fields {
+		if f.pk {
+			return v.FieldByIndex(f.index).Interface()
+		}
+	}
+	return nil
+}
+
+// Version returns the value of the version field of entity, 0 when it has none.
+func (m *EntityMeta) Version(entity any) int64 {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	for _, f := range m.fields {
+		if f.version {
+			if fv := v.FieldByIndex(f.index); fv.CanInt() {
+				return fv.Int()
+			}
+		}
+	}
+	return 0
+}
+
+// SetVersion assigns version to the version field of entity, which must be a pointer.
+func (m *EntityMeta) SetVersion(entity any, version int64) {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	for _, f := range m.fields {
+		if f.version {
+			if fv := v.FieldByIndex(f.index); fv.CanInt() {
+				fv.SetInt(version)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+)
+
+// fakeDB is an in-memory database/sql driver which records every statement it receives,
+// used where a real *sqlx.Tx or *sqlx.Rows is needed.
+type fakeDB struct {
+	mu           sync.Mutex
+	log          []string
+	prepared     []string
+	args         [][]driver.NamedValue
+	execErr      map[string]error
+	rowsAffected int64
+	columns      []string
+	rows         [][]driver.Value
+	// results answers the queries with their own columns and rows instead of columns and rows when set
+	results func(query string, args []driver.NamedValue) ([]string, [][]driver.Value)
+}
+
+var fakeDBs sync.Map
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	fdb := &fakeDB{execErr: map[string]error{}, rowsAffected: 1}
+	fakeDBs.Store(t.Name(), fdb)
+	db, err := sql.Open("fakedb", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDBs.Delete(t.Name())
+	})
+	return db, fdb
+}
+
+func newFakeMasterDB(t *testing.T) (oracle.MasterDB, *fakeDB) {
+	db, fdb := newFakeDB(t)
+	return oracle.NewMasterDB(db, "godror"), fdb
+}
+
+func newFakeSlaveDB(t *testing.T) (oracle.SlaveDB, *fakeDB) {
+	db, fdb := newFakeDB(t)
+	return oracle.NewSlaveDB(db, "godror"), fdb
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = append(f.log, query)
+	f.args = append(f.args, args)
+	for substr, err := range f.execErr {
+		if strings.Contains(query, substr) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeDB) statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.log...)
+}
+
+// preparations returns the statements prepared, once per connection
+func (f *fakeDB) preparations() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.prepared...)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fdb, ok := fakeDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("fakedb %s not registered", name)
+	}
+	return &fakeConn{db: fdb.(*fakeDB)}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.prepared = append(c.db.prepared, query)
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	begin := "BEGIN"
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		begin += " " + sql.IsolationLevel(opts.Isolation).String()
+	}
+	if opts.ReadOnly {
+		begin += " READ ONLY"
+	}
+	if err := c.db.record(begin, nil); err != nil {
+		return nil, err
+	}
+	return &fakeTx{conn: c}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.db.record(query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(c.db.rowsAffected), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.db.record(query, args); err != nil {
+		return nil, err
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if c.db.results != nil {
+		columns, rows := c.db.results(query, args)
+		return &fakeRows{db: c.db, columns: columns, rows: rows}, nil
+	}
+	return &fakeRows{db: c.db, columns: c.db.columns, rows: c.db.rows}, nil
+}
+
+// CheckNamedValue accepts any argument, including sql.Out
+func (c *fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (tx *fakeTx) Commit() error   { return tx.conn.db.record("COMMIT", nil) }
+func (tx *fakeTx) Rollback() error { return tx.conn.db.record("ROLLBACK", nil) }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, toNamed(args))
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, toNamed(args))
+}
+
+func toNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+type fakeRows struct {
+	db      *fakeDB
+	columns []string
+	rows    [][]driver.Value
+	pos     int
+	closed  bool
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return r.db.record("CLOSE CURSOR", nil)
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+// DEFAULT_ACTOR is recorded as the author of changes made without an actor in the context
+const DEFAULT_ACTOR = "system"
+
+// ErrHistoryNotEnabled is returned when reading the history of a table which is not audited
+var ErrHistoryNotEnabled = errors.New("HISTORY_NOT_ENABLED")
+
+// HistoryTable describes where the changes of an audited table are recorded
+type HistoryTable struct {
+	// TableName is the history table, e.g. MEMBER_HISTORY
+	TableName string
+	// ForeignKey is the history column holding the primary key of the changed row, e.g. MEMBER_ID
+	ForeignKey string
+	// PrimaryKey is the primary key of the audited table, ID when empty
+	PrimaryKey string
+}
+
+// HistoryRecord is a row of a history table. OldValue is NULL for an insert and NewValue for a hard delete.
+type HistoryRecord struct {
+	Id          int64          `db:"ID"`
+	OldValue    sql.NullString `db:"OLD_VALUE"`
+	NewValue    sql.NullString `db:"NEW_VALUE"`
+	Action      string         `db:"ACTION"`
+	CreatedBy   string         `db:"CREATED_BY"`
+	CreatedDate time.Time      `db:"CREATED_DATE"`
+}
+
+// FieldChange is the change of a single field between two versions of a row.
+// Fields of JSON columns are compared one by one, Field is then the dotted path, e.g. INFO.address.primary.
+type FieldChange struct {
+	Field    string      `json:"field" example:"NAME"`
+	OldValue interface{} `json:"oldValue"`
+	NewValue interface{} `json:"newValue"`
+}
+
+// HistoryEntry is a HistoryRecord with its values decoded and compared
+type HistoryEntry struct {
+	Id          int64                  `json:"id" example:"1"`
+	Action      string                 `json:"action" example:"update"`
+	Actor       string                 `json:"actor" example:"system"`
+	CreatedDate time.Time              `json:"createdDate"`
+	OldValue    map[string]interface{} `json:"oldValue,omitempty"`
+	NewValue    map[string]interface{} `json:"newValue,omitempty"`
+	Changes     []FieldChange          `json:"changes"`
+}
+
+// SetActorInContext sets who the changes made with the returned context are recorded for
+func SetActorInContext(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, constants.ACTOR, actor)
+}
+
+// GetActorFromContext returns the actor set by SetActorInContext, DEFAULT_ACTOR otherwise
+func GetActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(constants.ACTOR).(string); ok && actor != "" {
+		return actor
+	}
+	return DEFAULT_ACTOR
+}
+
+// WithHistory returns a copy of r recording every insert, update and delete of table into history,
+// in the same transaction as the change. Bulk operations are not recorded.
+func (r BaseRepository) WithHistory(table string, history HistoryTable) BaseRepository {
+	if history.PrimaryKey == "" {
+		history.PrimaryKey = "ID"
+	}
+	histories := maps.Clone(r.histories)
+	if histories == nil {
+		histories = map[string]HistoryTable{}
+	}
+	histories[tableKey(table)] = history
+	r.histories = histories
+	return r
+}
+
+// historyOf returns the history of table, which may be given with an alias, e.g. "MEMBER m"
+func (r *BaseRepository) historyOf(table string) (HistoryTable, bool) {
+	history, ok := r.histories[tableKey(table)]
+	return history, ok
+}
+
+// tableKey is the name of table without its alias, e.g. MEMBER for "MEMBER m"
+func tableKey(table string) string {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// FindHistory returns the history of the row of table with the given id, latest first, paginated by param
+func (r *BaseRepository) FindHistory(ctx context.Context, table string, id int64, param SqlParameter) (entries []HistoryEntry, err error) {
+	history, ok := r.historyOf(table)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrHistoryNotEnabled, table)
+	}
+	limit := param.Limit
+	if limit == 0 {
+		limit = constants.DEFAULT_LIMIT
+	}
+
+	var records []HistoryRecord
+	query := fmt.Sprintf(findHistoryQuery, history.TableName, history.ForeignKey)
+	if err = r.SelectOperations(ctx, &records, query, id, param.Offset, limit); err != nil {
+		return nil, err
+	}
+
+	entries = make([]HistoryEntry, 0, len(records))
+	for _, record := range records {
+		entry, err := record.Entry()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// CountHistory returns how many changes of the row of table with the given id are recorded
+func (r *BaseRepository) CountHistory(ctx context.Context, table string, id int64) (count int64, err error) {
+	history, ok := r.historyOf(table)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrHistoryNotEnabled, table)
+	}
+	err = r.GetOperations(WithOperationClass(ctx, OPERATION_COUNT), &count, fmt.Sprintf(countHistoryQuery, history.TableName, history.ForeignKey), id)
+	return count, err
+}
+
+// Entry decodes the values of the record and computes its field-level changes
+func (h HistoryRecord) Entry() (entry HistoryEntry, err error) {
+	entry = HistoryEntry{
+		Id:          h.Id,
+		Action:      h.Action,
+		Actor:       h.CreatedBy,
+		CreatedDate: h.CreatedDate,
+	}
+	if h.OldValue.Valid {
+		if err = json.Unmarshal([]byte(h.OldValue.String), &entry.OldValue); err != nil {
+			return entry, fmt.Errorf("history %d: invalid old value: %w", h.Id, err)
+		}
+	}
+	if h.NewValue.Valid {
+		if err = json.Unmarshal([]byte(h.NewValue.String), &entry.NewValue); err != nil {
+			return entry, fmt.Errorf("history %d: invalid new value: %w", h.Id, err)
+		}
+	}
+	entry.Changes = DiffValues(entry.OldValue, entry.NewValue)
+	return entry, nil
+}
+
+// DiffValues returns the fields which differ between oldValue and newValue sorted by name.
+// Nested objects are compared field by field.
+func DiffValues(oldValue, newValue map[string]interface{}) []FieldChange {
+	changes := []FieldChange{}
+	diffValues("", oldValue, newValue, &changes)
+	return changes
+}
+
+func diffValues(prefix string, oldValue, newValue map[string]interface{}, changes *[]FieldChange) {
+	fields := slices.Collect(maps.Keys(oldValue))
+	for field := range newValue {
+		if _, ok := oldValue[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+
+	for _, field := range fields {
+		before, after := oldValue[field], newValue[field]
+		oldObject, oldIsObject := before.(map[string]interface{})
+		newObject, newIsObject := after.(map[string]interface{})
+		if oldIsObject && newIsObject {
+			diffValues(prefix+field+".", oldObject, newObject, changes)
+			continue
+		}
+		if !reflect.DeepEqual(before, after) {
+			*changes = append(*changes, FieldChange{Field: prefix + field, OldValue: before, NewValue: after})
+		}
+	}
+}
+
+// rowSnapshot is the value of audited rows keyed by primary key, ids keeps the order they were read in
+type rowSnapshot struct {
+	ids    []int64
+	values map[int64]map[string]interface{}
+}
+
+// audited runs write and records the change of every row matching sqlParameter.Params, comparing the rows
+// locked before the write to the same rows after it. action is recorded for the rows which exist on both sides,
+// a row appearing is an insert and a row disappearing a delete.
+func (r *BaseRepository) audited(ctx context.Context, sqlParameter SqlParameter, action string, write func(ctx context.Context) (int64, error)) (rowsAffected int64, err error) {
+	history, ok := r.historyOf(sqlParameter.TableName)
+	if !ok {
+		return write(ctx)
+	}
+
+	err = r.WithTransaction(ctx, func(ctx context.Context) error {
+		conditional, args := r.GenerateConditional(sqlParameter)
+		before, err := r.snapshot(ctx, sqlParameter.TableName, history, conditional+" FOR UPDATE", args)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected, err = write(ctx); err != nil {
+			return err
+		}
+
+		var after rowSnapshot
+		if len(before.ids) == 0 {
+			after, err = r.snapshot(ctx, sqlParameter.TableName, history, conditional, args)
+		} else {
+			after, err = r.snapshotByIds(ctx, sqlParameter.TableName, history, before.ids)
+		}
+		if err != nil {
+			return err
+		}
+
+		ids := before.ids
+		for _, id := range after.ids {
+			if _, ok := before.values[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+		for _, id := range ids {
+			rowAction := action
+			switch {
+			case before.values[id] == nil:
+				rowAction = constants.ACTION_INSERT
+			case after.values[id] == nil:
+				rowAction = constants.ACTION_DELETE
+			}
+			if err = r.recordHistory(ctx, history, id, rowAction, before.values[id], after.values[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return rowsAffected, err
+}
+
+// auditedInsert runs insert, which returns the generated primary key, and records the inserted row
+func (r *BaseRepository) auditedInsert(ctx context.Context, table string, insert func(ctx context.Context) (int64, error)) (id int64, err error) {
+	history, ok := r.historyOf(table)
+	if !ok {
+		return insert(ctx)
+	}
+
+	err = r.WithTransaction(ctx, func(ctx context.Context) error {
+		if id, err = insert(ctx); err != nil {
+			return err
+		}
+		after, err := r.snapshotByIds(ctx, table, history, []int64{id})
+		if err != nil {
+			return err
+		}
+		return r.recordHistory(ctx, history, id, constants.ACTION_INSERT, nil, after.values[id])
+	})
+	return id, err
+}
+
+func (r *BaseRepository) snapshotByIds(ctx context.Context, table string, history HistoryTable, ids []int64) (rowSnapshot, error) {
+	conditional, args := r.GenerateConditional(SqlParameter{
+		Params: []FilterParam{MakeFilterParam(history.PrimaryKey, constants.IN, ids)},
+	})
+	return r.snapshot(ctx, table, history, conditional, args)
+}
+
+// snapshot reads the full rows of table matching conditional, it must run inside the transaction of the change
+func (r *BaseRepository) snapshot(ctx context.Context, table string, history HistoryTable, conditional string, args []interface{}) (snapshot rowSnapshot, err error) {
+	tx, ok := GetTxConnInContext(ctx)
+	if !ok {
+		return snapshot, errors.New("history snapshot must run inside a transaction")
+	}
+
+	query := fmt.Sprintf(snapshotQuery, table) + conditional
+	ctx = logQuery(ctx, query, args)
+	ctx, cancel := r.withTimeout(ctx, OPERATION_READ)
+	defer cancel()
+	var rows *sqlx.Rows
+	err = observe(ctx, GetLastFuncCallerName(), database.TARGET_TX, func(ctx context.Context) (err error) {
+		rows, err = tx.QueryxContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return snapshot, err
+	}
+	defer rows.Close()
+
+	snapshot.values = map[int64]map[string]interface{}{}
+	for rows.Next() {
+		row := map[string]interface{}{}
+		if err = rows.MapScan(row); err != nil {
+			return snapshot, err
+		}
+		normalized := make(map[string]interface{}, len(row))
+		for column, value := range row {
+			normalized[strings.ToUpper(column)] = snapshotValue(value)
+		}
+		id, err := snapshotId(normalized[strings.ToUpper(history.PrimaryKey)])
+		if err != nil {
+			return snapshot, fmt.Errorf("history %s: %w", history.TableName, err)
+		}
+		snapshot.ids = append(snapshot.ids, id)
+		snapshot.values[id] = normalized
+	}
+	return snapshot, rows.Err()
+}
+
+// snapshotValue converts a scanned value so it is stored readable: JSON documents are nested instead of quoted
+func snapshotValue(value interface{}) interface{} {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return value
+	}
+	trimmed := strings.TrimSpace(string(raw))
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid(raw) {
+		return json.RawMessage(raw)
+	}
+	return string(raw)
+}
+
+func snapshotId(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case nil:
+		return 0, errors.New("row has no primary key")
+	default:
+		return strconv.ParseInt(fmt.Sprint(v), 10, 64)
+	}
+}
+
+func (r *BaseRepository) recordHistory(ctx context.Context, history HistoryTable, id int64, action string, oldValue, newValue map[string]interface{}) error {
+	oldJSON, err := historyValue(oldValue)
+	if err != nil {
+		return err
+	}
+	newJSON, err := historyValue(newValue)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(insertHistoryQuery, history.TableName, history.ForeignKey)
+	_, err = r.WriteOrUpdateOperation(ctx, query, nil, id, oldJSON, newJSON, action, GetActorFromContext(ctx), time.Now())
+	return err
+}
+
+func historyValue(value map[string]interface{}) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
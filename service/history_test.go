@@ -0,0 +1,135 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+var memberHistory = service.HistoryTable{TableName: "MEMBER_HISTORY", ForeignKey: "MEMBER_ID"}
+
+func setupHistoryRepo(t *testing.T) (*service.BaseRepository, *fakeDB) {
+	master, fdb := newFakeMasterDB(t)
+	repo := service.BaseRepository{MasterDB: master}.WithHistory("MEMBER", memberHistory)
+	fdb.columns = []string{"ID", "NAME", "INFO"}
+	fdb.rows = [][]driver.Value{{int64(7), "John", `{"age":30}`}}
+	return &repo, fdb
+}
+
+func TestUpdate_RecordsHistory(t *testing.T) {
+	repo, fdb := setupHistoryRepo(t)
+	ctx := service.SetActorInContext(context.Background(), "alice")
+
+	_, err := repo.Update(ctx, service.SqlParameter{
+		TableName: "MEMBER",
+		Values:    []service.Value{{Field: "NAME", Value: "John"}},
+		Params:    []service.FilterParam{service.MakeFilterParam("ID", "=", int64(7))},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"SELECT * FROM MEMBER WHERE ID = :1 FOR UPDATE",
+		"CLOSE CURSOR",
+		"UPDATE MEMBER SET NAME=:1 WHERE ID = :2",
+		"SELECT * FROM MEMBER WHERE ID IN (:1)",
+		"CLOSE CURSOR",
+		"INSERT INTO MEMBER_HISTORY (MEMBER_ID, OLD_VALUE, NEW_VALUE, ACTION, CREATED_BY, CREATED_DATE) VALUES (:1, :2, :3, :4, :5, :6)",
+		"COMMIT",
+	}, fdb.statements())
+
+	args := fdb.args[len(fdb.args)-2]
+	assert.Equal(t, int64(7), args[0].Value)
+	assert.JSONEq(t, `{"ID":7,"NAME":"John","INFO":{"age":30}}`, args[1].Value.(sql.NullString).String)
+	assert.Equal(t, "update", args[3].Value)
+	assert.Equal(t, "alice", args[4].Value)
+}
+
+func TestDelete_RecordsDeleteAction(t *testing.T) {
+	repo, fdb := setupHistoryRepo(t)
+
+	_, err := repo.Delete(context.Background(), service.SqlParameter{
+		TableName: "MEMBER",
+		Params:    []service.FilterParam{service.MakeFilterParam("ID", "=", int64(7))},
+	})
+
+	assert.NoError(t, err)
+	args := fdb.args[len(fdb.args)-2]
+	assert.Equal(t, "delete", args[3].Value)
+	assert.Equal(t, service.DEFAULT_ACTOR, args[4].Value)
+}
+
+func TestUpdate_FailureRecordsNoHistory(t *testing.T) {
+	repo, fdb := setupHistoryRepo(t)
+	execErr := errors.New("ORA-00001: unique constraint violated")
+	fdb.execErr["UPDATE MEMBER"] = execErr
+
+	_, err := repo.Update(context.Background(), service.SqlParameter{
+		TableName: "MEMBER",
+		Values:    []service.Value{{Field: "NAME", Value: "John"}},
+		Params:    []service.FilterParam{service.MakeFilterParam("ID", "=", int64(7))},
+	})
+
+	assert.ErrorIs(t, err, execErr)
+	assert.Equal(t, "ROLLBACK", fdb.statements()[len(fdb.statements())-1])
+	assert.NotContains(t, fdb.statements(), "INSERT INTO MEMBER_HISTORY (MEMBER_ID, OLD_VALUE, NEW_VALUE, ACTION, CREATED_BY, CREATED_DATE) VALUES (:1, :2, :3, :4, :5, :6)")
+}
+
+func TestUpdate_NotAuditedTable(t *testing.T) {
+	repo, fdb := setupHistoryRepo(t)
+
+	_, err := repo.Update(context.Background(), service.SqlParameter{
+		TableName: "PRODUCT",
+		Values:    []service.Value{{Field: "CODE", Value: "A"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"UPDATE PRODUCT SET CODE=:1"}, fdb.statements())
+}
+
+func TestFindHistory_NotEnabled(t *testing.T) {
+	repo, _ := setupHistoryRepo(t)
+
+	_, err := repo.FindHistory(context.Background(), "PRODUCT", 1, service.SqlParameter{})
+
+	assert.ErrorIs(t, err, service.ErrHistoryNotEnabled)
+}
+
+func TestHistoryRecord_Entry(t *testing.T) {
+	record := service.HistoryRecord{
+		Id:        3,
+		OldValue:  sql.NullString{String: `{"NAME":"John","INFO":{"age":30,"address":{"primary":"A"}},"VERSION":1}`, Valid: true},
+		NewValue:  sql.NullString{String: `{"NAME":"Jane","INFO":{"age":30,"address":{"primary":"B"}},"VERSION":2}`, Valid: true},
+		Action:    "update",
+		CreatedBy: "alice",
+	}
+
+	entry, err := record.Entry()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", entry.Actor)
+	assert.Equal(t, []service.FieldChange{
+		{Field: "INFO.address.primary", OldValue: "A", NewValue: "B"},
+		{Field: "NAME", OldValue: "John", NewValue: "Jane"},
+		{Field: "VERSION", OldValue: float64(1), NewValue: float64(2)},
+	}, entry.Changes)
+}
+
+func TestHistoryRecord_EntryInsert(t *testing.T) {
+	record := service.HistoryRecord{
+		NewValue: sql.NullString{String: `{"NAME":"John"}`, Valid: true},
+		Action:   "insert",
+	}
+
+	entry, err := record.Entry()
+
+	assert.NoError(t, err)
+	assert.Nil(t, entry.OldValue)
+	assert.Equal(t, []service.FieldChange{{Field: "NAME", OldValue: nil, NewValue: "John"}}, entry.Changes)
+}
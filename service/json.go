@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/godror/godror"
+)
+
+// ErrInvalidJSON matches every document which does not decode into the type of its JSON column
+var ErrInvalidJSON = errors.New("INVALID_JSON")
+
+// JSON is a nullable JSON document of type T, stored in a VARCHAR2, CLOB, BLOB or native JSON column.
+// Like sql.Null[T], Valid is false for NULL. A document which does not decode into T fails the scan with
+// ErrInvalidJSON instead of leaving V zero.
+type JSON[T any] struct {
+	V     T
+	Valid bool
+	// Binary binds the document as []byte, which a BLOB column needs, instead of a string.
+	// Scan sets it when the column is read as bytes.
+	Binary bool
+}
+
+// NewJSON returns the valid document v, bound as a string
+func NewJSON[T any](v T) JSON[T] {
+	return JSON[T]{V: v, Valid: true}
+}
+
+// NewBinaryJSON returns the valid document v, bound as []byte for a BLOB column
+func NewBinaryJSON[T any](v T) JSON[T] {
+	return JSON[T]{V: v, Valid: true, Binary: true}
+}
+
+// nativeJSON is godror.JSON, read from a native JSON column (Oracle 21c)
+type nativeJSON interface {
+	StringWithOption(opts godror.JSONOption) (string, error)
+}
+
+// Scan implements sql.Scanner. An empty document, as read from EMPTY_BLOB(), is NULL.
+func (j *JSON[T]) Scan(value interface{}) error {
+	var zero T
+	j.V, j.Valid = zero, false
+
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw, j.Binary = v, true
+	case nativeJSON:
+		// numbers are decoded as float64, godror.Number would be marshalled as a string
+		document, err := v.StringWithOption(godror.JSONOptDefault)
+		if err != nil {
+			return fmt.Errorf("%w: %T: %w", ErrInvalidJSON, zero, err)
+		}
+		raw = []byte(document)
+	case io.Reader:
+		// a LOB read with godror.LobAsReader
+		var err error
+		if raw, err = io.ReadAll(v); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: cannot scan %T into %T", ErrInvalidJSON, value, zero)
+	}
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &j.V); err != nil {
+		return fmt.Errorf("%w: %T: %w", ErrInvalidJSON, zero, err)
+	}
+	j.Valid = true
+	return nil
+}
+
+// Value implements driver.Valuer, NULL when the document is not valid
+func (j JSON[T]) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+	document, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %T: %w", ErrInvalidJSON, j.V, err)
+	}
+	if j.Binary {
+		return document, nil
+	}
+	return string(document), nil
+}
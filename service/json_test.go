@@ -0,0 +1,95 @@
+package service_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/godror/godror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+type document struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// fakeNativeJSON stands for godror.JSON, read from a native JSON column
+type fakeNativeJSON struct {
+	document string
+	err      error
+}
+
+func (f fakeNativeJSON) StringWithOption(godror.JSONOption) (string, error) {
+	return f.document, f.err
+}
+
+func TestJSON_Scan(t *testing.T) {
+	testCases := []struct {
+		name   string
+		value  interface{}
+		want   service.JSON[document]
+		errMsg string
+	}{
+		{name: "varchar2 or clob", value: `{"name":"John","age":30}`, want: service.NewJSON(document{Name: "John", Age: 30})},
+		{name: "blob", value: []byte(`{"name":"John"}`), want: service.NewBinaryJSON(document{Name: "John"})},
+		{name: "native json", value: fakeNativeJSON{document: `{"name":"John","age":30}`}, want: service.NewJSON(document{Name: "John", Age: 30})},
+		{name: "lob reader", value: strings.NewReader(`{"age":30}`), want: service.NewJSON(document{Age: 30})},
+		{name: "null", value: nil},
+		{name: "empty blob", value: []byte{}, want: service.JSON[document]{Binary: true}},
+		{name: "corrupt", value: `{"name":`, errMsg: "INVALID_JSON: service_test.document: unexpected end of JSON input"},
+		{name: "wrong shape", value: `{"age":"thirty"}`, errMsg: "INVALID_JSON: service_test.document: json: cannot unmarshal string"},
+		{name: "native json error", value: fakeNativeJSON{err: godror.ErrInvalidJSON}, errMsg: "INVALID_JSON"},
+		{name: "unsupported", value: int64(1), errMsg: "INVALID_JSON: cannot scan int64 into service_test.document"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := service.NewJSON(document{Name: "stale"})
+			err := got.Scan(tc.value)
+			if tc.errMsg != "" {
+				assert.ErrorIs(t, err, service.ErrInvalidJSON)
+				assert.ErrorContains(t, err, tc.errMsg)
+				assert.False(t, got.Valid)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestJSON_Value(t *testing.T) {
+	value, err := service.NewJSON(document{Name: "John"}).Value()
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"John","age":0}`, value)
+
+	value, err = service.NewBinaryJSON(document{Name: "John"}).Value()
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"name":"John","age":0}`), value)
+
+	value, err = service.JSON[document]{V: document{Name: "ignored"}}.Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	_, err = service.NewJSON(map[string]interface{}{"f": func() {}}).Value()
+	assert.ErrorIs(t, err, service.ErrInvalidJSON)
+}
+
+func TestJSON_ScanErrorSurfaces(t *testing.T) {
+	slave, fdb := newFakeSlaveDB(t)
+	repo := service.BaseRepository{SlaveDB: slave}
+	fdb.columns = []string{"ID", "INFO"}
+	fdb.rows = [][]driver.Value{{int64(1), `{"name":`}}
+
+	var row struct {
+		Id   int64                  `db:"ID"`
+		Info service.JSON[document] `db:"INFO"`
+	}
+	err := repo.GetOperations(context.Background(), &row, "SELECT ID, INFO FROM MEMBER WHERE ID = :1", int64(1))
+	assert.True(t, errors.Is(err, service.ErrInvalidJSON), "corrupt documents are not rendered as zero values: %v", err)
+}
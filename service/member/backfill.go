@@ -0,0 +1,131 @@
+package member
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	service "oracle.com/oracle/my-go-oracle-app/service"
+)
+
+// JSON_BACKFILL is the backfill reshaping the legacy INFO and DETAIL documents of members
+const JSON_BACKFILL = "member_json"
+
+// legacyDetailKeys are the snake_case keys of DETAIL written before MemberDetail
+var legacyDetailKeys = map[string]string{
+	"member_id":        "memberId",
+	"onboarding_stage": "onboardingStage",
+	"risk_rating":      "riskRating",
+}
+
+// JSONBackfill returns the backfill reshaping the documents of members to MemberInfo and MemberDetail:
+// an INFO address given as a string becomes its primary address and the snake_case keys of DETAIL are
+// renamed. The other fields are kept, the documents already reshaped are left untouched.
+func JSONBackfill(chunkSize int, throttle time.Duration) service.Backfill {
+	return service.Backfill{
+		Name:      JSON_BACKFILL,
+		Table:     tableName,
+		ChunkSize: chunkSize,
+		Throttle:  throttle,
+		Transform: reshapeMembers,
+	}
+}
+
+func reshapeMembers(ctx context.Context, rows []service.BackfillRow) ([]service.BackfillUpdate, error) {
+	var updates []service.BackfillUpdate
+	for _, row := range rows {
+		var values []service.Value
+
+		info, changed, err := reshapeInfo(row.Values["INFO"])
+		if err != nil {
+			slog.WarnContext(ctx, fmt.Sprintf("member %d: INFO left as is: %v", row.Id, err))
+		} else if changed {
+			values = append(values, service.Value{Field: "INFO", Value: string(info)})
+		}
+
+		detail, changed, err := reshapeDetail(row.Values["DETAIL"])
+		if err != nil {
+			slog.WarnContext(ctx, fmt.Sprintf("member %d: DETAIL left as is: %v", row.Id, err))
+		} else if changed {
+			values = append(values, service.Value{Field: "DETAIL", Value: []byte(detail)})
+		}
+
+		if len(values) > 0 {
+			updates = append(updates, service.BackfillUpdate{Id: row.Id, Values: values})
+		}
+	}
+	return updates, nil
+}
+
+// reshapeInfo turns {"address": "..."} into {"address": {"primary": "...", "secondary": ""}}
+func reshapeInfo(value interface{}) (json.RawMessage, bool, error) {
+	document, err := jsonObject(value)
+	if document == nil || err != nil {
+		return nil, false, err
+	}
+
+	raw := document["address"]
+	if len(raw) == 0 || raw[0] != '"' {
+		return nil, false, nil
+	}
+	var address string
+	if err = json.Unmarshal(raw, &address); err != nil {
+		return nil, false, err
+	}
+	if document["address"], err = json.Marshal(Address{Primary: address}); err != nil {
+		return nil, false, err
+	}
+	reshaped, err := json.Marshal(document)
+	return reshaped, err == nil, err
+}
+
+// reshapeDetail renames the legacyDetailKeys of DETAIL, unless the new key is already set
+func reshapeDetail(value interface{}) (json.RawMessage, bool, error) {
+	document, err := jsonObject(value)
+	if document == nil || err != nil {
+		return nil, false, err
+	}
+
+	changed := false
+	for legacy, key := range legacyDetailKeys {
+		v, ok := document[legacy]
+		if !ok {
+			continue
+		}
+		if _, ok := document[key]; !ok {
+			document[key] = v
+		}
+		delete(document, legacy)
+		changed = true
+	}
+	if !changed {
+		return nil, false, nil
+	}
+	reshaped, err := json.Marshal(document)
+	return reshaped, err == nil, err
+}
+
+// jsonObject decodes a document read by the backfill, nil for NULL
+func jsonObject(value interface{}) (map[string]json.RawMessage, error) {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case json.RawMessage:
+		raw = v
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return nil, fmt.Errorf("unexpected %T", value)
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
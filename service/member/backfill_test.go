@@ -0,0 +1,57 @@
+package member_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+	"oracle.com/oracle/my-go-oracle-app/service/member"
+)
+
+func TestJSONBackfill_Transform(t *testing.T) {
+	backfill := member.JSONBackfill(100, 0)
+	assert.Equal(t, "MEMBER", backfill.Table)
+
+	rows := []service.BackfillRow{
+		{Id: 1, Values: map[string]interface{}{
+			"INFO":   json.RawMessage(`{"address": "789 Tree House", "salary": 45000, "age": 32}`),
+			"DETAIL": nil,
+		}},
+		{Id: 2, Values: map[string]interface{}{
+			"INFO":   json.RawMessage(`{"address": {"primary": "1 Main St", "secondary": ""}, "age": 40}`),
+			"DETAIL": json.RawMessage(`{"member_id": "M-2", "risk_rating": "low", "riskRating": "high", "extra": 1}`),
+		}},
+		{Id: 3, Values: map[string]interface{}{
+			"INFO":   json.RawMessage(`{"address": {"primary": "2 Main St", "secondary": ""}}`),
+			"DETAIL": json.RawMessage(`{"memberId": "M-3"}`),
+		}},
+		{Id: 4, Values: map[string]interface{}{
+			"INFO":   "not json",
+			"DETAIL": json.RawMessage(`{"address": null}`),
+		}},
+	}
+
+	updates, err := backfill.Transform(context.Background(), rows)
+	require.NoError(t, err)
+	require.Len(t, updates, 2, "reshaped and invalid documents are left untouched")
+
+	assert.Equal(t, int64(1), updates[0].Id)
+	require.Len(t, updates[0].Values, 1)
+	assert.Equal(t, "INFO", updates[0].Values[0].Field)
+	assert.JSONEq(t, `{"address": {"primary": "789 Tree House", "secondary": ""}, "salary": 45000, "age": 32}`, updates[0].Values[0].Value.(string))
+
+	assert.Equal(t, int64(2), updates[1].Id)
+	require.Len(t, updates[1].Values, 1)
+	assert.Equal(t, "DETAIL", updates[1].Values[0].Field)
+	assert.JSONEq(t, `{"memberId": "M-2", "riskRating": "high", "extra": 1}`, string(updates[1].Values[0].Value.([]byte)), "BLOB bound as bytes")
+
+	again, err := backfill.Transform(context.Background(), []service.BackfillRow{
+		{Id: 1, Values: map[string]interface{}{"INFO": json.RawMessage(updates[0].Values[0].Value.(string))}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, again, "idempotent")
+}
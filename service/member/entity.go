@@ -1,8 +1,6 @@
 package member
 
 import (
-	"database/sql"
-	"encoding/json"
 	"time"
 
 	entity "oracle.com/oracle/my-go-oracle-app/service"
@@ -12,11 +10,12 @@ import (
 // buat contoh untuk type data lain
 // integer, booleah (cahar(1)), date/timestamp
 
+// Member is a row of MEMBER: INFO and POLICY are text documents, DETAIL a BLOB one
 type Member struct {
-	Name   string           `db:"NAME"`
-	Info   string           `db:"INFO"`
-	Detail sql.Null[[]byte] `db:"DETAIL"`
-	Policy sql.NullString   `db:"POLICY"`
+	Name   string                    `db:"NAME"`
+	Info   entity.JSON[MemberInfo]   `db:"INFO"`
+	Detail entity.JSON[MemberDetail] `db:"DETAIL"`
+	Policy entity.JSON[Policy]       `db:"POLICY"`
 	entity.BaseEntity
 }
 
@@ -31,6 +30,8 @@ type MemberRequest struct {
 	Info   MemberInfo   `json:"info"`
 	Detail MemberDetail `json:"detail"`
 	Policy Policy       `json:"policy"`
+	// Version the update is based on, mandatory for updates
+	Version int64 `json:"version,omitempty" example:"1"`
 }
 
 type Policy struct {
@@ -47,6 +48,7 @@ type MemberResponse struct {
 	Policy      Policy       `json:"policy"`
 	CreatedDate time.Time    `json:"createdDate,omitempty"`
 	IsDeleted   bool         `json:"isDeleted"`
+	Version     int64        `json:"version"`
 }
 
 type MemberInfo struct {
@@ -60,15 +62,10 @@ type Address struct {
 	Secondary string `json:"secondary"`
 }
 
+// ToResponse renders the member, a NULL document as its zero value
 func (m *Member) ToResponse() MemberResponse {
 
-	var info MemberInfo
-	var detail MemberDetail
-	var policy Policy
 	var isDeleted bool
-	json.Unmarshal([]byte(m.Info), &info)
-	json.Unmarshal(m.Detail.V, &detail)
-	json.Unmarshal([]byte(m.Policy.String), &policy)
 	isDeleted = false
 	if m.IsDeleted == "1" {
 		isDeleted = true
@@ -76,23 +73,35 @@ func (m *Member) ToResponse() MemberResponse {
 	return MemberResponse{
 		Id:          m.BaseEntity.Id,
 		Name:        m.Name,
-		Info:        info,
-		Detail:      detail,
-		Policy:      policy,
+		Info:        m.Info.V,
+		Detail:      m.Detail.V,
+		Policy:      m.Policy.V,
 		CreatedDate: m.CreatedDate,
 		IsDeleted:   isDeleted,
+		Version:     m.Version,
 	}
 }
-func (m *MemberRequest) ToEntity(base entity.BaseEntity) Member {
-	infoBytes, _ := json.Marshal(m.Info)
-	policyBytes, _ := json.Marshal(m.Policy)
-	detailBytes, _ := json.Marshal(m.Detail)
 
+// KeysetValue returns the value of the column members are ordered by for keyset pagination,
+// nil for an empty name which Oracle stores as NULL
+func (m *Member) KeysetValue(orderKey string) interface{} {
+	switch orderKey {
+	case "name":
+		if m.Name == "" {
+			return nil
+		}
+		return m.Name
+	default:
+		return m.Id
+	}
+}
+
+func (m *MemberRequest) ToEntity(base entity.BaseEntity) Member {
 	return Member{
 		Name:       m.Name,
-		Info:       string(infoBytes),
-		Detail:     sql.Null[[]byte]{V: detailBytes, Valid: true},
-		Policy:     sql.NullString{String: string(policyBytes), Valid: true},
+		Info:       entity.NewJSON(m.Info),
+		Detail:     entity.NewBinaryJSON(m.Detail),
+		Policy:     entity.NewJSON(m.Policy),
 		BaseEntity: base,
 	}
 }
@@ -2,7 +2,6 @@ package member
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log/slog"
 
@@ -21,39 +20,82 @@ var (
 const (
 	FAILED_FETCH_DATA_ERR_MSG = "failed fetch data. err=%v"
 	tableName                 = "MEMBER"
+	idColumn                  = "M.ID"
 )
 
+// history is where the changes of members are recorded, see WithHistory
+var history = service.HistoryTable{
+	TableName:  "MEMBER_HISTORY",
+	ForeignKey: "MEMBER_ID",
+}
+
+// schema is what member statements may name, filters and sort options of the HTTP API included
+var schema = service.NewSchema()
+
+func init() {
+	schema.Table(tableName, "ID", "NAME", "INFO", "DETAIL", "POLICY", "CREATED_DATE", "UPDATED_DATE", "IS_DELETED", "VERSION").
+		JSONPaths("INFO", "$.address", "$.age", "$.salary").
+		JSONPaths("DETAIL", "$.memberId", "$.onboardingStage", "$.riskRating").
+		JSONPaths("POLICY", "$.dataCategories")
+}
+
 type memberRepository struct {
 	service.BaseRepository
 }
 
 type MemberRepository interface {
-	FindById(ctx context.Context, ID int64) (Member, error)
+	FindById(ctx context.Context, ID int64, includeDeleted bool) (Member, error)
 	GetAllMembers(ctx context.Context, param service.SqlParameter) ([]Member, error)
 	CountAll(ctx context.Context, params service.SqlParameter) (int64, error)
 	CreateMember(ctx context.Context, data *Member) (int64, error)
 	UpdateMember(ctx context.Context, id int64, data *Member) (int64, error)
 	DeleteMember(ctx context.Context, id int64) (int64, error)
+	RestoreMember(ctx context.Context, id int64) (int64, error)
+	FindHistory(ctx context.Context, id int64, param service.SqlParameter) ([]service.HistoryEntry, error)
+	CountHistory(ctx context.Context, id int64) (int64, error)
 }
 
 func NewMemberRepository(baseRepository service.BaseRepository) MemberRepository {
 	return &memberRepository{
-		baseRepository,
+		baseRepository.WithSchema(schema).WithSoftDelete(tableName).WithVersionColumn(tableName),
 	}
 
 }
 
-func (mr *memberRepository) FindById(ctx context.Context, ID int64) (member Member, err error) {
-	err = mr.GetOperations(ctx, &member, findByIdQuery, ID)
+// WithHistory returns baseRepository recording the changes of members into MEMBER_HISTORY
+func WithHistory(baseRepository service.BaseRepository) service.BaseRepository {
+	return baseRepository.WithHistory(tableName, history)
+}
+
+func (mr *memberRepository) FindById(ctx context.Context, ID int64, includeDeleted bool) (member Member, err error) {
+	param := service.SqlParameter{
+		TableName: fmt.Sprintf("%s m", tableName),
+		Params:    []service.FilterParam{service.MakeFilterParam(idColumn, constants.EQUAL, ID)},
+	}
+	if includeDeleted {
+		param = param.WithDeleted()
+	}
+	query, args, err := mr.BuildQuerySelect(getAllMemberQuery, param)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to build query: %v", err), slog.Int64("ID", ID))
+		return
+	}
+
+	err = mr.GetOperations(ctx, &member, query, args...)
 	if err != nil {
-		slog.WarnContext(ctx, fmt.Sprintf("failed to fetch data: %v", err), slog.String("query", findByIdQuery), slog.Int64("ID", ID))
+		slog.WarnContext(ctx, fmt.Sprintf("failed to fetch data: %v", err), slog.String("query", query), slog.Int64("ID", ID))
 		return
 	}
 	return
 }
 
 func (mr *memberRepository) GetAllMembers(ctx context.Context, param service.SqlParameter) (members []Member, err error) {
-	query, args := mr.GenerateQuerySelectWithParams(getAllMemberQuery, param)
+	param.TableName = fmt.Sprintf("%s m", tableName)
+	query, args, err := mr.BuildQuerySelect(getAllMemberQuery, param)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to build query: %v", err))
+		return
+	}
 
 	err = mr.SelectOperations(ctx, &members, query, args...)
 	if err != nil {
@@ -80,10 +122,12 @@ func (mr *memberRepository) CountAll(ctx context.Context, params service.SqlPara
 
 }
 
+// CreateMember inserts every column of the member, its version starting at service.INITIAL_VERSION
 func (m memberRepository) CreateMember(ctx context.Context, data *Member) (lastInsertId int64, err error) {
-	var returnedID int64
-	args := []interface{}{data.Name, data.Info, sql.Out{Dest: &returnedID}}
-	_, err = m.WriteOrUpdateOperation(ctx, createMemberQuery, &returnedID, args...)
+	returnedID, err := m.InsertReturning(ctx, service.SqlParameter{
+		TableName: tableName,
+		Values:    service.EntityMetaOf[Member]().InsertValues(data),
+	}, "ID")
 
 	if err != nil {
 		slog.WarnContext(ctx, fmt.Sprintf("failed to execute query, member = %v, errInsert = %v", data, err))
@@ -95,34 +139,73 @@ func (m memberRepository) CreateMember(ctx context.Context, data *Member) (lastI
 
 }
 
+// UpdateMember updates the member at data.Version and increments it.
+// service.VersionConflictError is returned when the member was updated in the meantime,
+// sql.ErrNoRows when it does not exist or is deleted: RestoreMember brings it back.
 func (m memberRepository) UpdateMember(ctx context.Context, id int64, data *Member) (rowsAffected int64, err error) {
-	args := []interface{}{data.Name, data.Info, data.Detail, data.Policy, data.UpdatedDate, data.IsDeleted, id}
-	result, errExec := m.WriteOrUpdateOperation(ctx, updateMemberQuery, nil, args...)
+	result, errExec := m.Update(ctx, service.SqlParameter{
+		TableName: tableName,
+		Values: []service.Value{
+			{Field: "NAME", Value: data.Name},
+			{Field: "INFO", Value: data.Info},
+			{Field: "DETAIL", Value: data.Detail},
+			{Field: "POLICY", Value: data.Policy},
+			{Field: "UPDATED_DATE", Value: data.UpdatedDate},
+		},
+		Params:  []service.FilterParam{service.MakeFilterParam("ID", constants.EQUAL, id)},
+		Version: data.Version,
+	})
 	if errExec != nil {
 		slog.WarnContext(ctx, fmt.Sprintf("failed to execute query, member = %v, errExec = %v", data, errExec))
 		return 0, errExec
 	}
 
-	if err != nil {
-		slog.WarnContext(ctx, fmt.Sprintf("failed to get rows affected, member = %v, err = %v", data, err))
-		return 0, err
+	data.Version++
+	return result, nil
+}
+
+// DeleteMember soft-deletes the member, it can be brought back with RestoreMember
+func (m memberRepository) DeleteMember(ctx context.Context, id int64) (rowsAffected int64, err error) {
+	result, errExec := m.Delete(ctx, service.SqlParameter{
+		TableName: tableName,
+		Params:    []service.FilterParam{service.MakeFilterParam("ID", constants.EQUAL, id)},
+	})
+	if errExec != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to execute query, id = %v, errExec = %v", id, errExec))
+		return 0, errExec
 	}
 
 	return result, nil
 }
 
-func (m memberRepository) DeleteMember(ctx context.Context, id int64) (rowsAffected int64, err error) {
-	args := []interface{}{id}
-	result, errExec := m.WriteOrUpdateOperation(ctx, DeleteMemberQuery, nil, args...)
+func (m memberRepository) RestoreMember(ctx context.Context, id int64) (rowsAffected int64, err error) {
+	result, errExec := m.Restore(ctx, service.SqlParameter{
+		TableName: tableName,
+		Params:    []service.FilterParam{service.MakeFilterParam("ID", constants.EQUAL, id)},
+	})
 	if errExec != nil {
 		slog.WarnContext(ctx, fmt.Sprintf("failed to execute query, id = %v, errExec = %v", id, errExec))
 		return 0, errExec
 	}
 
+	return result, nil
+}
+
+// FindHistory returns the recorded changes of the member, latest first
+func (m memberRepository) FindHistory(ctx context.Context, id int64, param service.SqlParameter) (entries []service.HistoryEntry, err error) {
+	entries, err = m.BaseRepository.FindHistory(ctx, tableName, id, param)
 	if err != nil {
-		slog.WarnContext(ctx, fmt.Sprintf("failed to get rows affected, id = %v, err = %v", id, err))
-		return 0, err
+		slog.WarnContext(ctx, fmt.Sprintf(FAILED_FETCH_DATA_ERR_MSG, err), slog.Int64("id", id))
+		return nil, err
 	}
+	return entries, nil
+}
 
-	return result, nil
+func (m memberRepository) CountHistory(ctx context.Context, id int64) (count int64, err error) {
+	count, err = m.BaseRepository.CountHistory(ctx, tableName, id)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf(FAILED_FETCH_DATA_ERR_MSG, err), slog.Int64("id", id))
+		return 0, err
+	}
+	return count, nil
 }
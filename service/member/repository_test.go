@@ -3,7 +3,10 @@ package member_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
 	entity "oracle.com/oracle/my-go-oracle-app/service"
 	"oracle.com/oracle/my-go-oracle-app/service/member"
 )
@@ -129,7 +133,7 @@ func (m *MockMasterDB) QueryRowContext(ctx context.Context, query string, args .
 	return &sql.Row{}
 }
 
-const findByIdQuery = "SELECT ID,NAME,INFO FROM MEMBER m WHERE id = :1 "
+const findByIdQuery = "SELECT ID,NAME,INFO,DETAIL,POLICY, CREATED_DATE, IS_DELETED, VERSION FROM MEMBER m WHERE M.ID = :1 AND NVL(m.IS_DELETED, '0') = :2"
 
 func setupTestRepo() (member.MemberRepository, *MockMasterDB, *MockSlaveDB) {
 	mockMaster := new(MockMasterDB)
@@ -149,7 +153,7 @@ func TestFindById_Success(t *testing.T) {
 	expectedID := int64(1)
 	expectedMember := member.Member{
 		Name: "Test User",
-		Info: "Test Info",
+		Info: entity.NewJSON(member.MemberInfo{Age: 30}),
 		BaseEntity: entity.BaseEntity{
 			Id: expectedID,
 		},
@@ -162,7 +166,7 @@ func TestFindById_Success(t *testing.T) {
 		findByIdQuery,
 		mock.MatchedBy(func(args []interface{}) bool {
 			// Verify the args array contains our expected ID
-			if len(args) != 1 {
+			if len(args) != 2 {
 				return false
 			}
 			return args[0] == expectedID
@@ -174,7 +178,7 @@ func TestFindById_Success(t *testing.T) {
 	}).Return(nil)
 
 	// Execute
-	result, err := repo.FindById(ctx, expectedID)
+	result, err := repo.FindById(ctx, expectedID, false)
 
 	// Assert
 	assert.NoError(t, err)
@@ -196,12 +200,12 @@ func TestFindById_NotFound(t *testing.T) {
 		mock.AnythingOfType("*member.Member"),
 		findByIdQuery,
 		mock.MatchedBy(func(args []interface{}) bool {
-			return len(args) == 1 && args[0] == expectedID
+			return len(args) == 2 && args[0] == expectedID
 		}),
 	).Return(sql.ErrNoRows)
 
 	// Execute
-	result, err := repo.FindById(ctx, expectedID)
+	result, err := repo.FindById(ctx, expectedID, false)
 
 	// Assert
 	assert.Error(t, err)
@@ -223,12 +227,12 @@ func TestFindById_DBError(t *testing.T) {
 		mock.AnythingOfType("*member.Member"),
 		findByIdQuery,
 		mock.MatchedBy(func(args []interface{}) bool {
-			return len(args) == 1 && args[0] == expectedID
+			return len(args) == 2 && args[0] == expectedID
 		}),
 	).Return(expectedError)
 
 	// Execute
-	result, err := repo.FindById(ctx, expectedID)
+	result, err := repo.FindById(ctx, expectedID, false)
 
 	// Assert
 	assert.Error(t, err)
@@ -250,14 +254,14 @@ func TestFindById_ContextTimeout(t *testing.T) {
 		mock.AnythingOfType("*member.Member"),
 		findByIdQuery,
 		mock.MatchedBy(func(args []interface{}) bool {
-			return len(args) == 1 && args[0] == expectedID
+			return len(args) == 2 && args[0] == expectedID
 		}),
 	).Run(func(args mock.Arguments) {
 		time.Sleep(5 * time.Millisecond) // Simulate slow DB
 	}).Return(context.DeadlineExceeded)
 
 	// Execute
-	result, err := repo.FindById(ctx, expectedID)
+	result, err := repo.FindById(ctx, expectedID, false)
 
 	// Assert
 	assert.Error(t, err)
@@ -267,8 +271,8 @@ func TestFindById_ContextTimeout(t *testing.T) {
 }
 
 const (
-	getAllMembersQuery = "SELECT ID,NAME,INFO FROM MEMBER m"
-	countAllQuery      = "SELECT COUNT(*) as count FROM MEMBER m"
+	getAllMembersQuery = "SELECT ID,NAME,INFO,DETAIL,POLICY, CREATED_DATE, IS_DELETED, VERSION FROM MEMBER m WHERE NVL(m.IS_DELETED, '0') = :1"
+	countAllQuery      = "SELECT COUNT(*) as count FROM MEMBER m WHERE NVL(m.IS_DELETED, '0') = :1"
 )
 
 func TestGetAllMembers_Success(t *testing.T) {
@@ -282,14 +286,14 @@ func TestGetAllMembers_Success(t *testing.T) {
 	expectedMembers := []member.Member{
 		{
 			Name: "User 1",
-			Info: "Info 1",
+			Info: entity.NewJSON(member.MemberInfo{Age: 30}),
 			BaseEntity: entity.BaseEntity{
 				Id: 1,
 			},
 		},
 		{
 			Name: "User 2",
-			Info: "Info 2",
+			Info: entity.NewJSON(member.MemberInfo{Age: 35}),
 			BaseEntity: entity.BaseEntity{
 				Id: 2,
 			},
@@ -349,6 +353,37 @@ func TestGetAllMembers_Error(t *testing.T) {
 	mockSlave.AssertExpectations(t)
 }
 
+// TestGetAllMembers_DetailFilters filters on every key a MemberDetail is stored with, which the schema must allow
+func TestGetAllMembers_DetailFilters(t *testing.T) {
+	repo, _, mockSlave := setupTestRepo()
+	mockSlave.On("SelectContext", mock.Anything, mock.AnythingOfType("*[]member.Member"), mock.Anything, mock.Anything).Return(nil)
+
+	data, err := json.Marshal(member.MemberDetail{MemberId: "M-1", OnboardingStage: "KYC", RiskRating: "LOW"})
+	assert.NoError(t, err)
+	var detail map[string]string
+	assert.NoError(t, json.Unmarshal(data, &detail))
+	assert.Len(t, detail, 3)
+
+	for key, value := range detail {
+		param := entity.SqlParameter{
+			TableName: "MEMBER m",
+			Columns:   []string{"ID", "NAME", "DETAIL"},
+			Params: []entity.FilterParam{
+				{Field: fmt.Sprintf("JSON_VALUE(DETAIL, '$.%s')", key), Operand: constants.LIKE, Value: value},
+			},
+		}
+		_, err := repo.GetAllMembers(context.Background(), param)
+		assert.NoError(t, err, key)
+	}
+
+	_, err = repo.GetAllMembers(context.Background(), entity.SqlParameter{
+		TableName: "MEMBER m",
+		Columns:   []string{"ID"},
+		Params:    []entity.FilterParam{{Field: "JSON_VALUE(DETAIL, '$.category')", Operand: constants.LIKE, Value: "A"}},
+	})
+	assert.ErrorIs(t, err, entity.ErrInvalidIdentifier, "not a MemberDetail key")
+}
+
 func TestCountAll_Success(t *testing.T) {
 	// Setup
 	repo, _, mockSlave := setupTestRepo()
@@ -423,23 +458,25 @@ func TestCreateMember_Success(t *testing.T) {
 	repo, mockMaster, _ := setupTestRepo()
 	ctx := context.Background()
 	newMember := &member.Member{
-		Name: "New User",
-		Info: "New Info",
+		Name:   "New User",
+		Info:   entity.NewJSON(member.MemberInfo{Age: 25}),
+		Detail: entity.NewJSON(member.MemberDetail{OnboardingStage: "KYC"}),
+		Policy: entity.NewJSON(member.Policy{Status: "ACTIVE"}),
 	}
 	expectedLastID := int64(1)
 
 	// Mock behavior for ExecContext (Oracle RETURNING INTO clause)
 	mockMaster.On("ExecContext",
 		mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.Anything,
+		"INSERT INTO MEMBER (NAME,INFO,DETAIL,POLICY,CREATED_DATE,UPDATED_DATE,IS_DELETED,VERSION) VALUES (:1,:2,:3,:4,:5,:6,:7,:8) RETURNING ID INTO :9",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 9 && reflect.DeepEqual(args[2], newMember.Detail) && reflect.DeepEqual(args[3], newMember.Policy) &&
+				args[7] == int64(entity.INITIAL_VERSION)
+		}),
 	).Run(func(args mock.Arguments) {
 		queryArgs := args.Get(2).([]interface{})
-		if len(queryArgs) >= 3 {
-			if out, ok := queryArgs[2].(sql.Out); ok {
-				*(out.Dest.(*int64)) = expectedLastID
-			}
-		}
+		out := queryArgs[len(queryArgs)-1].(sql.Out)
+		*(out.Dest.(*int64)) = expectedLastID
 	}).Return(mockResult{rowsAffected: 1}, nil)
 
 	// Execute
@@ -459,7 +496,7 @@ func TestUpdateMember_Success(t *testing.T) {
 	updateID := int64(1)
 	updateMember := &member.Member{
 		Name: "Updated User",
-		Info: "Updated Info",
+		Info: entity.NewJSON(member.MemberInfo{Age: 26}),
 	}
 	expectedRowsAffected := int64(1)
 	mockResult := &mockResult{rowsAffected: expectedRowsAffected}
@@ -480,6 +517,37 @@ func TestUpdateMember_Success(t *testing.T) {
 	mockMaster.AssertExpectations(t)
 }
 
+func TestUpdateMember_Deleted(t *testing.T) {
+	// Setup
+	repo, mockMaster, _ := setupTestRepo()
+	ctx := context.Background()
+	updateMember := &member.Member{
+		Name:       "Updated User",
+		BaseEntity: entity.BaseEntity{IsDeleted: "0", Version: 2},
+	}
+
+	// Mock behavior: the member is soft-deleted, so neither the update nor the version lookup match it
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"UPDATE MEMBER SET NAME=:1,INFO=:2,DETAIL=:3,POLICY=:4,UPDATED_DATE=:5,VERSION=VERSION+1 WHERE ID = :6 AND NVL(IS_DELETED, '0') = :7 AND VERSION = :8",
+		mock.Anything,
+	).Return(&mockResult{rowsAffected: 0}, nil)
+	mockMaster.On("GetContext",
+		mock.Anything,
+		mock.Anything,
+		"SELECT VERSION FROM MEMBER WHERE ID = :1 AND NVL(IS_DELETED, '0') = :2",
+		[]interface{}{int64(1), "0"},
+	).Return(sql.ErrNoRows)
+
+	// Execute
+	_, err := repo.UpdateMember(ctx, 1, updateMember)
+
+	// Assert
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Equal(t, int64(2), updateMember.Version)
+	mockMaster.AssertExpectations(t)
+}
+
 func TestDeleteMember_Success(t *testing.T) {
 	// Setup
 	repo, mockMaster, _ := setupTestRepo()
@@ -488,11 +556,13 @@ func TestDeleteMember_Success(t *testing.T) {
 	expectedRowsAffected := int64(1)
 	mockResult := &mockResult{rowsAffected: expectedRowsAffected}
 
-	// Mock behavior
+	// Mock behavior - soft delete
 	mockMaster.On("ExecContext",
 		mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.Anything,
+		"UPDATE MEMBER SET IS_DELETED=:1,UPDATED_DATE=:2,VERSION=VERSION+1 WHERE ID = :3 AND NVL(IS_DELETED, '0') = :4",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 4 && args[0] == "1" && args[2] == deleteID
+		}),
 	).Return(mockResult, nil)
 
 	// Execute
@@ -503,3 +573,27 @@ func TestDeleteMember_Success(t *testing.T) {
 	assert.Equal(t, expectedRowsAffected, rowsAffected)
 	mockMaster.AssertExpectations(t)
 }
+
+func TestRestoreMember_Success(t *testing.T) {
+	// Setup
+	repo, mockMaster, _ := setupTestRepo()
+	ctx := context.Background()
+	restoreID := int64(1)
+
+	// Mock behavior
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"UPDATE MEMBER SET IS_DELETED=:1,UPDATED_DATE=:2,VERSION=VERSION+1 WHERE ID = :3",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 3 && args[0] == "0" && args[2] == restoreID
+		}),
+	).Return(&mockResult{rowsAffected: 1}, nil)
+
+	// Execute
+	rowsAffected, err := repo.RestoreMember(ctx, restoreID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rowsAffected)
+	mockMaster.AssertExpectations(t)
+}
@@ -3,6 +3,7 @@ package member
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -10,29 +11,34 @@ import (
 	service "oracle.com/oracle/my-go-oracle-app/service"
 )
 
+// ErrVersionRequired is returned when an update does not say which version it is based on
+var ErrVersionRequired = errors.New("VERSION_REQUIRED")
+
 type memberService struct {
 	mr MemberRepository
 }
 
 type MemberService interface {
-	FindById(ctx context.Context, id int64) (MemberResponse, error)
+	FindById(ctx context.Context, id int64, includeDeleted bool) (MemberResponse, error)
 	FindAll(ctx context.Context, param service.SqlParameter) ([]MemberResponse, service.Pagination, error)
 	CreateMember(ctx context.Context, data *MemberRequest) (MemberResponse, error)
 	UpdateMember(ctx context.Context, id int64, data *MemberRequest) (MemberResponse, error)
 	DeleteMember(ctx context.Context, id int64) (bool, error)
+	RestoreMember(ctx context.Context, id int64) (MemberResponse, error)
+	FindHistory(ctx context.Context, id int64, param service.SqlParameter) ([]service.HistoryEntry, service.Pagination, error)
 }
 
 func NewMemberService(mr MemberRepository) MemberService {
 	return &memberService{mr: mr}
 }
 
-func (m *memberService) FindById(ctx context.Context, id int64) (MemberResponse, error) {
+func (m *memberService) FindById(ctx context.Context, id int64, includeDeleted bool) (MemberResponse, error) {
 	var (
 		response MemberResponse
 		member   Member
 	)
 
-	member, err := m.mr.FindById(ctx, id)
+	member, err := m.mr.FindById(ctx, id, includeDeleted)
 	if err != nil {
 		slog.WarnContext(ctx, fmt.Sprintf("Failed to get member data: %v", err), slog.Int64("id", id))
 		return response, err
@@ -43,6 +49,10 @@ func (m *memberService) FindById(ctx context.Context, id int64) (MemberResponse,
 }
 
 func (m *memberService) FindAll(ctx context.Context, param service.SqlParameter) (memberResponse []MemberResponse, page service.Pagination, err error) {
+	if param.Keyset != nil {
+		return m.findAllKeyset(ctx, param)
+	}
+
 	memberEntities, err := m.mr.GetAllMembers(ctx, param)
 
 	if err != nil {
@@ -65,6 +75,42 @@ func (m *memberService) FindAll(ctx context.Context, param service.SqlParameter)
 	return result, page, nil
 }
 
+// findAllKeyset fetches one row more than the limit to know whether a next page exists, total data is not counted.
+func (m *memberService) findAllKeyset(ctx context.Context, param service.SqlParameter) (memberResponse []MemberResponse, page service.Pagination, err error) {
+	limit := param.Limit
+	param.Limit = limit + 1
+	memberEntities, err := m.mr.GetAllMembers(ctx, param)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("Failed to get member data: %v", err))
+		return
+	}
+
+	hasNext := len(memberEntities) > limit
+	if hasNext {
+		memberEntities = memberEntities[:limit]
+	}
+	result := make([]MemberResponse, len(memberEntities))
+	for idx, data := range memberEntities {
+		result[idx] = data.ToResponse()
+	}
+
+	var (
+		lastSortValue interface{}
+		lastID        int64
+	)
+	if len(memberEntities) > 0 {
+		last := memberEntities[len(memberEntities)-1]
+		lastSortValue, lastID = last.KeysetValue(param.Keyset.OrderKey), last.Id
+	}
+	page, err = service.MakeKeysetPagination(param.Keyset, hasNext, lastSortValue, lastID)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("Failed to make member cursor: %v", err))
+		return
+	}
+
+	return result, page, nil
+}
+
 func (m *memberService) CreateMember(ctx context.Context, data *MemberRequest) (MemberResponse, error) {
 
 	var (
@@ -74,6 +120,7 @@ func (m *memberService) CreateMember(ctx context.Context, data *MemberRequest) (
 	baseEntity := service.BaseEntity{
 		CreatedDate: time.Now(),
 		IsDeleted:   "0",
+		Version:     service.INITIAL_VERSION,
 	}
 
 	member := data.ToEntity(baseEntity)
@@ -81,7 +128,7 @@ func (m *memberService) CreateMember(ctx context.Context, data *MemberRequest) (
 	id, err := m.mr.CreateMember(ctx, &member)
 	if err != nil {
 		slog.WarnContext(ctx, fmt.Sprintf("failed create member = %v, err = %v", data, err))
-		return response, fmt.Errorf("err:%s", err.Error())
+		return response, fmt.Errorf("err:%w", err)
 	}
 
 	response = member.ToResponse()
@@ -96,9 +143,15 @@ func (m *memberService) UpdateMember(ctx context.Context, id int64, data *Member
 		response MemberResponse
 	)
 
+	if data.Version == 0 {
+		return response, ErrVersionRequired
+	}
+
+	// IS_DELETED is not updated, a deleted member is not found: RestoreMember brings it back
 	baseEntity := service.BaseEntity{
 		UpdatedDate: sql.NullTime{Time: time.Now(), Valid: true},
 		IsDeleted:   "0",
+		Version:     data.Version,
 	}
 
 	member := data.ToEntity(baseEntity)
@@ -109,7 +162,7 @@ func (m *memberService) UpdateMember(ctx context.Context, id int64, data *Member
 	_, err := m.mr.UpdateMember(ctx, id, &member)
 	if err != nil {
 		slog.WarnContext(ctx, fmt.Sprintf("failed update member = %v, err = %v", data, err))
-		return response, fmt.Errorf("err:%s", err.Error())
+		return response, fmt.Errorf("err:%w", err)
 	}
 
 	response = member.ToResponse()
@@ -124,8 +177,40 @@ func (m *memberService) DeleteMember(ctx context.Context, id int64) (bool, error
 	_, err := m.mr.DeleteMember(ctx, id)
 	if err != nil {
 		slog.WarnContext(ctx, fmt.Sprintf("failed delete member id = %v, err = %v", id, err))
-		return false, fmt.Errorf("err:%s", err.Error())
+		return false, fmt.Errorf("err:%w", err)
 	}
 
 	return true, nil
 }
+
+// RestoreMember brings back a soft-deleted member. sql.ErrNoRows is returned when the member does not exist.
+func (m *memberService) RestoreMember(ctx context.Context, id int64) (MemberResponse, error) {
+	rowsAffected, err := m.mr.RestoreMember(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed restore member id = %v, err = %v", id, err))
+		return MemberResponse{}, fmt.Errorf("err:%w", err)
+	}
+	if rowsAffected == 0 {
+		return MemberResponse{}, sql.ErrNoRows
+	}
+
+	return m.FindById(ctx, id, false)
+}
+
+// FindHistory returns the recorded changes of the member, latest first, with their field-level differences
+func (m *memberService) FindHistory(ctx context.Context, id int64, param service.SqlParameter) (entries []service.HistoryEntry, page service.Pagination, err error) {
+	entries, err = m.mr.FindHistory(ctx, id, param)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("Failed to get member history: %v", err), slog.Int64("id", id))
+		return
+	}
+
+	count, err := m.mr.CountHistory(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("Failed to count member history: %v", err), slog.Int64("id", id))
+		return
+	}
+	page = service.MakePagination(count, param, len(entries))
+
+	return entries, page, nil
+}
@@ -2,6 +2,7 @@ package member_test
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 
@@ -17,8 +18,8 @@ type MockMemberRepository struct {
 	mock.Mock
 }
 
-func (m *MockMemberRepository) FindById(ctx context.Context, ID int64) (member.Member, error) {
-	args := m.Called(ctx, ID)
+func (m *MockMemberRepository) FindById(ctx context.Context, ID int64, includeDeleted bool) (member.Member, error) {
+	args := m.Called(ctx, ID, includeDeleted)
 	return args.Get(0).(member.Member), args.Error(1)
 }
 
@@ -47,6 +48,21 @@ func (m *MockMemberRepository) DeleteMember(ctx context.Context, id int64) (int6
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockMemberRepository) RestoreMember(ctx context.Context, id int64) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMemberRepository) FindHistory(ctx context.Context, id int64, param service.SqlParameter) ([]service.HistoryEntry, error) {
+	args := m.Called(ctx, id, param)
+	return args.Get(0).([]service.HistoryEntry), args.Error(1)
+}
+
+func (m *MockMemberRepository) CountHistory(ctx context.Context, id int64) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func setupTestService() (member.MemberService, *MockMemberRepository) {
 	mockRepo := new(MockMemberRepository)
 	service := member.NewMemberService(mockRepo)
@@ -60,23 +76,23 @@ func TestService_FindById_Success(t *testing.T) {
 	expectedID := int64(1)
 	memberEntity := member.Member{
 		Name: "Test User",
-		Info: `{"address":"Test Address","salary":5000,"age":30}`,
+		Info: service.NewJSON(member.MemberInfo{Address: member.Address{Primary: "Test Address"}, Salary: 5000, Age: 30}),
 		BaseEntity: service.BaseEntity{
 			Id: expectedID,
 		},
 	}
 
 	// Mock behavior
-	mockRepo.On("FindById", ctx, expectedID).Return(memberEntity, nil)
+	mockRepo.On("FindById", ctx, expectedID, false).Return(memberEntity, nil)
 
 	// Execute
-	result, err := svc.FindById(ctx, expectedID)
+	result, err := svc.FindById(ctx, expectedID, false)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.Equal(t, expectedID, result.Id)
 	assert.Equal(t, memberEntity.Name, result.Name)
-	assert.Equal(t, "Test Address", result.Info.Address)
+	assert.Equal(t, "Test Address", result.Info.Address.Primary)
 	assert.Equal(t, 5000, result.Info.Salary)
 	assert.Equal(t, 30, result.Info.Age)
 	mockRepo.AssertExpectations(t)
@@ -89,10 +105,10 @@ func TestService_FindById_NotFound(t *testing.T) {
 	expectedID := int64(999)
 
 	// Mock behavior
-	mockRepo.On("FindById", ctx, expectedID).Return(member.Member{}, errors.New("not found"))
+	mockRepo.On("FindById", ctx, expectedID, false).Return(member.Member{}, errors.New("not found"))
 
 	// Execute
-	result, err := svc.FindById(ctx, expectedID)
+	result, err := svc.FindById(ctx, expectedID, false)
 
 	// Assert
 	assert.Error(t, err)
@@ -114,14 +130,14 @@ func TestService_FindAll_Success(t *testing.T) {
 	members := []member.Member{
 		{
 			Name: "User 1",
-			Info: `{"address":"Address 1","salary":5000,"age":30}`,
+			Info: service.NewJSON(member.MemberInfo{Address: member.Address{Primary: "Address 1"}, Salary: 5000, Age: 30}),
 			BaseEntity: service.BaseEntity{
 				Id: 1,
 			},
 		},
 		{
 			Name: "User 2",
-			Info: `{"address":"Address 2","salary":6000,"age":35}`,
+			Info: service.NewJSON(member.MemberInfo{Address: member.Address{Primary: "Address 2"}, Salary: 6000, Age: 35}),
 			BaseEntity: service.BaseEntity{
 				Id: 2,
 			},
@@ -145,6 +161,39 @@ func TestService_FindAll_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestService_FindAll_Keyset(t *testing.T) {
+	// Setup
+	svc, mockRepo := setupTestService()
+	ctx := context.Background()
+	keyset := &service.Keyset{OrderKey: "name", SortColumn: "M.NAME", IDColumn: "M.ID"}
+	param := service.SqlParameter{Limit: 2, Keyset: keyset}
+	fetchParam := param
+	fetchParam.Limit = 3
+
+	members := []member.Member{
+		{Name: "User 1", BaseEntity: service.BaseEntity{Id: 1}},
+		{Name: "User 2", BaseEntity: service.BaseEntity{Id: 2}},
+		{Name: "User 3", BaseEntity: service.BaseEntity{Id: 3}},
+	}
+
+	// Mock behavior
+	mockRepo.On("GetAllMembers", ctx, fetchParam).Return(members, nil)
+
+	// Execute
+	results, pagination, err := svc.FindAll(ctx, param)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.True(t, pagination.NextPage)
+	cursor, err := service.DecodeCursor(pagination.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "User 2", cursor.SortValue)
+	assert.Equal(t, int64(2), cursor.ID)
+	mockRepo.AssertNotCalled(t, "CountAll", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestService_CreateMember_Success(t *testing.T) {
 	// Setup
 	svc, mockRepo := setupTestService()
@@ -152,7 +201,7 @@ func TestService_CreateMember_Success(t *testing.T) {
 	request := &member.MemberRequest{
 		Name: "New User",
 		Info: member.MemberInfo{
-			Address: "New Address",
+			Address: member.Address{Primary: "New Address"},
 			Salary:  5000,
 			Age:     25,
 		},
@@ -165,10 +214,8 @@ func TestService_CreateMember_Success(t *testing.T) {
 		Run(func(args mock.Arguments) {
 			memberArg := args.Get(1).(*member.Member)
 			assert.Equal(t, request.Name, memberArg.Name)
-			// Info will be JSON string
-			assert.Contains(t, memberArg.Info, `"address":"New Address"`)
-			assert.Contains(t, memberArg.Info, `"salary":5000`)
-			assert.Contains(t, memberArg.Info, `"age":25`)
+			assert.Equal(t, service.NewJSON(request.Info), memberArg.Info)
+			assert.True(t, memberArg.Detail.Binary, "DETAIL is a BLOB")
 		}).
 		Return(expectedID, nil)
 
@@ -193,10 +240,11 @@ func TestService_UpdateMember_Success(t *testing.T) {
 	request := &member.MemberRequest{
 		Name: "Updated User",
 		Info: member.MemberInfo{
-			Address: "Updated Address",
+			Address: member.Address{Primary: "Updated Address"},
 			Salary:  6000,
 			Age:     26,
 		},
+		Version: 2,
 	}
 
 	// Mock for UpdateMember
@@ -204,10 +252,10 @@ func TestService_UpdateMember_Success(t *testing.T) {
 	mockRepo.On("UpdateMember", ctx, updateID, mock.AnythingOfType("*member.Member")).
 		Run(func(args mock.Arguments) {
 			memberArg := args.Get(2).(*member.Member)
+			assert.Equal(t, int64(2), memberArg.Version)
+			memberArg.Version++
 			assert.Equal(t, request.Name, memberArg.Name)
-			assert.Contains(t, memberArg.Info, `"address":"Updated Address"`)
-			assert.Contains(t, memberArg.Info, `"salary":6000`)
-			assert.Contains(t, memberArg.Info, `"age":26`)
+			assert.Equal(t, service.NewJSON(request.Info), memberArg.Info)
 		}).
 		Return(int64(1), nil)
 
@@ -221,6 +269,57 @@ func TestService_UpdateMember_Success(t *testing.T) {
 	assert.Equal(t, request.Info.Address, result.Info.Address)
 	assert.Equal(t, request.Info.Salary, result.Info.Salary)
 	assert.Equal(t, request.Info.Age, result.Info.Age)
+	assert.Equal(t, int64(3), result.Version)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_UpdateMember_VersionRequired(t *testing.T) {
+	// Setup
+	svc, mockRepo := setupTestService()
+	ctx := context.Background()
+
+	// Execute
+	_, err := svc.UpdateMember(ctx, 1, &member.MemberRequest{Name: "Updated User"})
+
+	// Assert
+	assert.ErrorIs(t, err, member.ErrVersionRequired)
+	mockRepo.AssertNotCalled(t, "UpdateMember", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestService_UpdateMember_VersionConflict(t *testing.T) {
+	// Setup
+	svc, mockRepo := setupTestService()
+	ctx := context.Background()
+	conflict := &service.VersionConflictError{TableName: "MEMBER", ExpectedVersion: 2, CurrentVersion: 5}
+
+	// Mock behavior
+	mockRepo.On("UpdateMember", ctx, int64(1), mock.AnythingOfType("*member.Member")).Return(int64(0), conflict)
+
+	// Execute
+	_, err := svc.UpdateMember(ctx, 1, &member.MemberRequest{Name: "Updated User", Version: 2})
+
+	// Assert
+	var target *service.VersionConflictError
+	assert.ErrorIs(t, err, service.ErrVersionConflict)
+	assert.ErrorAs(t, err, &target)
+	assert.Equal(t, int64(5), target.CurrentVersion)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_UpdateMember_Deleted(t *testing.T) {
+	// Setup
+	svc, mockRepo := setupTestService()
+	ctx := context.Background()
+
+	// Mock behavior: the update does not match a soft-deleted member
+	mockRepo.On("UpdateMember", ctx, int64(1), mock.AnythingOfType("*member.Member")).Return(int64(0), sql.ErrNoRows)
+
+	// Execute
+	_, err := svc.UpdateMember(ctx, 1, &member.MemberRequest{Name: "Updated User", Version: 2})
+
+	// Assert
+	assert.ErrorIs(t, err, sql.ErrNoRows, "not found rather than a version conflict")
+	assert.NotErrorIs(t, err, service.ErrVersionConflict)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -258,3 +357,69 @@ func TestService_DeleteMember_NotFound(t *testing.T) {
 	assert.True(t, success) // The operation succeeded but no rows were affected
 	mockRepo.AssertExpectations(t)
 }
+
+func TestService_RestoreMember_Success(t *testing.T) {
+	// Setup
+	svc, mockRepo := setupTestService()
+	ctx := context.Background()
+	restoreID := int64(1)
+	memberEntity := member.Member{
+		Name:       "Restored User",
+		BaseEntity: service.BaseEntity{Id: restoreID, IsDeleted: "0"},
+	}
+
+	// Mock behavior
+	mockRepo.On("RestoreMember", ctx, restoreID).Return(int64(1), nil)
+	mockRepo.On("FindById", ctx, restoreID, false).Return(memberEntity, nil)
+
+	// Execute
+	result, err := svc.RestoreMember(ctx, restoreID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, restoreID, result.Id)
+	assert.False(t, result.IsDeleted)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RestoreMember_NotFound(t *testing.T) {
+	// Setup
+	svc, mockRepo := setupTestService()
+	ctx := context.Background()
+
+	// Mock behavior
+	mockRepo.On("RestoreMember", ctx, int64(999)).Return(int64(0), nil)
+
+	// Execute
+	_, err := svc.RestoreMember(ctx, 999)
+
+	// Assert
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_FindHistory_Success(t *testing.T) {
+	// Setup
+	svc, mockRepo := setupTestService()
+	ctx := context.Background()
+	param := service.SqlParameter{Limit: 1, Offset: 0}
+	entries := []service.HistoryEntry{{
+		Id:      2,
+		Action:  "update",
+		Changes: []service.FieldChange{{Field: "NAME", OldValue: "Old", NewValue: "New"}},
+	}}
+
+	// Mock behavior
+	mockRepo.On("FindHistory", ctx, int64(1), param).Return(entries, nil)
+	mockRepo.On("CountHistory", ctx, int64(1)).Return(int64(2), nil)
+
+	// Execute
+	result, page, err := svc.FindHistory(ctx, 1, param)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, entries, result)
+	assert.Equal(t, int64(2), page.TotalData)
+	assert.True(t, page.NextPage)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/mock"
+
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+)
+
+// MockSlaveDB implements oracle.SlaveDB interface for testing
+type MockSlaveDB struct {
+	mock.Mock
+}
+
+func (m *MockSlaveDB) Rebind(query string) string {
+	args := m.Called(query)
+	return args.String(0)
+}
+
+func (m *MockSlaveDB) Ping() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockSlaveDB) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockSlaveDB) PreparexContext(ctx context.Context, query string) (oracle.SlaveStatement, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(oracle.SlaveStatement), args.Error(1)
+}
+
+func (m *MockSlaveDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	callArgs := m.Called(ctx, dest, query, args)
+	return callArgs.Error(0)
+}
+
+func (m *MockSlaveDB) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	callArgs := m.Called(ctx, query, args)
+	return callArgs.Get(0).(*sqlx.Row)
+}
+
+func (m *MockSlaveDB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	callArgs := m.Called(ctx, dest, query, args)
+	return callArgs.Error(0)
+}
+
+func (m *MockSlaveDB) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	callArgs := m.Called(ctx, query, args)
+	return callArgs.Get(0).(*sqlx.Rows), callArgs.Error(1)
+}
+
+// MockMasterDB implements oracle.MasterDB interface for testing
+type MockMasterDB struct {
+	mock.Mock
+}
+
+func (m *MockMasterDB) Rebind(query string) string {
+	args := m.Called(query)
+	return args.String(0)
+}
+
+func (m *MockMasterDB) Ping() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockMasterDB) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockMasterDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	args := m.Called(ctx, opts)
+	return args.Get(0).(*sqlx.Tx), args.Error(1)
+}
+
+func (m *MockMasterDB) Beginx() (*sqlx.Tx, error) {
+	args := m.Called()
+	return args.Get(0).(*sqlx.Tx), args.Error(1)
+}
+
+func (m *MockMasterDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	callArgs := m.Called(ctx, query, args)
+	return callArgs.Get(0).(sql.Result), callArgs.Error(1)
+}
+
+func (m *MockMasterDB) PreparexContext(ctx context.Context, query string) (oracle.MasterStatement, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(oracle.MasterStatement), args.Error(1)
+}
+
+func (m *MockMasterDB) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	callArgs := m.Called(ctx, query, args)
+	return callArgs.Get(0).(*sqlx.Row)
+}
+
+func (m *MockMasterDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	callArgs := m.Called(ctx, query, args)
+	return callArgs.Get(0).(*sql.Rows), callArgs.Error(1)
+}
+
+func (m *MockMasterDB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	callArgs := m.Called(ctx, dest, query, args)
+	return callArgs.Error(0)
+}
+
+func (m *MockMasterDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	callArgs := m.Called(ctx, dest, query, args)
+	return callArgs.Error(0)
+}
+
+func (m *MockMasterDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	callArgs := m.Called(ctx, query, args)
+	if row, ok := callArgs.Get(0).(*sql.Row); ok {
+		return row
+	}
+	return &sql.Row{}
+}
+
+type mockResult struct {
+	lastId       int64
+	rowsAffected int64
+}
+
+func (m mockResult) LastInsertId() (int64, error) {
+	return m.lastId, nil
+}
+
+func (m mockResult) RowsAffected() (int64, error) {
+	return m.rowsAffected, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+)
+
+var monitor = database.NewQueryMonitor()
+
+// observe runs op as operation on target, recording its latency and, but for sql.ErrNoRows, its error.
+// op is logged when slow, see QueryLog. An op cancelled by its deadline returns a QueryTimeoutError.
+func observe(ctx context.Context, operation, target string, op func(ctx context.Context) error) error {
+	ctx = database.StartMetrics(ctx, database.Event{Name: operation, Target: target})
+	start := time.Now()
+	err := timeoutError(ctx, operation, op(ctx))
+	logSlowQuery(ctx, operation, target, time.Since(start), err)
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		monitor.Succeeded(ctx)
+	} else {
+		monitor.Failed(ctx, oraCodeLabel(err))
+	}
+	return err
+}
+
+// oraCodeLabel returns the ORA code of err formatted as ORA-00000, empty when it has none
+func oraCodeLabel(err error) string {
+	if code := OracleErrorCode(err); code != 0 {
+		return fmt.Sprintf("ORA-%05d", code)
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+// queryMetric sums the samples of the oracle query metric name whose labels include labels
+func queryMetric(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	total := 0.0
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			found := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				found[label.GetName()] = label.GetValue()
+			}
+			for key, value := range labels {
+				if found[key] != value {
+					continue metrics
+				}
+			}
+			if histogram := metric.GetHistogram(); histogram != nil {
+				total += float64(histogram.GetSampleCount())
+			} else {
+				total += metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return total
+}
+
+func TestMonitor_WritesObserved(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, errUniqueKey).Once()
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, nil)
+	master := map[string]string{"target": database.TARGET_MASTER}
+	uniqueKey := map[string]string{"target": database.TARGET_MASTER, "code": "ORA-00001"}
+	latencies, errors := queryMetric(t, "oracle_query_duration_seconds", master), queryMetric(t, "oracle_query_errors_total", uniqueKey)
+
+	_, err := repo.Insert(context.Background(), insertParam("John"))
+	assert.ErrorIs(t, err, errUniqueKey)
+	_, err = repo.Insert(context.Background(), insertParam("John"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, latencies+2, queryMetric(t, "oracle_query_duration_seconds", master))
+	assert.Equal(t, errors+1, queryMetric(t, "oracle_query_errors_total", uniqueKey))
+}
+
+func TestMonitor_TransactionObserved(t *testing.T) {
+	master, _ := newFakeMasterDB(t)
+	repo := service.BaseRepository{MasterDB: master}
+	tx := map[string]string{"target": database.TARGET_TX}
+	before := queryMetric(t, "oracle_query_duration_seconds", tx)
+
+	err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, insertParam("John"))
+		return err
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, before+2, queryMetric(t, "oracle_query_duration_seconds", tx), "the insert and the commit")
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// sqlIDAlphabet is the base 32 alphabet of Oracle SQL_IDs
+const sqlIDAlphabet = "0123456789abcdfghjkmnpqrstuvwxyz"
+
+// QueryLog configures how statements are logged, see SetQueryLog
+type QueryLog struct {
+	// Debug logs every statement at DEBUG with its redacted binds
+	Debug bool
+	// SlowThreshold above which a statement is logged at WARN, 0 disables the slow query log
+	SlowThreshold time.Duration
+	// SampleSize is how many of the last slow statements are kept for SlowQueries, 0 keeps none
+	SampleSize int
+}
+
+// SlowQuery is a statement which ran longer than QueryLog.SlowThreshold
+type SlowQuery struct {
+	Time      time.Time     `json:"time"`
+	Duration  time.Duration `json:"durationNs"`
+	Operation string        `json:"operation"`
+	Target    string        `json:"target"`
+	RequestID string        `json:"requestId,omitempty"`
+	SqlID     string        `json:"sqlId,omitempty"`
+	Query     string        `json:"query,omitempty"`
+	Binds     string        `json:"binds,omitempty"`
+	Err       string        `json:"error,omitempty"`
+}
+
+type queryLogger struct {
+	QueryLog
+
+	mu      sync.Mutex
+	samples []SlowQuery
+	next    int
+}
+
+var queryLog atomic.Pointer[queryLogger]
+
+func init() {
+	SetQueryLog(QueryLog{})
+}
+
+// SetQueryLog sets how statements are logged, dropping the slow queries kept so far
+func SetQueryLog(config QueryLog) {
+	queryLog.Store(&queryLogger{QueryLog: config})
+}
+
+// SlowQueries returns the last slow statements kept, the latest first
+func SlowQueries() []SlowQuery {
+	l := queryLog.Load()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	queries := make([]SlowQuery, 0, len(l.samples))
+	for i := range l.samples {
+		queries = append(queries, l.samples[(l.next-1-i+len(l.samples))%len(l.samples)])
+	}
+	return queries
+}
+
+type statementKey struct{}
+
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+// logQuery logs query at DEBUG when QueryLog.Debug is set and returns ctx carrying it for the slow query log
+func logQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	if queryLog.Load().Debug {
+		if dl, ok := ctx.Deadline(); ok {
+			slog.DebugContext(ctx, fmt.Sprintf("query= %v, binds=%v, sqlId=%s, remaining=%v", query, RedactBinds(args), SqlID(query), time.Until(dl)))
+		} else {
+			slog.DebugContext(ctx, fmt.Sprintf("query= %v, binds=%v, sqlId=%s", query, RedactBinds(args), SqlID(query)))
+		}
+	}
+	return context.WithValue(ctx, statementKey{}, statement{query: query, args: args})
+}
+
+// logSlowQuery logs the statement of ctx at WARN when it ran longer than QueryLog.SlowThreshold
+func logSlowQuery(ctx context.Context, operation, target string, duration time.Duration, err error) {
+	l := queryLog.Load()
+	if l.SlowThreshold <= 0 || duration < l.SlowThreshold {
+		return
+	}
+
+	slow := SlowQuery{
+		Time:      time.Now(),
+		Duration:  duration,
+		Operation: operation,
+		Target:    target,
+		RequestID: middleware.GetReqID(ctx),
+	}
+	if stmt, ok := ctx.Value(statementKey{}).(statement); ok {
+		slow.Query, slow.SqlID, slow.Binds = stmt.query, SqlID(stmt.query), RedactBinds(stmt.args)
+	}
+	if err != nil {
+		slow.Err = err.Error()
+	}
+
+	slog.WarnContext(ctx, fmt.Sprintf("slow query: duration=%v, operation=%s, target=%s, requestId=%s, sqlId=%s, binds=%s, query= %s",
+		slow.Duration, slow.Operation, slow.Target, slow.RequestID, slow.SqlID, slow.Binds, slow.Query))
+	l.sample(slow)
+}
+
+func (l *queryLogger) sample(slow SlowQuery) {
+	if l.SampleSize <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < l.SampleSize {
+		l.samples = append(l.samples, slow)
+	} else {
+		l.samples[l.next] = slow
+	}
+	l.next = (l.next + 1) % l.SampleSize
+}
+
+// SqlID returns the SQL_ID Oracle gives to query, to look it up in V$SQL or DBMS_XPLAN.DISPLAY_CURSOR.
+// It is the last 64 bits of the MD5 of the statement text with a trailing NUL, in base 32.
+func SqlID(query string) string {
+	if query == "" {
+		return ""
+	}
+	sum := md5.Sum(append([]byte(query), 0))
+	value := uint64(binary.LittleEndian.Uint32(sum[8:12]))<<32 | uint64(binary.LittleEndian.Uint32(sum[12:16]))
+
+	id := make([]byte, 13)
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i] = sqlIDAlphabet[value%32]
+		value /= 32
+	}
+	return string(id)
+}
+
+// RedactBinds describes args without their values: the bind name or position, the type and the length of strings
+func RedactBinds(args []interface{}) string {
+	binds := make([]string, 0, len(args))
+	position := 0
+	for _, arg := range args {
+		name := ""
+		if named, ok := arg.(sql.NamedArg); ok {
+			name, arg = ":"+named.Name, named.Value
+		}
+
+		var kind string
+		switch v := arg.(type) {
+		case nil:
+			kind = "NULL"
+		case sql.Out:
+			kind = "OUT"
+		case string:
+			kind = fmt.Sprintf("string(%d)", len(v))
+		case []byte:
+			kind = fmt.Sprintf("[]byte(%d)", len(v))
+		default:
+			typ := reflect.TypeOf(arg)
+			if typ.PkgPath() == "github.com/godror/godror" {
+				// driver options, e.g. godror.PrefetchCount, are not binds
+				continue
+			}
+			kind = typ.String()
+		}
+
+		if name == "" {
+			position++
+			name = fmt.Sprintf(":%d", position)
+		}
+		binds = append(binds, name+" "+kind)
+	}
+	return "[" + strings.Join(binds, ", ") + "]"
+}
@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/godror/godror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+func TestSqlID(t *testing.T) {
+	assert.Equal(t, "a5ks9fhw2v9s1", service.SqlID("select * from dual"))
+	assert.Empty(t, service.SqlID(""))
+}
+
+func TestRedactBinds(t *testing.T) {
+	var id int64
+	binds := service.RedactBinds([]interface{}{
+		"john@example.com", 42, nil, time.Time{}, sql.Named("v1", "secret"), sql.Out{Dest: &id}, godror.PrefetchCount(10),
+	})
+
+	assert.Equal(t, "[:1 string(16), :2 int, :3 NULL, :4 time.Time, :v1 string(6), :5 OUT]", binds)
+	assert.NotContains(t, binds, "john")
+}
+
+func TestSlowQuery_Sampled(t *testing.T) {
+	service.SetQueryLog(service.QueryLog{SlowThreshold: time.Nanosecond, SampleSize: 2})
+	t.Cleanup(func() { service.SetQueryLog(service.QueryLog{}) })
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { time.Sleep(time.Millisecond) }).Return(mockResult{}, nil)
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+
+	for _, name := range []string{"Ann", "Bob", "Carl"} {
+		_, err := repo.Insert(ctx, insertParam(name))
+		require.NoError(t, err)
+	}
+
+	slow := service.SlowQueries()
+	require.Len(t, slow, 2, "only the last SampleSize are kept")
+	assert.Equal(t, "INSERT INTO MEMBER (NAME) VALUES (:1)", slow[0].Query)
+	assert.Equal(t, service.SqlID(slow[0].Query), slow[0].SqlID)
+	assert.Equal(t, "[:1 string(4)]", slow[0].Binds, "the latest first")
+	assert.Equal(t, "[:1 string(3)]", slow[1].Binds)
+	assert.Equal(t, "req-1", slow[0].RequestID)
+	assert.Equal(t, database.TARGET_MASTER, slow[0].Target)
+	assert.GreaterOrEqual(t, slow[0].Duration, time.Millisecond)
+}
+
+func TestSlowQuery_BelowThreshold(t *testing.T) {
+	service.SetQueryLog(service.QueryLog{SlowThreshold: time.Hour, SampleSize: 2})
+	t.Cleanup(func() { service.SetQueryLog(service.QueryLog{}) })
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, nil)
+
+	_, err := repo.Insert(context.Background(), insertParam("Ann"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, service.SlowQueries())
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+// Repository is a typed repository for entity T built on top of BaseRepository.
+// Table name, columns and primary key are taken from T's `db` tags, see EntityMeta.
+type Repository[T any] struct {
+	BaseRepository
+	Meta *EntityMeta
+}
+
+// NewRepository creates Repository for entity T. The table and columns of T are added to the schema
+// of baseRepository, see WithSchema; JSON paths and expressions are registered with WithSchema.
+// Entities with IS_DELETED and UPDATED_DATE columns are soft-deletable, see WithSoftDelete, and those
+// with a version column get it incremented by soft deletes, see WithVersionColumn.
+func NewRepository[T any](baseRepository BaseRepository) *Repository[T] {
+	meta := EntityMetaOf[T]()
+	schema := NewSchema()
+	schema.Table(meta.TableName, meta.Columns...)
+	if meta.SoftDeletable() {
+		baseRepository = baseRepository.WithSoftDelete(meta.TableName)
+	}
+	if meta.VersionColumn != "" {
+		baseRepository = baseRepository.WithVersionColumn(meta.TableName)
+	}
+	return &Repository[T]{
+		BaseRepository: baseRepository.WithSchema(schema),
+		Meta:           meta,
+	}
+}
+
+// FindById returns the row with the given primary key. sql.ErrNoRows is returned when not found.
+func (r *Repository[T]) FindById(ctx context.Context, id int64) (entity T, err error) {
+	param := r.withDefaults(SqlParameter{
+		Params: []FilterParam{MakeFilterParam(r.Meta.PrimaryKey, constants.EQUAL, id)},
+	})
+	err = r.GetWithParameter(ctx, &entity, param)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to fetch %s: %v", r.Meta.TableName, err), slog.Int64("id", id))
+		return
+	}
+	return
+}
+
+// FindOne returns the first row matching param.
+func (r *Repository[T]) FindOne(ctx context.Context, param SqlParameter) (entity T, err error) {
+	param = r.withDefaults(param)
+	param.Limit = 1
+	param.Offset = 0
+	err = r.GetWithParameter(ctx, &entity, param)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to fetch %s: %v", r.Meta.TableName, err))
+		return
+	}
+	return
+}
+
+// FindAll returns all rows matching param, honouring its order, limit and offset.
+func (r *Repository[T]) FindAll(ctx context.Context, param SqlParameter) (entities []T, err error) {
+	err = r.SelectWithParameter(ctx, &entities, r.withDefaults(param))
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to fetch %s: %v", r.Meta.TableName, err))
+		return
+	}
+	return
+}
+
+// Count returns the number of rows matching the filters of param.
+func (r *Repository[T]) Count(ctx context.Context, param SqlParameter) (count int64, err error) {
+	param = r.withDefaults(param)
+	param.Columns = []string{constants.COUNT_COL}
+	param.OrderBy = nil
+	param.Limit = 0
+	param.Offset = 0
+	err = r.GetWithParameter(WithOperationClass(ctx, OPERATION_COUNT), &count, param)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to count %s: %v", r.Meta.TableName, err))
+		return 0, err
+	}
+	return
+}
+
+// Create inserts entity and sets its primary key from the generated value.
+func (r *Repository[T]) Create(ctx context.Context, entity *T) (lastInsertId int64, err error) {
+	returnedID, err := r.InsertReturning(ctx, SqlParameter{
+		TableName: r.Meta.TableName,
+		Values:    r.Meta.InsertValues(entity),
+	}, r.Meta.PrimaryKey)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to insert %s: %v", r.Meta.TableName, err))
+		return 0, err
+	}
+
+	r.Meta.SetPrimaryKey(entity, returnedID)
+	return returnedID, nil
+}
+
+// Upsert inserts entity or updates the existing row matching keyFields, which must be a unique key.
+// The existing row gets the columns UpdateById sets and its version incremented; the primary key is never
+// updated, so it is excluded unless listed in keyFields.
+func (r *Repository[T]) Upsert(ctx context.Context, entity *T, keyFields ...string) (inserted bool, err error) {
+	inserted, err = r.BaseRepository.Upsert(ctx, SqlParameter{
+		TableName: r.Meta.TableName,
+		Values:    r.Meta.InsertValues(entity),
+	}, r.Meta.UpdateValues(entity), keyFields)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to upsert %s: %v", r.Meta.TableName, err))
+		return false, err
+	}
+	return
+}
+
+// UpdateById updates all updatable columns of the row with the given primary key.
+// When the entity has a version column, the update only applies to that version and the entity
+// gets the incremented one; otherwise a VersionConflictError is returned.
+func (r *Repository[T]) UpdateById(ctx context.Context, id int64, entity *T) (rowsAffected int64, err error) {
+	param := SqlParameter{
+		TableName: r.Meta.TableName,
+		Values:    r.Meta.UpdateValues(entity),
+		Params:    []FilterParam{MakeFilterParam(r.Meta.PrimaryKey, constants.EQUAL, id)},
+	}
+	if r.Meta.VersionColumn != "" {
+		param.Version = r.Meta.Version(entity)
+	}
+	rowsAffected, err = r.Update(ctx, param)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to update %s: %v", r.Meta.TableName, err), slog.Int64("id", id))
+		return 0, err
+	}
+	if param.Version != 0 {
+		r.Meta.SetVersion(entity, param.Version+1)
+	}
+	return
+}
+
+// DeleteById soft-deletes the row with the given primary key, see BaseRepository.Delete.
+// The row of an entity which is not soft-deletable is removed, see BaseRepository.HardDelete.
+func (r *Repository[T]) DeleteById(ctx context.Context, id int64) (rowsAffected int64, err error) {
+	rowsAffected, err = r.Delete(ctx, SqlParameter{
+		TableName: r.Meta.TableName,
+		Params:    []FilterParam{MakeFilterParam(r.Meta.PrimaryKey, constants.EQUAL, id)},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to delete %s: %v", r.Meta.TableName, err), slog.Int64("id", id))
+		return 0, err
+	}
+	return
+}
+
+// BulkCreate inserts entities with array binding, see BaseRepository.BulkInsert.
+// Generated primary keys are not read back.
+func (r *Repository[T]) BulkCreate(ctx context.Context, entities []T, opts ...BulkOption) (result BulkResult, err error) {
+	rows := make([]SqlParameter, len(entities))
+	for i := range entities {
+		rows[i] = SqlParameter{
+			TableName: r.Meta.TableName,
+			Values:    r.Meta.InsertValues(&entities[i]),
+		}
+	}
+	result, err = r.BulkInsert(ctx, rows, opts...)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to bulk insert %s: %v", r.Meta.TableName, err))
+		return
+	}
+	return
+}
+
+// BulkUpdateById updates entities by their primary key with array binding, see BaseRepository.BulkUpdate.
+func (r *Repository[T]) BulkUpdateById(ctx context.Context, entities []T, opts ...BulkOption) (result BulkResult, err error) {
+	rows := make([]SqlParameter, len(entities))
+	for i := range entities {
+		rows[i] = SqlParameter{
+			TableName: r.Meta.TableName,
+			Values:    r.Meta.UpdateValues(&entities[i]),
+			Params:    []FilterParam{MakeFilterParam(r.Meta.PrimaryKey, constants.EQUAL, r.Meta.PrimaryKeyValue(&entities[i]))},
+		}
+	}
+	result, err = r.BulkUpdate(ctx, rows, opts...)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to bulk update %s: %v", r.Meta.TableName, err))
+		return
+	}
+	return
+}
+
+// RestoreById reverts the soft delete of the row with the given primary key.
+func (r *Repository[T]) RestoreById(ctx context.Context, id int64) (rowsAffected int64, err error) {
+	rowsAffected, err = r.Restore(ctx, SqlParameter{
+		TableName: r.Meta.TableName,
+		Params:    []FilterParam{MakeFilterParam(r.Meta.PrimaryKey, constants.EQUAL, id)},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("failed to restore %s: %v", r.Meta.TableName, err), slog.Int64("id", id))
+		return 0, err
+	}
+	return
+}
+
+// withDefaults fills table name and columns of param from the entity mapping when they are empty
+func (r *Repository[T]) withDefaults(param SqlParameter) SqlParameter {
+	if param.TableName == "" {
+		param.TableName = r.Meta.TableName
+	}
+	if len(param.Columns) == 0 {
+		param.Columns = r.Meta.Columns
+	}
+	return param
+}
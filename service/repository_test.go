@@ -0,0 +1,315 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+type product struct {
+	Code  string `db:"CODE"`
+	Price int64  `db:"PRICE"`
+	Notes string `db:"NOTES,readonly"`
+	Skip  string `db:"-"`
+	service.BaseEntity
+}
+
+func (product) TableName() string {
+	return "PRODUCT"
+}
+
+type unnamedTable struct {
+	Key  int64  `db:"KEY_ID,pk"`
+	Name string `db:"NAME"`
+}
+
+func setupProductRepo() (*service.Repository[product], *MockMasterDB, *MockSlaveDB) {
+	mockMaster := new(MockMasterDB)
+	mockSlave := new(MockSlaveDB)
+	repo := service.NewRepository[product](service.BaseRepository{
+		MasterDB: mockMaster,
+		SlaveDB:  mockSlave,
+	})
+	return repo, mockMaster, mockSlave
+}
+
+func TestEntityMetaOf(t *testing.T) {
+	meta := service.EntityMetaOf[product]()
+	assert.Equal(t, "PRODUCT", meta.TableName)
+	assert.Equal(t, "ID", meta.PrimaryKey)
+	assert.Equal(t, []string{"CODE", "PRICE", "NOTES", "ID", "CREATED_DATE", "UPDATED_DATE", "IS_DELETED", "VERSION"}, meta.Columns)
+	assert.Equal(t, "VERSION", meta.VersionColumn)
+
+	other := service.EntityMetaOf[unnamedTable]()
+	assert.Equal(t, "UNNAMEDTABLE", other.TableName)
+	assert.Equal(t, "KEY_ID", other.PrimaryKey)
+}
+
+func TestEntityMeta_Values(t *testing.T) {
+	meta := service.EntityMetaOf[product]()
+	p := product{Code: "A1", Price: 10, Notes: "ignored"}
+
+	insertFields := []string{}
+	for _, v := range meta.InsertValues(&p) {
+		insertFields = append(insertFields, v.Field)
+	}
+	assert.Equal(t, []string{"CODE", "PRICE", "CREATED_DATE", "UPDATED_DATE", "IS_DELETED", "VERSION"}, insertFields)
+
+	values := meta.InsertValues(&p)
+	assert.IsType(t, time.Time{}, values[2].Value)
+	assert.False(t, values[2].Value.(time.Time).IsZero(), "a zero CREATED_DATE is inserted as now")
+	assert.Equal(t, service.NOT_DELETED_FLAG, values[4].Value, "a zero IS_DELETED would be bound as NULL")
+	assert.Equal(t, int64(service.INITIAL_VERSION), values[5].Value)
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.CreatedDate, p.IsDeleted = created, service.DELETED_FLAG
+	values = meta.InsertValues(&p)
+	assert.Equal(t, created, values[2].Value)
+	assert.Equal(t, service.DELETED_FLAG, values[4].Value)
+
+	updateFields := []string{}
+	for _, v := range meta.UpdateValues(&p) {
+		updateFields = append(updateFields, v.Field)
+	}
+	assert.Equal(t, []string{"CODE", "PRICE", "UPDATED_DATE"}, updateFields, "IS_DELETED is only set by Delete and Restore")
+}
+
+func TestRepository_FindById(t *testing.T) {
+	repo, _, mockSlave := setupProductRepo()
+	ctx := context.Background()
+
+	mockSlave.On("GetContext",
+		mock.Anything,
+		mock.AnythingOfType("*service_test.product"),
+		"SELECT CODE,PRICE,NOTES,ID,CREATED_DATE,UPDATED_DATE,IS_DELETED,VERSION FROM PRODUCT WHERE ID = :1 AND NVL(IS_DELETED, '0') = :2",
+		[]interface{}{int64(7), "0"},
+	).Run(func(args mock.Arguments) {
+		dest := args.Get(1).(*product)
+		dest.Id = 7
+		dest.Code = "A1"
+	}).Return(nil)
+
+	result, err := repo.FindById(ctx, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), result.Id)
+	assert.Equal(t, "A1", result.Code)
+	mockSlave.AssertExpectations(t)
+}
+
+func TestRepository_Count(t *testing.T) {
+	repo, _, mockSlave := setupProductRepo()
+	ctx := context.Background()
+
+	mockSlave.On("GetContext",
+		mock.Anything,
+		mock.AnythingOfType("*int64"),
+		"SELECT COUNT(*) as count FROM PRODUCT WHERE CODE = :1 AND NVL(IS_DELETED, '0') = :2",
+		[]interface{}{"A1", "0"},
+	).Run(func(args mock.Arguments) {
+		*args.Get(1).(*int64) = 3
+	}).Return(nil)
+
+	count, err := repo.Count(ctx, service.SqlParameter{
+		Params:  []service.FilterParam{service.MakeFilterParam("CODE", "=", "A1")},
+		OrderBy: []string{"CODE asc"},
+		Limit:   10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	mockSlave.AssertExpectations(t)
+}
+
+func TestRepository_Create(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+	ctx := context.Background()
+	p := &product{Code: "A1", Price: 10}
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"INSERT INTO PRODUCT (CODE,PRICE,CREATED_DATE,UPDATED_DATE,IS_DELETED,VERSION) VALUES (:1,:2,:3,:4,:5,:6) RETURNING ID INTO :7",
+		mock.MatchedBy(func(args []interface{}) bool {
+			created, ok := args[2].(time.Time)
+			return len(args) == 7 && ok && !created.IsZero() && args[4] == service.NOT_DELETED_FLAG && args[5] == int64(service.INITIAL_VERSION)
+		}),
+	).Run(func(args mock.Arguments) {
+		queryArgs := args.Get(2).([]interface{})
+		out := queryArgs[len(queryArgs)-1].(sql.Out)
+		*(out.Dest.(*int64)) = 42
+	}).Return(mockResult{rowsAffected: 1}, nil)
+
+	id, err := repo.Create(ctx, p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+	assert.Equal(t, int64(42), p.Id)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestRepository_Upsert(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+	ctx := context.Background()
+	p := &product{Code: "A1", Price: 10}
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"BEGIN INSERT INTO PRODUCT (CODE,PRICE,CREATED_DATE,UPDATED_DATE,IS_DELETED,VERSION) VALUES (:v1,:v2,:v3,:v4,:v5,:v6); :inserted := 1; "+
+			"EXCEPTION WHEN DUP_VAL_ON_INDEX THEN UPDATE PRODUCT t SET t.PRICE = :v2,t.UPDATED_DATE = :v4,t.VERSION = t.VERSION + 1 WHERE t.CODE = :v1; "+
+			"IF SQL%ROWCOUNT = 0 THEN RAISE; END IF; :inserted := 0; END;",
+		mock.Anything,
+	).Return(mockResult{rowsAffected: 1}, nil)
+
+	_, err := repo.Upsert(ctx, p, "CODE")
+
+	assert.NoError(t, err, "an existing row keeps its CREATED_DATE and IS_DELETED, its version is incremented")
+	mockMaster.AssertExpectations(t)
+}
+
+func TestRepository_UpdateById(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+	ctx := context.Background()
+	p := &product{Code: "A2", Price: 20, BaseEntity: service.BaseEntity{IsDeleted: "0"}}
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"UPDATE PRODUCT SET CODE=:1,PRICE=:2,UPDATED_DATE=:3 WHERE ID = :4 AND NVL(IS_DELETED, '0') = :5",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 5 && args[0] == "A2" && args[3] == int64(5) && args[4] == "0"
+		}),
+	).Return(mockResult{rowsAffected: 1}, nil)
+
+	rows, err := repo.UpdateById(ctx, 5, p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestRepository_DeleteById(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+	ctx := context.Background()
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"UPDATE PRODUCT SET IS_DELETED=:1,UPDATED_DATE=:2,VERSION=VERSION+1 WHERE ID = :3 AND NVL(IS_DELETED, '0') = :4",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 4 && args[0] == "1" && args[2] == int64(5)
+		}),
+	).Return(mockResult{rowsAffected: 1}, nil)
+
+	rows, err := repo.DeleteById(ctx, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestRepository_DeleteById_NotSoftDeletable(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := service.NewRepository[unnamedTable](service.BaseRepository{MasterDB: mockMaster})
+
+	mockMaster.On("ExecContext", mock.Anything, "DELETE FROM UNNAMEDTABLE WHERE KEY_ID = :1", []interface{}{int64(5)}).
+		Return(mockResult{rowsAffected: 1}, nil)
+
+	rows, err := repo.DeleteById(context.Background(), 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestRepository_RestoreById(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+	ctx := context.Background()
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		"UPDATE PRODUCT SET IS_DELETED=:1,UPDATED_DATE=:2,VERSION=VERSION+1 WHERE ID = :3",
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 3 && args[0] == "0" && args[2] == int64(5)
+		}),
+	).Return(mockResult{rowsAffected: 1}, nil)
+
+	rows, err := repo.RestoreById(ctx, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestRepository_DeletedScope(t *testing.T) {
+	repo, _, mockSlave := setupProductRepo()
+	ctx := context.Background()
+	param := service.SqlParameter{Columns: []string{"CODE"}}
+
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, "SELECT CODE FROM PRODUCT", []interface{}(nil)).Return(nil).Once()
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, "SELECT CODE FROM PRODUCT WHERE IS_DELETED = :1", []interface{}{"1"}).Return(nil).Once()
+
+	_, err := repo.FindAll(ctx, param.WithDeleted())
+	assert.NoError(t, err)
+	_, err = repo.FindAll(ctx, param.OnlyDeleted())
+	assert.NoError(t, err)
+	mockSlave.AssertExpectations(t)
+
+	other := service.NewRepository[unnamedTable](service.BaseRepository{SlaveDB: mockSlave})
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, "SELECT KEY_ID,NAME FROM UNNAMEDTABLE", []interface{}(nil)).Return(nil).Once()
+	_, err = other.FindAll(ctx, service.SqlParameter{})
+	assert.NoError(t, err)
+	mockSlave.AssertExpectations(t)
+}
+
+func TestBaseRepository_WithSoftDelete(t *testing.T) {
+	mockSlave := new(MockSlaveDB)
+	ctx := context.Background()
+	var ids []int64
+
+	base := service.BaseRepository{SlaveDB: mockSlave}
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, "SELECT ID FROM MEMBER_HISTORY WHERE MEMBER_ID = :1", []interface{}{int64(1)}).Return(nil).Once()
+	err := base.SelectWithParameter(ctx, &ids, service.SqlParameter{
+		TableName: "MEMBER_HISTORY",
+		Columns:   []string{"ID"},
+		Params:    []service.FilterParam{service.MakeFilterParam("MEMBER_ID", "=", int64(1))},
+	})
+	assert.NoError(t, err, "tables without IS_DELETED are not scoped")
+
+	softDelete := base.WithSoftDelete("member")
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, "SELECT m.ID FROM MEMBER m WHERE NVL(m.IS_DELETED, '0') = :1", []interface{}{"0"}).Return(nil).Once()
+	err = softDelete.SelectWithParameter(ctx, &ids, service.SqlParameter{TableName: "MEMBER m", Columns: []string{"m.ID"}})
+	assert.NoError(t, err)
+	mockSlave.AssertExpectations(t)
+}
+
+func TestBaseRepository_Delete(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	ctx := context.Background()
+	param := service.SqlParameter{
+		TableName: "PRODUCT",
+		Params:    []service.FilterParam{service.MakeFilterParam("ID", "=", int64(5))},
+	}
+
+	base := service.BaseRepository{MasterDB: mockMaster}
+	mockMaster.On("ExecContext", mock.Anything, "DELETE FROM PRODUCT WHERE ID = :1", []interface{}{int64(5)}).
+		Return(mockResult{rowsAffected: 1}, nil).Once()
+	_, err := base.Delete(ctx, param)
+	assert.NoError(t, err, "rows of a table not registered with WithSoftDelete are removed")
+
+	softDelete := base.WithSoftDelete("PRODUCT")
+	mockMaster.On("ExecContext", mock.Anything, "UPDATE PRODUCT SET IS_DELETED=:1,UPDATED_DATE=:2 WHERE ID = :3 AND NVL(IS_DELETED, '0') = :4", mock.Anything).
+		Return(mockResult{rowsAffected: 1}, nil).Once()
+	_, err = softDelete.Delete(ctx, param)
+	assert.NoError(t, err)
+
+	versioned := softDelete.WithVersionColumn("PRODUCT")
+	mockMaster.On("ExecContext", mock.Anything, "UPDATE PRODUCT SET IS_DELETED=:1,UPDATED_DATE=:2,VERSION=VERSION+1 WHERE ID = :3", mock.Anything).
+		Return(mockResult{rowsAffected: 1}, nil).Once()
+	_, err = versioned.Restore(ctx, param)
+	assert.NoError(t, err, "a restore bumps the version, an update based on the one read before conflicts")
+	mockMaster.AssertExpectations(t)
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eapache/go-resiliency/retrier"
+	"github.com/godror/godror"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+)
+
+const (
+	DEFAULT_RETRY_BACKOFF     = 100 * time.Millisecond
+	DEFAULT_RETRY_MAX_BACKOFF = 2 * time.Second
+	DEFAULT_RETRY_JITTER      = 0.25
+)
+
+// DEFAULT_TRANSIENT_CODES are the ORA codes of a lost connection, a failover or an instance restart
+var DEFAULT_TRANSIENT_CODES = []int{
+	1033,  // ORACLE initialization or shutdown in progress
+	1034,  // ORACLE not available
+	1089,  // immediate shutdown in progress
+	1092,  // ORACLE instance terminated
+	3113,  // end-of-file on communication channel
+	3114,  // not connected to ORACLE
+	3135,  // connection lost contact
+	12170, // TNS: connect timeout occurred
+	12514, // TNS: listener does not currently know of service
+	12528, // TNS: all appropriate instances are blocking new connections
+	12537, // TNS: connection closed
+	12541, // TNS: no listener
+	12543, // TNS: destination host unreachable
+	12571, // TNS: packet writer failure
+	25401, // can not continue fetches
+	25402, // transaction must roll back
+	25408, // can not safely replay call
+}
+
+var oraCodeRegex = regexp.MustCompile(`ORA-(\d{5})`)
+
+// RetryPolicy retries operations failing with a transient Oracle error, see WithRetry.
+// Reads are retried outside a transaction. Writes are not: one whose connection is lost may have been
+// committed, so only those marked with WithIdempotentWrites are retried, never an INSERT or array DML.
+// A transaction is replayed as a whole when started with Replayable.
+type RetryPolicy struct {
+	// MaxRetries after the first attempt, 0 disables retrying
+	MaxRetries int
+	// Backoff before the first retry, doubled up to MaxBackoff for the next ones
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter randomizes each backoff by up to this factor, between 0 and 1
+	Jitter float64
+	// TransientCodes are the ORA codes retried, DEFAULT_TRANSIENT_CODES when empty.
+	// Errors without a code are transient when the driver reports a bad connection.
+	TransientCodes []int
+}
+
+// retryer runs operations with the retrier built from a RetryPolicy
+type retryer struct {
+	policy  RetryPolicy
+	retrier *retrier.Retrier
+}
+
+// WithRetry returns a copy of r retrying its operations as policy says. A policy without retries disables it.
+func (r BaseRepository) WithRetry(policy RetryPolicy) BaseRepository {
+	if policy.MaxRetries <= 0 {
+		r.retry = nil
+		return r
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = DEFAULT_RETRY_BACKOFF
+	}
+	if policy.MaxBackoff < policy.Backoff {
+		policy.MaxBackoff = max(policy.Backoff, DEFAULT_RETRY_MAX_BACKOFF)
+	}
+	if len(policy.TransientCodes) == 0 {
+		policy.TransientCodes = DEFAULT_TRANSIENT_CODES
+	}
+
+	retry := retrier.New(retrier.LimitedExponentialBackoff(policy.MaxRetries, policy.Backoff, policy.MaxBackoff), policy)
+	retry.SetJitter(policy.Jitter)
+	r.retry = &retryer{policy: policy, retrier: retry}
+	return r
+}
+
+// OracleErrorCode returns the ORA code of err, 0 when it has none
+func OracleErrorCode(err error) int {
+	if oraErr, ok := godror.AsOraErr(err); ok {
+		return oraErr.Code()
+	}
+	if err == nil {
+		return 0
+	}
+	if match := oraCodeRegex.FindStringSubmatch(err.Error()); match != nil {
+		code, _ := strconv.Atoi(match[1])
+		return code
+	}
+	return 0
+}
+
+// IsTransient reports whether err is worth retrying: a transient ORA code or a bad connection.
+// Cancellations and timeouts of the context are never transient.
+func (p RetryPolicy) IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if code := OracleErrorCode(err); code != 0 {
+		codes := p.TransientCodes
+		if len(codes) == 0 {
+			codes = DEFAULT_TRANSIENT_CODES
+		}
+		return slices.Contains(codes, code)
+	}
+	return errors.Is(err, driver.ErrBadConn) || godror.IsBadConn(err)
+}
+
+// Classify implements retrier.Classifier, counting the class of every error but sql.ErrNoRows
+func (p RetryPolicy) Classify(err error) retrier.Action {
+	if err == nil {
+		return retrier.Succeed
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return retrier.Fail
+	}
+
+	code := oraCodeLabel(err)
+	if p.IsTransient(err) {
+		database.RecordErrorClass(code, database.ERROR_TRANSIENT)
+		return retrier.Retry
+	}
+	database.RecordErrorClass(code, database.ERROR_PERMANENT)
+	return retrier.Fail
+}
+
+// retrying runs op, retrying it on transient errors when r has a retry policy. Nothing is retried
+// inside a transaction: its connection is gone with the error, only the whole transaction can be replayed.
+// Every attempt is observed in the query metrics.
+func (r *BaseRepository) retrying(ctx context.Context, operation, target string, op func(ctx context.Context) error) error {
+	attempt := func(ctx context.Context) error {
+		return observe(ctx, operation, target, op)
+	}
+	if r.retry == nil {
+		return attempt(ctx)
+	}
+	if _, ok := GetTxConnInContext(ctx); ok {
+		return attempt(ctx)
+	}
+	return r.retry.run(ctx, operation, target, attempt)
+}
+
+// retryingWrite runs the write query like retrying when ctx marks its writes idempotent, see
+// WithIdempotentWrites, and only once otherwise. An INSERT or array DML is always run once.
+func (r *BaseRepository) retryingWrite(ctx context.Context, operation, target, query string, args []interface{}, op func(ctx context.Context) error) error {
+	idempotent, _ := ctx.Value(idempotentWritesKey{}).(bool)
+	if !idempotent || isInsert(query) || hasArrayBinds(args) {
+		return observe(ctx, operation, target, op)
+	}
+	return r.retrying(ctx, operation, target, op)
+}
+
+type idempotentWritesKey struct{}
+
+// WithIdempotentWrites returns ctx whose writes the retry policy may run again, see WithRetry. Only mark
+// writes applying the same result when run twice, e.g. an UPDATE by key setting fixed values or an upsert.
+// INSERTs and array DML are never retried, whatever ctx says.
+func WithIdempotentWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentWritesKey{}, true)
+}
+
+func isInsert(query string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "INSERT")
+}
+
+// hasArrayBinds reports whether args bind slices, which godror runs as array DML
+func hasArrayBinds(args []interface{}) bool {
+	for _, arg := range args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			arg = named.Value
+		}
+		if _, ok := arg.([]byte); ok {
+			continue
+		}
+		if arg != nil && reflect.TypeOf(arg).Kind() == reflect.Slice {
+			return true
+		}
+	}
+	return false
+}
+
+func (rt *retryer) run(ctx context.Context, operation, target string, op func(ctx context.Context) error) error {
+	err := rt.retrier.RunFn(ctx, func(ctx context.Context, retries int) error {
+		if retries > 0 {
+			database.RecordRetry(operation, target)
+			slog.WarnContext(ctx, fmt.Sprintf("retrying %s on %s, retry %d of %d", operation, target, retries, rt.policy.MaxRetries))
+		}
+		return op(ctx)
+	})
+	if rt.policy.IsTransient(err) {
+		database.RecordRetriesExhausted(operation, target)
+	}
+	return err
+}
+
+// truncateOnRetry returns a func restoring the slice dest points to at its current length,
+// so a retried select does not keep the rows scanned by the failed attempt
+func truncateOnRetry(dest interface{}) func() {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Slice {
+		return func() {}
+	}
+	slice := v.Elem()
+	length := slice.Len()
+	return func() {
+		slice.SetLen(length)
+	}
+}
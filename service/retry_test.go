@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eapache/go-resiliency/retrier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+var (
+	errLostConnection = errors.New("ORA-03113: end-of-file on communication channel")
+	errUniqueKey      = errors.New("ORA-00001: unique constraint (MEMBER_APP.MEMBER_PK) violated")
+)
+
+var fastRetry = service.RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func TestOracleErrorCode(t *testing.T) {
+	assert.Equal(t, 3113, service.OracleErrorCode(errLostConnection))
+	assert.Equal(t, 25408, service.OracleErrorCode(errors.New("dpiStmt_execute: ORA-25408: can not safely replay call")))
+	assert.Equal(t, 0, service.OracleErrorCode(sql.ErrNoRows))
+	assert.Equal(t, 0, service.OracleErrorCode(nil))
+}
+
+func TestRetryPolicy_Classify(t *testing.T) {
+	policy := service.RetryPolicy{TransientCodes: []int{1}}
+
+	assert.Equal(t, retrier.Succeed, fastRetry.Classify(nil))
+	assert.Equal(t, retrier.Retry, fastRetry.Classify(errLostConnection))
+	assert.Equal(t, retrier.Fail, fastRetry.Classify(errUniqueKey))
+	assert.Equal(t, retrier.Fail, fastRetry.Classify(sql.ErrNoRows))
+	assert.Equal(t, retrier.Fail, fastRetry.Classify(context.DeadlineExceeded))
+	assert.Equal(t, retrier.Retry, policy.Classify(errUniqueKey), "configured codes replace the defaults")
+	assert.Equal(t, retrier.Fail, policy.Classify(errLostConnection))
+}
+
+func TestRetry_ReadRetriedOnTransientError(t *testing.T) {
+	mockSlave := new(MockSlaveDB)
+	repo := service.BaseRepository{SlaveDB: mockSlave}.WithRetry(fastRetry)
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, "SELECT ID FROM MEMBER", mock.Anything).
+		Run(func(args mock.Arguments) {
+			dest := args.Get(1).(*[]int64)
+			*dest = append(*dest, 1)
+		}).Return(errLostConnection).Once()
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, "SELECT ID FROM MEMBER", mock.Anything).
+		Run(func(args mock.Arguments) {
+			dest := args.Get(1).(*[]int64)
+			*dest = append(*dest, 1, 2)
+		}).Return(nil).Once()
+
+	var ids []int64
+	err := repo.SelectOperations(context.Background(), &ids, "SELECT ID FROM MEMBER")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids, "rows of the failed attempt are dropped")
+	mockSlave.AssertNumberOfCalls(t, "SelectContext", 2)
+}
+
+func TestRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}.WithRetry(fastRetry)
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, errLostConnection)
+
+	_, err := repo.Update(service.WithIdempotentWrites(context.Background()), service.SqlParameter{
+		TableName: "MEMBER",
+		Values:    []service.Value{{Field: "NAME", Value: "John"}},
+	})
+
+	assert.ErrorIs(t, err, errLostConnection)
+	mockMaster.AssertNumberOfCalls(t, "ExecContext", 3)
+}
+
+func TestRetry_WriteNotRetriedByDefault(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}.WithRetry(fastRetry)
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, errLostConnection)
+
+	_, err := repo.Update(context.Background(), service.SqlParameter{
+		TableName: "MEMBER",
+		Values:    []service.Value{{Field: "NAME", Value: "John"}},
+	})
+
+	assert.ErrorIs(t, err, errLostConnection)
+	mockMaster.AssertNumberOfCalls(t, "ExecContext", 1)
+}
+
+func TestRetry_InsertAndArrayDMLNeverRetried(t *testing.T) {
+	ctx := service.WithIdempotentWrites(context.Background())
+
+	t.Run("insert", func(t *testing.T) {
+		mockMaster := new(MockMasterDB)
+		repo := service.BaseRepository{MasterDB: mockMaster}.WithRetry(fastRetry)
+		mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, errLostConnection)
+
+		_, err := repo.Insert(ctx, insertParam("John"))
+
+		assert.ErrorIs(t, err, errLostConnection)
+		mockMaster.AssertNumberOfCalls(t, "ExecContext", 1)
+	})
+
+	t.Run("array dml", func(t *testing.T) {
+		mockMaster := new(MockMasterDB)
+		repo := service.BaseRepository{MasterDB: mockMaster}.WithRetry(fastRetry)
+		mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, errLostConnection)
+
+		_, err := repo.BulkUpdate(ctx, bulkRows(2))
+
+		assert.Error(t, err)
+		mockMaster.AssertNumberOfCalls(t, "ExecContext", 1)
+	})
+}
+
+func TestRetry_PermanentErrorNotRetried(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}.WithRetry(fastRetry)
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, errUniqueKey)
+
+	_, err := repo.Insert(context.Background(), insertParam("John"))
+
+	assert.ErrorIs(t, err, errUniqueKey)
+	mockMaster.AssertNumberOfCalls(t, "ExecContext", 1)
+}
+
+func TestRetry_NotRetriedInsideTransaction(t *testing.T) {
+	master, fdb := newFakeMasterDB(t)
+	repo := service.BaseRepository{MasterDB: master}.WithRetry(fastRetry)
+	fdb.execErr["INSERT"] = errLostConnection
+
+	err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, insertParam("John"))
+		return err
+	})
+
+	assert.ErrorIs(t, err, errLostConnection)
+	assert.Equal(t, []string{"BEGIN", "INSERT INTO MEMBER (NAME) VALUES (:1)", "ROLLBACK"}, fdb.statements())
+}
+
+func TestRetry_ReplayableTransactionReplayed(t *testing.T) {
+	master, fdb := newFakeMasterDB(t)
+	repo := service.BaseRepository{MasterDB: master}.WithRetry(fastRetry)
+	fdb.execErr["INSERT"] = errLostConnection
+	attempts := 0
+
+	err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+		attempts++
+		if attempts == 3 {
+			delete(fdb.execErr, "INSERT")
+		}
+		_, err := repo.Insert(ctx, insertParam("John"))
+		return err
+	}, service.Replayable())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, "COMMIT", fdb.statements()[len(fdb.statements())-1])
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+)
+
+// ReadRouting decides when the reads of a BaseRepository leave SlaveDB for MasterDB, see WithReadRouting.
+// Reads of a ReadSession that wrote recently always go to MasterDB.
+type ReadRouting struct {
+	// Fallback reads from MasterDB when SlaveDB is unhealthy or a read on it fails
+	Fallback bool
+	// SlaveHealthy reports whether SlaveDB can serve reads. By default SlaveDB is unhealthy when it
+	// is an oracle.AvailableDB not available, e.g. its circuit breaker is open or every replica is ejected.
+	SlaveHealthy func() bool
+}
+
+// WithReadRouting returns a copy of r routing its reads as routing says
+func (r BaseRepository) WithReadRouting(routing ReadRouting) BaseRepository {
+	r.routing = &routing
+	return r
+}
+
+// reader is implemented by both MasterDB and SlaveDB
+type reader interface {
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// read runs op on SlaveDB, or on MasterDB when the session of ctx wrote recently or SlaveDB is unhealthy.
+// A read failing on SlaveDB runs again on MasterDB when the routing falls back; sql.ErrNoRows and
+// errors of an ended ctx are returned as is. dest is truncated before each attempt, see truncateOnRetry.
+func (r *BaseRepository) read(ctx context.Context, operation string, dest interface{}, op func(ctx context.Context, db reader) error) error {
+	reset := truncateOnRetry(dest)
+	attempt := func(target string, db reader) error {
+		return r.retrying(ctx, operation, target, func(ctx context.Context) error {
+			reset()
+			return op(ctx, db)
+		})
+	}
+
+	if reason := r.masterReadReason(ctx); reason != "" {
+		slog.InfoContext(ctx, fmt.Sprintf("%s reads from master: %s", operation, reason))
+		database.RecordReadRoute(operation, database.TARGET_MASTER, reason)
+		return attempt(database.TARGET_MASTER, r.MasterDB)
+	}
+
+	database.RecordReadRoute(operation, database.TARGET_SLAVE, database.ROUTE_REPLICA)
+	err := attempt(database.TARGET_SLAVE, r.SlaveDB)
+	if err == nil || r.routing == nil || !r.routing.Fallback || errors.Is(err, sql.ErrNoRows) || ctx.Err() != nil {
+		return err
+	}
+
+	slog.WarnContext(ctx, fmt.Sprintf("%s falls back to master after slave error: %v", operation, err))
+	database.RecordReadRoute(operation, database.TARGET_MASTER, database.ROUTE_FALLBACK)
+	return attempt(database.TARGET_MASTER, r.MasterDB)
+}
+
+// masterReadReason returns why a read of ctx goes to MasterDB, empty when SlaveDB serves it
+func (r *BaseRepository) masterReadReason(ctx context.Context) string {
+	if session, ok := ReadSessionFromContext(ctx); ok && session.ReadsMaster() {
+		return database.ROUTE_READ_YOUR_WRITES
+	}
+	if r.routing != nil && r.routing.Fallback && !r.slaveHealthy() {
+		return database.ROUTE_UNHEALTHY
+	}
+	return ""
+}
+
+func (r *BaseRepository) slaveHealthy() bool {
+	if r.routing.SlaveHealthy != nil {
+		return r.routing.SlaveHealthy()
+	}
+	if db, ok := r.SlaveDB.(oracle.AvailableDB); ok {
+		return db.Available()
+	}
+	return true
+}
+
+type readSessionKey struct{}
+
+// ReadSession gives a client read-your-writes: its reads go to MasterDB until a deadline carried
+// from its previous requests, and for the rest of the request once it wrote.
+type ReadSession struct {
+	mu          sync.Mutex
+	masterUntil time.Time
+	wrote       bool
+}
+
+// WithReadSession returns ctx carrying a new ReadSession reading from MasterDB until masterUntil.
+// Every write made with the returned context marks the session, see ReadSession.Wrote.
+func WithReadSession(ctx context.Context, masterUntil time.Time) (context.Context, *ReadSession) {
+	session := &ReadSession{masterUntil: masterUntil}
+	return context.WithValue(ctx, readSessionKey{}, session), session
+}
+
+// ReadSessionFromContext returns the ReadSession set by WithReadSession
+func ReadSessionFromContext(ctx context.Context) (*ReadSession, bool) {
+	session, ok := ctx.Value(readSessionKey{}).(*ReadSession)
+	return session, ok
+}
+
+// ReadsMaster reports whether the reads of s go to MasterDB
+func (s *ReadSession) ReadsMaster() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wrote || time.Now().Before(s.masterUntil)
+}
+
+// MarkWrite records a write of s
+func (s *ReadSession) MarkWrite() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wrote = true
+}
+
+// Wrote reports whether a write was made with s
+func (s *ReadSession) Wrote() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wrote
+}
+
+// markWrite marks the ReadSession of ctx, if any
+func markWrite(ctx context.Context) {
+	if session, ok := ReadSessionFromContext(ctx); ok {
+		session.MarkWrite()
+	}
+}
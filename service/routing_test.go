@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+const selectIDs = "SELECT ID FROM MEMBER"
+
+func appendIDs(ids ...int64) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		dest := args.Get(1).(*[]int64)
+		*dest = append(*dest, ids...)
+	}
+}
+
+func TestRouting_ReadsSlave(t *testing.T) {
+	mockMaster, mockSlave := new(MockMasterDB), new(MockSlaveDB)
+	repo := service.BaseRepository{MasterDB: mockMaster, SlaveDB: mockSlave}.WithReadRouting(service.ReadRouting{Fallback: true})
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Run(appendIDs(1)).Return(nil)
+
+	var ids []int64
+	err := repo.SelectOperations(context.Background(), &ids, selectIDs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, ids)
+	mockMaster.AssertNotCalled(t, "SelectContext")
+}
+
+func TestRouting_FallsBackToMaster(t *testing.T) {
+	mockMaster, mockSlave := new(MockMasterDB), new(MockSlaveDB)
+	repo := service.BaseRepository{MasterDB: mockMaster, SlaveDB: mockSlave}.WithReadRouting(service.ReadRouting{Fallback: true})
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Run(appendIDs(1)).Return(errLostConnection)
+	mockMaster.On("SelectContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Run(appendIDs(1, 2)).Return(nil)
+
+	var ids []int64
+	err := repo.SelectOperations(context.Background(), &ids, selectIDs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids, "rows of the failed slave read are dropped")
+}
+
+func TestRouting_NoFallback(t *testing.T) {
+	mockMaster, mockSlave := new(MockMasterDB), new(MockSlaveDB)
+	mockSlave.On("GetContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Return(errLostConnection)
+	var id int64
+
+	repo := service.BaseRepository{MasterDB: mockMaster, SlaveDB: mockSlave}
+	assert.ErrorIs(t, repo.GetOperations(context.Background(), &id, selectIDs), errLostConnection, "no routing")
+
+	repo = repo.WithReadRouting(service.ReadRouting{Fallback: true})
+	mockSlave.ExpectedCalls = nil
+	mockSlave.On("GetContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Return(sql.ErrNoRows)
+	assert.ErrorIs(t, repo.GetOperations(context.Background(), &id, selectIDs), sql.ErrNoRows, "not found is an answer")
+
+	mockMaster.AssertNotCalled(t, "GetContext")
+}
+
+func TestRouting_UnhealthySlaveSkipped(t *testing.T) {
+	mockMaster, mockSlave := new(MockMasterDB), new(MockSlaveDB)
+	breaker := oracle.NewBreaker("slave", oracle.BreakerConfig{FailureRate: 0.5, MinRequests: 1})
+	_ = breaker.Run(func() error { return driver.ErrBadConn })
+	repo := service.BaseRepository{MasterDB: mockMaster, SlaveDB: oracle.WithSlaveBreaker(mockSlave, breaker)}.
+		WithReadRouting(service.ReadRouting{Fallback: true})
+	mockMaster.On("GetContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Return(nil)
+
+	var id int64
+	err := repo.GetOperations(context.Background(), &id, selectIDs)
+
+	assert.NoError(t, err)
+	mockSlave.AssertNotCalled(t, "GetContext")
+}
+
+func TestRouting_ReadYourWrites(t *testing.T) {
+	mockMaster, mockSlave := new(MockMasterDB), new(MockSlaveDB)
+	repo := service.BaseRepository{MasterDB: mockMaster, SlaveDB: mockSlave}
+	mockMaster.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(mockResult{}, nil)
+	mockMaster.On("GetContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Return(nil)
+	var id int64
+
+	ctx, session := service.WithReadSession(context.Background(), time.Time{})
+	assert.False(t, session.ReadsMaster())
+	_, err := repo.Insert(ctx, insertParam("John"))
+	assert.NoError(t, err)
+	assert.True(t, session.Wrote())
+	assert.NoError(t, repo.GetOperations(ctx, &id, selectIDs), "read after a write of the request")
+
+	ctx, _ = service.WithReadSession(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, repo.GetOperations(ctx, &id, selectIDs), "read within the window of a previous write")
+
+	mockSlave.AssertNotCalled(t, "GetContext")
+	mockMaster.AssertNumberOfCalls(t, "GetContext", 2)
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+// ErrInvalidIdentifier is returned when a SqlParameter names an identifier its repository did not register, see Schema
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// kinds of identifier rejected by a Schema
+const (
+	IDENTIFIER_TABLE          = "table"
+	IDENTIFIER_ALIAS          = "alias"
+	IDENTIFIER_COLUMN         = "column"
+	IDENTIFIER_JSON_PATH      = "json path"
+	IDENTIFIER_EXPRESSION     = "expression"
+	IDENTIFIER_OPERAND        = "operand"
+	IDENTIFIER_JOIN_TYPE      = "join type"
+	IDENTIFIER_SORT_DIRECTION = "sort direction"
+)
+
+// IdentifierError is the identifier rejected before any SQL is built. It matches ErrInvalidIdentifier.
+type IdentifierError struct {
+	Kind       string
+	Identifier string
+}
+
+func (e *IdentifierError) Error() string {
+	return fmt.Sprintf("%v: %s %q is not allowed", ErrInvalidIdentifier, e.Kind, e.Identifier)
+}
+
+func (e *IdentifierError) Unwrap() error {
+	return ErrInvalidIdentifier
+}
+
+var (
+	identifierRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*$`)
+	columnRefRegex  = regexp.MustCompile(`^(?:([A-Za-z][A-Za-z0-9_$#]*)\.)?([A-Za-z][A-Za-z0-9_$#]*)$`)
+	jsonRegex       = regexp.MustCompile(`^(?i:JSON_VALUE|JSON_QUERY)\(\s*([A-Za-z0-9_$#.]+)\s*,\s*'([^']*)'\s*\)$`)
+	andRegex        = regexp.MustCompile(`(?i)\s+AND\s+`)
+	countAll        = "COUNT(*)"
+
+	operands = map[string]bool{
+		constants.EQUAL: true, constants.NOT_EQUAL: true, "!=": true,
+		constants.LESS_THAN: true, constants.LESS_THAN_EQUAL: true,
+		constants.GREATER_THAN: true, constants.GREATER_THAN_EQUAL: true,
+		constants.LIKE: true, "NOT LIKE": true, constants.IS_NULL: true,
+		constants.IN: true, constants.NOT_IN: true, constants.REVERSE_IN: true,
+		constants.MULTIPLE_LIKE: true, constants.MULTIPLE_EQUAL: true, constants.IN_LIKE_STRING: true,
+		constants.CONTAINS: true, constants.NOT_CONTAINS: true,
+	}
+	joinTypes = map[string]bool{
+		"": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true,
+		"LEFT OUTER": true, "RIGHT OUTER": true, "FULL OUTER": true,
+	}
+)
+
+// Schema lists the tables, columns, JSON paths and expressions a repository allows in its statements.
+// Identifiers are matched case-insensitively like unquoted Oracle identifiers, JSON paths exactly.
+// Values are always bound, so only the SQL text written from a SqlParameter is checked.
+type Schema struct {
+	tables map[string]*TableSchema
+}
+
+// TableSchema is a table registered in a Schema
+type TableSchema struct {
+	columns     map[string]bool
+	jsonPaths   map[string]map[string]bool
+	expressions map[string]bool
+}
+
+// NewSchema returns an empty Schema, which allows nothing
+func NewSchema() *Schema {
+	return &Schema{tables: map[string]*TableSchema{}}
+}
+
+// Table registers table with columns and returns it to register more. A table registered twice is extended.
+func (s *Schema) Table(name string, columns ...string) *TableSchema {
+	key := strings.ToUpper(name)
+	table, ok := s.tables[key]
+	if !ok {
+		table = &TableSchema{
+			columns:     map[string]bool{},
+			jsonPaths:   map[string]map[string]bool{},
+			expressions: map[string]bool{},
+		}
+		s.tables[key] = table
+	}
+	return table.Columns(columns...)
+}
+
+// Columns allows columns of the table
+func (t *TableSchema) Columns(columns ...string) *TableSchema {
+	for _, column := range columns {
+		t.columns[strings.ToUpper(column)] = true
+	}
+	return t
+}
+
+// JSONPaths allows the JSON paths of column in JSON_VALUE, JSON_QUERY and CONTAINS filters, e.g. $.address
+func (t *TableSchema) JSONPaths(column string, paths ...string) *TableSchema {
+	t.Columns(column)
+	key := strings.ToUpper(column)
+	if t.jsonPaths[key] == nil {
+		t.jsonPaths[key] = map[string]bool{}
+	}
+	for _, path := range paths {
+		t.jsonPaths[key][path] = true
+	}
+	return t
+}
+
+// Expressions allows raw expressions as written by the repository, e.g. a HAVING condition or a join ON
+// that is not an equality of columns. Whitespace and case are not significant.
+func (t *TableSchema) Expressions(expressions ...string) *TableSchema {
+	for _, expression := range expressions {
+		t.expressions[normalizeExpression(expression)] = true
+	}
+	return t
+}
+
+// merge returns a copy of s extended with the tables of other
+func (s *Schema) merge(other *Schema) *Schema {
+	merged := NewSchema()
+	for _, schema := range []*Schema{s, other} {
+		if schema == nil {
+			continue
+		}
+		for key, table := range schema.tables {
+			into := merged.Table(key)
+			maps.Copy(into.columns, table.columns)
+			maps.Copy(into.expressions, table.expressions)
+			for column, paths := range table.jsonPaths {
+				into.JSONPaths(column, slices.Collect(maps.Keys(paths))...)
+			}
+		}
+	}
+	return merged
+}
+
+// WithSchema returns a copy of r rejecting with an IdentifierError any SqlParameter naming an identifier
+// that schema, or a schema r already has, does not register. Without a schema nothing is checked.
+func (r BaseRepository) WithSchema(schema *Schema) BaseRepository {
+	r.schema = r.schema.merge(schema)
+	return r
+}
+
+// Validate checks the identifiers of p against the schema of r, see WithSchema
+func (r *BaseRepository) Validate(p SqlParameter) error {
+	if r.schema == nil {
+		return nil
+	}
+	return r.schema.Validate(p)
+}
+
+// ValidateSortDirection returns direction as asc or desc, an IdentifierError for anything else
+func ValidateSortDirection(direction string) (string, error) {
+	switch {
+	case strings.EqualFold(direction, constants.ASC):
+		return constants.ASC, nil
+	case strings.EqualFold(direction, constants.DESC):
+		return constants.DESC, nil
+	}
+	return "", &IdentifierError{Kind: IDENTIFIER_SORT_DIRECTION, Identifier: direction}
+}
+
+// Validate returns an IdentifierError for the first identifier of p the schema does not allow. Columns
+// must belong to the table or a join they are qualified with, or to any of them when unqualified.
+func (s *Schema) Validate(p SqlParameter) error {
+	scope, err := s.scope(p)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range p.Columns {
+		if err := scope.selected(column); err != nil {
+			return err
+		}
+	}
+	if p.SelectField != "" {
+		for _, column := range strings.Split(strings.TrimPrefix(strings.TrimSpace(p.SelectField), constants.COMMA), constants.COMMA) {
+			if err := scope.selected(column); err != nil {
+				return err
+			}
+		}
+	}
+	for _, value := range p.Values {
+		if _, _, err := scope.column(value.Field); err != nil {
+			return err
+		}
+	}
+	for _, join := range p.Joins {
+		if err := scope.filters(join.Conditions); err != nil {
+			return err
+		}
+	}
+	if err := scope.filters(p.Params); err != nil {
+		return err
+	}
+	if p.Where != nil {
+		if err := scope.condition(*p.Where); err != nil {
+			return err
+		}
+	}
+	for _, group := range p.GroupBy {
+		if err := scope.expression(group); err != nil {
+			return err
+		}
+	}
+	for _, having := range p.Having {
+		if !scope.registered(having) {
+			return &IdentifierError{Kind: IDENTIFIER_EXPRESSION, Identifier: having}
+		}
+	}
+	for _, order := range p.OrderBy {
+		if err := scope.orderBy(order); err != nil {
+			return err
+		}
+	}
+	if p.Keyset != nil {
+		for _, column := range []string{p.Keyset.SortColumn, p.Keyset.IDColumn} {
+			if column == "" {
+				continue
+			}
+			if err := scope.expression(column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// identifierScope is what the identifiers of one statement resolve against:
+// its table and joins, by name and by alias
+type identifierScope struct {
+	tables map[string]*TableSchema
+}
+
+func (s *Schema) scope(p SqlParameter) (*identifierScope, error) {
+	scope := &identifierScope{tables: map[string]*TableSchema{}}
+	if err := scope.add(s, p.TableName, ""); err != nil {
+		return nil, err
+	}
+	for _, join := range p.Joins {
+		if !joinTypes[strings.ToUpper(strings.Join(strings.Fields(join.JoinType), " "))] {
+			return nil, &IdentifierError{Kind: IDENTIFIER_JOIN_TYPE, Identifier: join.JoinType}
+		}
+		if err := scope.add(s, join.Table, join.Alias); err != nil {
+			return nil, err
+		}
+	}
+	for _, join := range p.Joins {
+		if err := scope.on(join.On); err != nil {
+			return nil, err
+		}
+	}
+	return scope, nil
+}
+
+// add resolves table, written "NAME" or "NAME alias", and its alias
+func (sc *identifierScope) add(s *Schema, table, alias string) error {
+	fields := strings.Fields(table)
+	if len(fields) == 0 || len(fields) > 2 {
+		return &IdentifierError{Kind: IDENTIFIER_TABLE, Identifier: table}
+	}
+	registered, ok := s.tables[strings.ToUpper(fields[0])]
+	if !ok {
+		return &IdentifierError{Kind: IDENTIFIER_TABLE, Identifier: fields[0]}
+	}
+	sc.tables[strings.ToUpper(fields[0])] = registered
+	if len(fields) == 2 {
+		alias = fields[1]
+	}
+	if alias != "" {
+		if !identifierRegex.MatchString(alias) {
+			return &IdentifierError{Kind: IDENTIFIER_ALIAS, Identifier: alias}
+		}
+		sc.tables[strings.ToUpper(alias)] = registered
+	}
+	return nil
+}
+
+// on allows a registered expression or equalities of columns joined with AND
+func (sc *identifierScope) on(on string) error {
+	if sc.registered(on) {
+		return nil
+	}
+	for _, equality := range andRegex.Split(strings.TrimSpace(on), -1) {
+		columns := strings.Split(equality, constants.EQUAL)
+		if len(columns) != 2 {
+			return &IdentifierError{Kind: IDENTIFIER_EXPRESSION, Identifier: on}
+		}
+		for _, column := range columns {
+			if _, _, err := sc.column(column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// column resolves a column written COLUMN or qualifier.COLUMN
+func (sc *identifierScope) column(ref string) (*TableSchema, string, error) {
+	match := columnRefRegex.FindStringSubmatch(strings.TrimSpace(ref))
+	if match == nil {
+		return nil, "", &IdentifierError{Kind: IDENTIFIER_COLUMN, Identifier: ref}
+	}
+	qualifier, column := strings.ToUpper(match[1]), strings.ToUpper(match[2])
+	if qualifier != "" {
+		table, ok := sc.tables[qualifier]
+		if !ok {
+			return nil, "", &IdentifierError{Kind: IDENTIFIER_ALIAS, Identifier: match[1]}
+		}
+		if !table.columns[column] {
+			return nil, "", &IdentifierError{Kind: IDENTIFIER_COLUMN, Identifier: ref}
+		}
+		return table, column, nil
+	}
+	for _, table := range sc.tables {
+		if table.columns[column] {
+			return table, column, nil
+		}
+	}
+	return nil, "", &IdentifierError{Kind: IDENTIFIER_COLUMN, Identifier: ref}
+}
+
+// jsonPath checks path is registered for column
+func (sc *identifierScope) jsonPath(column, path string) error {
+	table, key, err := sc.column(column)
+	if err != nil {
+		return err
+	}
+	if !table.jsonPaths[key][path] {
+		return &IdentifierError{Kind: IDENTIFIER_JSON_PATH, Identifier: path}
+	}
+	return nil
+}
+
+// expression allows a registered expression, a column, COUNT(*) and JSON_VALUE / JSON_QUERY of a registered path
+func (sc *identifierScope) expression(expression string) error {
+	expression = strings.TrimSpace(expression)
+	if sc.registered(expression) || strings.EqualFold(expression, countAll) {
+		return nil
+	}
+	if match := jsonRegex.FindStringSubmatch(expression); match != nil {
+		return sc.jsonPath(match[1], match[2])
+	}
+	if columnRefRegex.MatchString(expression) {
+		_, _, err := sc.column(expression)
+		return err
+	}
+	return &IdentifierError{Kind: IDENTIFIER_EXPRESSION, Identifier: expression}
+}
+
+// selected allows an expression optionally followed by a column alias, e.g. COUNT(*) as count
+func (sc *identifierScope) selected(column string) error {
+	column = strings.TrimSpace(column)
+	err := sc.expression(column)
+	if err == nil {
+		return nil
+	}
+	fields := strings.Fields(column)
+	if len(fields) < 2 || !identifierRegex.MatchString(fields[len(fields)-1]) {
+		return err
+	}
+	expression := strings.TrimSpace(strings.TrimSuffix(column, fields[len(fields)-1]))
+	if len(fields) > 2 && strings.EqualFold(fields[len(fields)-2], "AS") {
+		expression = strings.TrimSpace(expression[:len(expression)-2])
+	}
+	if sc.expression(expression) != nil {
+		return err
+	}
+	return nil
+}
+
+// orderBy allows an expression optionally followed by asc or desc
+func (sc *identifierScope) orderBy(order string) error {
+	order = strings.TrimSpace(order)
+	expression := order
+	if i := strings.LastIndexAny(order, " \t"); i >= 0 && !strings.HasSuffix(order, ")") {
+		expression = strings.TrimSpace(order[:i])
+		if _, err := ValidateSortDirection(order[i+1:]); err != nil {
+			return err
+		}
+	}
+	return sc.expression(expression)
+}
+
+func (sc *identifierScope) filters(filters []FilterParam) error {
+	for _, filter := range filters {
+		if err := sc.filter(filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *identifierScope) condition(c Condition) error {
+	if c.Filter != nil {
+		return sc.filter(*c.Filter)
+	}
+	for _, child := range c.Conditions {
+		if err := sc.condition(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filter checks the operand and the field of filter the way Binds.filter writes them
+func (sc *identifierScope) filter(filter FilterParam) error {
+	if filter.Operand != "" && !operands[filter.Operand] {
+		return &IdentifierError{Kind: IDENTIFIER_OPERAND, Identifier: filter.Operand}
+	}
+	switch filter.Operand {
+	case constants.REVERSE_IN, constants.MULTIPLE_LIKE, constants.MULTIPLE_EQUAL:
+		for _, field := range strings.Split(filter.Field, constants.COMMA) {
+			if err := sc.expression(field); err != nil {
+				return err
+			}
+		}
+		return nil
+	case constants.CONTAINS, constants.NOT_CONTAINS:
+		if filter.Path != "" {
+			return sc.jsonPath(filter.Field, filter.Path)
+		}
+	}
+	return sc.expression(filter.Field)
+}
+
+// registered reports whether expression was registered on a table of the statement
+func (sc *identifierScope) registered(expression string) bool {
+	normalized := normalizeExpression(expression)
+	for _, table := range sc.tables {
+		if table.expressions[normalized] {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeExpression(expression string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(expression), " "))
+}
@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+func memberSchema() *service.Schema {
+	schema := service.NewSchema()
+	schema.Table("MEMBER", "ID", "NAME", "INFO", "POLICY", "CREATED_DATE").
+		JSONPaths("INFO", "$.age").
+		JSONPaths("POLICY", "$.dataCategories").
+		Expressions("COUNT(m.ID) > 1")
+	schema.Table("MEMBER_ORDER", "MEMBER_ID", "STATUS", "CODE")
+	return schema
+}
+
+func TestSchema_ValidateAllowsRegistered(t *testing.T) {
+	param := combinedSelect()
+	param.Columns = append(param.Columns, constants.COUNT_COL, "JSON_VALUE(m.INFO, '$.age') AS age")
+	param.GroupBy = []string{"m.ID"}
+	param.Having = []string{"count(m.id)  > 1"}
+	param.Keyset = &service.Keyset{SortColumn: "m.NAME", IDColumn: "m.ID"}
+
+	assert.NoError(t, memberSchema().Validate(param))
+}
+
+func TestSchema_ValidateRejectsUnregistered(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *service.SqlParameter)
+		want   service.IdentifierError
+	}{
+		{"table", func(p *service.SqlParameter) { p.TableName = "USERS u" },
+			service.IdentifierError{Kind: service.IDENTIFIER_TABLE, Identifier: "USERS"}},
+		{"table subquery", func(p *service.SqlParameter) { p.TableName = "(SELECT * FROM USERS) m" },
+			service.IdentifierError{Kind: service.IDENTIFIER_TABLE, Identifier: "(SELECT * FROM USERS) m"}},
+		{"column", func(p *service.SqlParameter) { p.Columns = []string{"m.PASSWORD"} },
+			service.IdentifierError{Kind: service.IDENTIFIER_COLUMN, Identifier: "m.PASSWORD"}},
+		{"column of other table", func(p *service.SqlParameter) { p.Columns = []string{"m.STATUS"} },
+			service.IdentifierError{Kind: service.IDENTIFIER_COLUMN, Identifier: "m.STATUS"}},
+		{"alias", func(p *service.SqlParameter) { p.Columns = []string{"x.ID"} },
+			service.IdentifierError{Kind: service.IDENTIFIER_ALIAS, Identifier: "x"}},
+		{"json path", func(p *service.SqlParameter) {
+			p.Params = []service.FilterParam{service.MakeFilterParam("JSON_VALUE(m.INFO, '$.password')", constants.EQUAL, 1)}
+		}, service.IdentifierError{Kind: service.IDENTIFIER_JSON_PATH, Identifier: "$.password"}},
+		{"contains path", func(p *service.SqlParameter) {
+			p.Params = []service.FilterParam{{Field: "m.POLICY", Operand: constants.CONTAINS, Value: "x", Path: "$.owners"}}
+		}, service.IdentifierError{Kind: service.IDENTIFIER_JSON_PATH, Identifier: "$.owners"}},
+		{"expression", func(p *service.SqlParameter) {
+			p.Params = []service.FilterParam{service.MakeFilterParam("1=1 OR m.ID", constants.EQUAL, 1)}
+		}, service.IdentifierError{Kind: service.IDENTIFIER_EXPRESSION, Identifier: "1=1 OR m.ID"}},
+		{"operand", func(p *service.SqlParameter) {
+			p.Params = []service.FilterParam{service.MakeFilterParam("m.ID", "= 1 OR 1 =", 1)}
+		}, service.IdentifierError{Kind: service.IDENTIFIER_OPERAND, Identifier: "= 1 OR 1 ="}},
+		{"nested condition", func(p *service.SqlParameter) {
+			where := service.Not(service.MakeCondition("m.SECRET", constants.EQUAL, 1))
+			p.Where = &where
+		}, service.IdentifierError{Kind: service.IDENTIFIER_COLUMN, Identifier: "m.SECRET"}},
+		{"sort direction", func(p *service.SqlParameter) { p.OrderBy = []string{"m.ID DESC--"} },
+			service.IdentifierError{Kind: service.IDENTIFIER_SORT_DIRECTION, Identifier: "DESC--"}},
+		{"order by subquery", func(p *service.SqlParameter) { p.OrderBy = []string{"m.ID asc, (SELECT 1 FROM dual)"} },
+			service.IdentifierError{Kind: service.IDENTIFIER_EXPRESSION, Identifier: "m.ID asc, (SELECT 1 FROM dual)"}},
+		{"order by", func(p *service.SqlParameter) { p.OrderBy = []string{"m.PASSWORD desc"} },
+			service.IdentifierError{Kind: service.IDENTIFIER_COLUMN, Identifier: "m.PASSWORD"}},
+		{"group by", func(p *service.SqlParameter) { p.GroupBy = []string{"m.ID, m.PASSWORD"} },
+			service.IdentifierError{Kind: service.IDENTIFIER_EXPRESSION, Identifier: "m.ID, m.PASSWORD"}},
+		{"having", func(p *service.SqlParameter) { p.Having = []string{"COUNT(*) > 0"} },
+			service.IdentifierError{Kind: service.IDENTIFIER_EXPRESSION, Identifier: "COUNT(*) > 0"}},
+		{"join on", func(p *service.SqlParameter) { p.Joins[0].On = "o.MEMBER_ID = m.ID OR 1 = 1" },
+			service.IdentifierError{Kind: service.IDENTIFIER_EXPRESSION, Identifier: "o.MEMBER_ID = m.ID OR 1 = 1"}},
+		{"join type", func(p *service.SqlParameter) { p.Joins[0].JoinType = "LEFT OUTER JOIN USERS ON 1 = 1 LEFT" },
+			service.IdentifierError{Kind: service.IDENTIFIER_JOIN_TYPE, Identifier: "LEFT OUTER JOIN USERS ON 1 = 1 LEFT"}},
+		{"value", func(p *service.SqlParameter) { p.Values = []service.Value{{Field: "NAME=NULL, PASSWORD", Value: 1}} },
+			service.IdentifierError{Kind: service.IDENTIFIER_COLUMN, Identifier: "NAME=NULL, PASSWORD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := combinedSelect()
+			tt.modify(&param)
+
+			err := memberSchema().Validate(param)
+
+			assert.ErrorIs(t, err, service.ErrInvalidIdentifier)
+			assert.Equal(t, &tt.want, err)
+		})
+	}
+}
+
+func TestValidateSortDirection(t *testing.T) {
+	direction, err := service.ValidateSortDirection("DESC")
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DESC, direction)
+
+	_, err = service.ValidateSortDirection("asc; DROP TABLE MEMBER")
+	assert.ErrorIs(t, err, service.ErrInvalidIdentifier)
+}
+
+func TestBuildQuerySelect_RejectsBeforeGenerating(t *testing.T) {
+	repo := service.BaseRepository{}.WithSchema(memberSchema())
+	param := combinedSelect()
+	param.OrderBy = []string{"m.ID asc NULLS FIRST"}
+
+	query, args, err := repo.BuildQuerySelect("", param)
+
+	assert.ErrorIs(t, err, service.ErrInvalidIdentifier)
+	assert.Empty(t, query)
+	assert.Nil(t, args)
+}
+
+func TestRepository_RejectsColumnOutsideEntity(t *testing.T) {
+	repo, mockMaster, mockSlave := setupProductRepo()
+
+	_, err := repo.FindAll(context.Background(), service.SqlParameter{
+		Params: []service.FilterParam{service.MakeFilterParam("PASSWORD", constants.EQUAL, "x")},
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidIdentifier)
+
+	_, err = repo.Update(context.Background(), service.SqlParameter{
+		TableName: "PRODUCT",
+		Values:    []service.Value{{Field: "CODE = 'x' --", Value: "A"}},
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidIdentifier)
+
+	mockSlave.AssertNotCalled(t, "SelectContext")
+	mockMaster.AssertNotCalled(t, "ExecContext")
+}
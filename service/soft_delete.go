@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+const (
+	IS_DELETED_COLUMN   = "IS_DELETED"
+	UPDATED_DATE_COLUMN = "UPDATED_DATE"
+	CREATED_DATE_COLUMN = "CREATED_DATE"
+	DELETED_FLAG        = "1"
+	NOT_DELETED_FLAG    = "0"
+)
+
+// DeletedScope tells reads which rows to return with regard to IS_DELETED
+type DeletedScope int
+
+const (
+	// ExcludeDeleted is the default of soft-deletable tables, soft-deleted rows are not returned
+	ExcludeDeleted DeletedScope = iota
+	// IncludeDeleted returns rows whether they are deleted or not
+	IncludeDeleted
+	// OnlyDeleted returns soft-deleted rows only
+	OnlyDeleted
+)
+
+// WithDeleted returns a copy of p which also reads soft-deleted rows
+func (p SqlParameter) WithDeleted() SqlParameter {
+	p.Deleted = IncludeDeleted
+	return p
+}
+
+// OnlyDeleted returns a copy of p which reads soft-deleted rows only
+func (p SqlParameter) OnlyDeleted() SqlParameter {
+	p.Deleted = OnlyDeleted
+	return p
+}
+
+// deletedColumn returns IS_DELETED qualified with the table alias, e.g. "MEMBER m" gives m.IS_DELETED
+func (p SqlParameter) deletedColumn() string {
+	fields := strings.Fields(p.TableName)
+	if len(fields) == 2 {
+		return fields[1] + "." + IS_DELETED_COLUMN
+	}
+	return IS_DELETED_COLUMN
+}
+
+// WithSoftDelete returns a copy of r scoping the reads of tables by IS_DELETED, see SqlParameter.Deleted.
+// Reads of the other tables, which may not have the column, are not scoped.
+func (r BaseRepository) WithSoftDelete(tables ...string) BaseRepository {
+	softDeletes := maps.Clone(r.softDeletes)
+	if softDeletes == nil {
+		softDeletes = map[string]bool{}
+	}
+	for _, table := range tables {
+		softDeletes[tableKey(table)] = true
+	}
+	r.softDeletes = softDeletes
+	return r
+}
+
+// isSoftDeletable reports whether table, which may be given with an alias, was registered with WithSoftDelete
+func (r *BaseRepository) isSoftDeletable(table string) bool {
+	return r.softDeletes[tableKey(table)]
+}
+
+// deletedFilter returns the IS_DELETED condition of the read scope of p, none when its table is not
+// soft-deletable. Rows with a NULL flag are not deleted.
+func (r *BaseRepository) deletedFilter(p SqlParameter) (FilterParam, bool) {
+	if !r.isSoftDeletable(p.TableName) {
+		return FilterParam{}, false
+	}
+	switch p.Deleted {
+	case IncludeDeleted:
+		return FilterParam{}, false
+	case OnlyDeleted:
+		return MakeFilterParam(p.deletedColumn(), constants.EQUAL, DELETED_FLAG), true
+	default:
+		return MakeFilterParam(fmt.Sprintf("NVL(%s, '%s')", p.deletedColumn(), NOT_DELETED_FLAG), constants.EQUAL, NOT_DELETED_FLAG), true
+	}
+}
+
+// Delete soft-deletes the rows matching sqlParameter.Params by setting IS_DELETED and UPDATED_DATE.
+// The rows of a table not registered with WithSoftDelete, which may not have these columns, are removed
+// by HardDelete.
+func (r *BaseRepository) Delete(ctx context.Context, sqlParameter SqlParameter) (int64, error) {
+	if !r.isSoftDeletable(sqlParameter.TableName) {
+		return r.HardDelete(ctx, sqlParameter)
+	}
+	return r.setDeletedFlag(ctx, sqlParameter, DELETED_FLAG, constants.ACTION_DELETE)
+}
+
+// Restore reverts the soft delete of the rows matching sqlParameter.Params
+func (r *BaseRepository) Restore(ctx context.Context, sqlParameter SqlParameter) (int64, error) {
+	return r.setDeletedFlag(ctx, sqlParameter.WithDeleted(), NOT_DELETED_FLAG, constants.ACTION_UPDATE)
+}
+
+// HardDelete removes the rows matching sqlParameter.Params with a DELETE statement
+func (r *BaseRepository) HardDelete(ctx context.Context, sqlParameter SqlParameter) (int64, error) {
+	if err := r.Validate(sqlParameter); err != nil {
+		return 0, err
+	}
+	return r.audited(ctx, sqlParameter, constants.ACTION_DELETE, func(ctx context.Context) (int64, error) {
+		sql := fmt.Sprintf("DELETE FROM %s", sqlParameter.TableName)
+		conditional, args := r.GenerateConditional(sqlParameter)
+		sql += conditional
+		return r.WriteOrUpdateOperation(ctx, sql, nil, args...)
+	})
+}
+
+// setDeletedFlag updates IS_DELETED, and VERSION of the tables registered with WithVersionColumn.
+// action is what audited tables record the change as.
+func (r *BaseRepository) setDeletedFlag(ctx context.Context, sqlParameter SqlParameter, flag, action string) (int64, error) {
+	sqlParameter.Values = []Value{
+		{Field: IS_DELETED_COLUMN, Value: flag},
+		{Field: UPDATED_DATE_COLUMN, Value: time.Now()},
+	}
+	sqlParameter.incrementVersion = r.versioned[tableKey(sqlParameter.TableName)]
+	return r.update(ctx, sqlParameter, action)
+}
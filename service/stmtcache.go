@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+)
+
+// txQuerier runs statements in a transaction, binding the cached statements of MasterDB to it with
+// tx.Stmtx when MasterDB has a statement cache, see oracle.WithMasterStatementCache
+type txQuerier struct {
+	tx    *sqlx.Tx
+	cache oracle.StatementCacheDB
+}
+
+// inTx returns the txQuerier of txConn, the transaction stored in the context
+func (r *BaseRepository) inTx(txConn interface{}) txQuerier {
+	cache, _ := oracle.AsStatementCache(r.MasterDB)
+	return txQuerier{tx: txConn.(*sqlx.Tx), cache: cache}
+}
+
+func (q txQuerier) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if q.cache == nil {
+		return q.tx.SelectContext(ctx, dest, query, args...)
+	}
+	stmt, done, err := q.cache.TxStmtx(ctx, q.tx, query)
+	if err != nil {
+		return err
+	}
+	defer done()
+	return stmt.SelectContext(ctx, dest, args...)
+}
+
+func (q txQuerier) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if q.cache == nil {
+		return q.tx.GetContext(ctx, dest, query, args...)
+	}
+	stmt, done, err := q.cache.TxStmtx(ctx, q.tx, query)
+	if err != nil {
+		return err
+	}
+	defer done()
+	return stmt.GetContext(ctx, dest, args...)
+}
+
+func (q txQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if q.cache == nil {
+		return q.tx.ExecContext(ctx, query, args...)
+	}
+	stmt, done, err := q.cache.TxStmtx(ctx, q.tx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return stmt.ExecContext(ctx, args...)
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	oracle "oracle.com/oracle/my-go-oracle-app/infra/database/sql"
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+const findByIdQuery = "SELECT ID FROM MEMBER WHERE ID = :1"
+
+func TestStatementCache_Reads(t *testing.T) {
+	slave, fdb := newFakeSlaveDB(t)
+	fdb.columns = []string{"ID"}
+	fdb.rows = [][]driver.Value{{int64(7)}}
+	repo := service.BaseRepository{SlaveDB: oracle.WithSlaveStatementCache(slave, "slave", 10)}
+
+	for range 3 {
+		var id int64
+		require.NoError(t, repo.GetOperations(context.Background(), &id, findByIdQuery, 7))
+		assert.Equal(t, int64(7), id)
+	}
+
+	assert.Equal(t, []string{findByIdQuery}, fdb.preparations())
+	assert.Len(t, fdb.statements(), 6, "3 queries and their cursors closed")
+}
+
+func TestStatementCache_Transaction(t *testing.T) {
+	master, fdb := newFakeMasterDB(t)
+	fdb.columns = []string{"ID"}
+	fdb.rows = [][]driver.Value{{int64(7)}}
+	repo := service.BaseRepository{MasterDB: oracle.WithMasterStatementCache(master, "master", 10)}
+
+	update := "UPDATE MEMBER SET NAME = :1 WHERE ID = :2"
+	for range 2 {
+		err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+			var id int64
+			if err := repo.GetOperations(ctx, &id, findByIdQuery, 7); err != nil {
+				return err
+			}
+			_, err := repo.WriteOrUpdateOperation2(ctx, update, "John", id)
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"BEGIN", findByIdQuery, "CLOSE CURSOR", update, "COMMIT"}, fdb.statements()[:5])
+	// the first transaction holds the connection the pool would prepare on, so each statement is prepared on
+	// another connection, then on the one of the transaction, where the second transaction reuses it
+	assert.Equal(t, []string{findByIdQuery, findByIdQuery, update, update}, fdb.preparations())
+}
+
+// TestStatementCache_TransactionConnections binds a cached statement in transactions: it is prepared again only
+// on a connection it was not prepared on yet
+func TestStatementCache_TransactionConnections(t *testing.T) {
+	db, fdb := newFakeDB(t)
+	repo := service.BaseRepository{MasterDB: oracle.WithMasterStatementCache(oracle.NewMasterDB(db, "godror"), "master", 10)}
+	update := "UPDATE MEMBER SET NAME = :1 WHERE ID = :2"
+	inTx := func() {
+		err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+			_, err := repo.WriteOrUpdateOperation2(ctx, update, "John", 7)
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := repo.WriteOrUpdateOperation2(context.Background(), update, "John", 7)
+	require.NoError(t, err)
+	assert.Len(t, fdb.preparations(), 1, "cached on the only connection")
+
+	inTx()
+	assert.Len(t, fdb.preparations(), 1, "the transaction runs on that connection")
+
+	busy, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	inTx()
+	assert.Len(t, fdb.preparations(), 2, "prepared once on the new connection of the transaction")
+	require.NoError(t, busy.Close())
+
+	for range 3 {
+		inTx()
+	}
+	assert.Equal(t, []string{update, update}, fdb.preparations(), "prepared on both connections already")
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"iter"
+	"reflect"
+	"time"
+
+	"github.com/godror/godror"
+	"github.com/jmoiron/sqlx"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+const (
+	DEFAULT_STREAM_PREFETCH_COUNT   = 500
+	DEFAULT_STREAM_FETCH_ARRAY_SIZE = 500
+)
+
+// StreamOption tunes how godror fetches rows while streaming
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	prefetchCount  int
+	fetchArraySize int
+}
+
+// WithPrefetchCount sets how many rows Oracle returns along with the execute round-trip, 0 keeps the driver default
+func WithPrefetchCount(rows int) StreamOption {
+	return func(o *streamOptions) {
+		o.prefetchCount = rows
+	}
+}
+
+// WithFetchArraySize sets how many rows are fetched per round-trip after the first one
+func WithFetchArraySize(rows int) StreamOption {
+	return func(o *streamOptions) {
+		o.fetchArraySize = rows
+	}
+}
+
+// StreamOperations runs query and yields the rows one by one, positioned on the current row for the
+// caller to scan. Unlike SelectOperations the result set is never held in memory.
+// The cursor is closed when the loop ends, the caller breaks out of it or ctx is cancelled;
+// a cancellation is yielded as the last error.
+// Outside a transaction streams always read SlaveDB, whatever the ReadRouting of r.
+func (r *BaseRepository) StreamOperations(ctx context.Context, query string, args []interface{}, opts ...StreamOption) iter.Seq2[*sqlx.Rows, error] {
+	options := streamOptions{
+		prefetchCount:  DEFAULT_STREAM_PREFETCH_COUNT,
+		fetchArraySize: DEFAULT_STREAM_FETCH_ARRAY_SIZE,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(yield func(*sqlx.Rows, error) bool) {
+		ctx := logQuery(ctx, query, args)
+		operation := GetLastFuncCallerName()
+		ctx, cancel := r.withTimeout(ctx, OPERATION_EXPORT)
+		defer cancel()
+
+		queryArgs := append([]interface{}{}, args...)
+		if options.prefetchCount > 0 {
+			queryArgs = append(queryArgs, godror.PrefetchCount(options.prefetchCount))
+		}
+		if options.fetchArraySize > 0 {
+			queryArgs = append(queryArgs, godror.FetchArraySize(options.fetchArraySize))
+		}
+
+		var (
+			rows *sqlx.Rows
+			err  error
+		)
+		txConn := ctx.Value(constants.CONTEXT_TRANSACTION)
+		if txConn == nil {
+			err = r.retrying(ctx, operation, database.TARGET_SLAVE, func(ctx context.Context) (err error) {
+				rows, err = r.SlaveDB.QueryxContext(ctx, query, queryArgs...)
+				return err
+			})
+		} else {
+			err = observe(ctx, operation, database.TARGET_TX, func(ctx context.Context) (err error) {
+				rows, err = txConn.(*sqlx.Tx).QueryxContext(ctx, query, queryArgs...)
+				return err
+			})
+		}
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			// database/sql closes the rows of a cancelled context asynchronously, stop right away instead
+			if err := ctx.Err(); err != nil {
+				yield(nil, timeoutError(ctx, operation, err))
+				return
+			}
+			if !yield(rows, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(nil, timeoutError(ctx, operation, err))
+		}
+	}
+}
+
+// Stream runs query and yields each row scanned into T: a struct is mapped by its db tags,
+// any other type must be a single column. See StreamOperations for cursor handling.
+func Stream[T any](ctx context.Context, r *BaseRepository, query string, args []interface{}, opts ...StreamOption) iter.Seq2[T, error] {
+	structScan := isStructScan[T]()
+	return func(yield func(T, error) bool) {
+		for rows, err := range r.StreamOperations(ctx, query, args, opts...) {
+			var entity T
+			if err == nil {
+				if structScan {
+					err = rows.StructScan(&entity)
+				} else {
+					err = rows.Scan(&entity)
+				}
+			}
+			if !yield(entity, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// StreamWithParameter streams the rows selected by param, see Stream.
+func StreamWithParameter[T any](ctx context.Context, r *BaseRepository, param SqlParameter, opts ...StreamOption) iter.Seq2[T, error] {
+	query, args, err := r.BuildQuerySelect("", param)
+	if err != nil {
+		return func(yield func(T, error) bool) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+	return Stream[T](ctx, r, query, args, opts...)
+}
+
+// Stream streams the rows matching param without loading them in memory, see BaseRepository.StreamOperations.
+func (r *Repository[T]) Stream(ctx context.Context, param SqlParameter, opts ...StreamOption) iter.Seq2[T, error] {
+	return StreamWithParameter[T](ctx, &r.BaseRepository, r.withDefaults(param), opts...)
+}
+
+// isStructScan reports whether T is scanned field by field rather than as a single column
+func isStructScan[T any]() bool {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct || t == reflect.TypeFor[time.Time]() {
+		return false
+	}
+	_, scanner := any(new(T)).(sql.Scanner)
+	return !scanner
+}
@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/godror/godror"
+	"github.com/stretchr/testify/assert"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+type streamedRow struct {
+	Id   int64  `db:"ID"`
+	Name string `db:"NAME"`
+}
+
+func setupStreamRepo(t *testing.T) (*service.BaseRepository, *fakeDB) {
+	slave, fdb := newFakeSlaveDB(t)
+	fdb.columns = []string{"ID", "NAME"}
+	fdb.rows = [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}, {int64(3), "c"}}
+	return &service.BaseRepository{SlaveDB: slave}, fdb
+}
+
+func TestStream_AllRows(t *testing.T) {
+	repo, fdb := setupStreamRepo(t)
+
+	var names []string
+	for row, err := range service.Stream[streamedRow](context.Background(), repo, "SELECT ID,NAME FROM MEMBER", nil,
+		service.WithPrefetchCount(10), service.WithFetchArraySize(20)) {
+		assert.NoError(t, err)
+		names = append(names, row.Name)
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+	assert.Equal(t, []string{"SELECT ID,NAME FROM MEMBER", "CLOSE CURSOR"}, fdb.statements())
+	assert.Len(t, fdb.args[0], 2)
+	assert.IsType(t, godror.Option(nil), fdb.args[0][0].Value)
+	assert.IsType(t, godror.Option(nil), fdb.args[0][1].Value)
+}
+
+func TestStream_EarlyStopClosesCursor(t *testing.T) {
+	repo, fdb := setupStreamRepo(t)
+	fdb.columns = []string{"ID"}
+	fdb.rows = [][]driver.Value{{int64(1)}, {int64(2)}}
+
+	var ids []int64
+	for id, err := range service.Stream[int64](context.Background(), repo, "SELECT ID FROM MEMBER", nil) {
+		assert.NoError(t, err)
+		ids = append(ids, id)
+		if len(ids) == 1 {
+			break
+		}
+	}
+
+	assert.Equal(t, []int64{1}, ids)
+	assert.Equal(t, []string{"SELECT ID FROM MEMBER", "CLOSE CURSOR"}, fdb.statements())
+}
+
+func TestStream_ContextCancelled(t *testing.T) {
+	repo, fdb := setupStreamRepo(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lastErr error
+	count := 0
+	for _, err := range service.Stream[streamedRow](ctx, repo, "SELECT ID,NAME FROM MEMBER", nil) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		count++
+		cancel()
+	}
+
+	assert.ErrorIs(t, lastErr, context.Canceled)
+	assert.Equal(t, 1, count)
+	assert.Contains(t, fdb.statements(), "CLOSE CURSOR")
+}
+
+func TestRepository_Stream(t *testing.T) {
+	slave, fdb := newFakeSlaveDB(t)
+	fdb.columns = []string{"CODE", "PRICE"}
+	fdb.rows = [][]driver.Value{{"A1", int64(10)}}
+	repo := service.NewRepository[product](service.BaseRepository{SlaveDB: slave})
+
+	var codes []string
+	for p, err := range repo.Stream(context.Background(), service.SqlParameter{Columns: []string{"CODE", "PRICE"}}) {
+		assert.NoError(t, err)
+		codes = append(codes, p.Code)
+	}
+
+	assert.Equal(t, []string{"A1"}, codes)
+	assert.Equal(t, "SELECT CODE,PRICE FROM PRODUCT WHERE NVL(IS_DELETED, '0') = :1", fdb.statements()[0])
+}
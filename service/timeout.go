@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// classes of an operation, each with its own timeout, see Timeouts
+const (
+	OPERATION_READ   = "read"
+	OPERATION_WRITE  = "write"
+	OPERATION_COUNT  = "count"
+	OPERATION_EXPORT = "export"
+)
+
+// ORA-01013: user requested cancel of current operation, reported by godror when the context of a call ends
+const oraUserCancel = 1013
+
+// Timeouts bounds each operation of a BaseRepository, see WithTimeouts. An operation gets the timeout of its
+// class, or the one set with WithOperationTimeout, cut to the remaining request deadline minus Margin.
+// A zero timeout leaves the operations of a class bounded by the request deadline only.
+type Timeouts struct {
+	Read   time.Duration
+	Write  time.Duration
+	Count  time.Duration
+	Export time.Duration
+	// Margin of the request deadline kept for rendering the response
+	Margin time.Duration
+}
+
+// WithTimeouts returns a copy of r bounding its operations as timeouts says
+func (r BaseRepository) WithTimeouts(timeouts Timeouts) BaseRepository {
+	r.timeouts = &timeouts
+	return r
+}
+
+func (t *Timeouts) of(class string) time.Duration {
+	switch class {
+	case OPERATION_WRITE:
+		return t.Write
+	case OPERATION_COUNT:
+		return t.Count
+	case OPERATION_EXPORT:
+		return t.Export
+	default:
+		return t.Read
+	}
+}
+
+// QueryTimeoutError is returned by an operation cancelled by its timeout or by the request deadline,
+// Oracle reporting ORA-01013. It is rendered as 504.
+type QueryTimeoutError struct {
+	Operation string
+	// Timeout given to the operation
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *QueryTimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %v: %v", e.Operation, e.Timeout, e.Err)
+}
+
+func (e *QueryTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// IsTimeout always returns true, see response.TimeoutError
+func (e *QueryTimeoutError) IsTimeout() bool {
+	return true
+}
+
+// IsTimeout reports whether err is a QueryTimeoutError
+func IsTimeout(err error) bool {
+	var timeout *QueryTimeoutError
+	return errors.As(err, &timeout)
+}
+
+type operationTimeoutKey struct{}
+
+type operationTimeout struct {
+	class   string
+	timeout time.Duration
+	// applied is set once an operation is bounded, the operations it runs keep its deadline
+	applied bool
+}
+
+// WithOperationClass returns ctx whose operations are bounded by the timeout of class, e.g. a repository
+// method counting rows with GetOperations sets OPERATION_COUNT
+func WithOperationClass(ctx context.Context, class string) context.Context {
+	t, _ := ctx.Value(operationTimeoutKey{}).(operationTimeout)
+	t.class = class
+	return context.WithValue(ctx, operationTimeoutKey{}, t)
+}
+
+// WithOperationTimeout returns ctx whose operations are bounded by timeout instead of the timeout of their
+// class, for a repository method needing more or less time than the others. It is still cut to the request deadline.
+func WithOperationTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	t, _ := ctx.Value(operationTimeoutKey{}).(operationTimeout)
+	t.timeout = timeout
+	return context.WithValue(ctx, operationTimeoutKey{}, t)
+}
+
+// withTimeout returns ctx bounded for an operation of class, see Timeouts.
+// An operation run by another one, e.g. a snapshot taken by Update, keeps the deadline of the latter.
+func (r *BaseRepository) withTimeout(ctx context.Context, class string) (context.Context, context.CancelFunc) {
+	t, _ := ctx.Value(operationTimeoutKey{}).(operationTimeout)
+	if t.applied || (r.timeouts == nil && t.timeout <= 0) {
+		return ctx, func() {}
+	}
+	if t.class != "" {
+		class = t.class
+	}
+
+	timeout, margin := t.timeout, time.Duration(0)
+	if r.timeouts != nil {
+		if timeout <= 0 {
+			timeout = r.timeouts.of(class)
+		}
+		margin = r.timeouts.Margin
+	}
+
+	now := time.Now()
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = now.Add(timeout)
+	}
+	if requestDeadline, ok := ctx.Deadline(); ok {
+		if budget := requestDeadline.Add(-margin); deadline.IsZero() || budget.Before(deadline) {
+			deadline = budget
+		}
+	}
+
+	t.applied = true
+	if deadline.IsZero() {
+		return context.WithValue(ctx, operationTimeoutKey{}, t), func() {}
+	}
+	t.timeout = max(deadline.Sub(now), 0)
+	return context.WithDeadline(context.WithValue(ctx, operationTimeoutKey{}, t), deadline)
+}
+
+// timeoutError returns err as a QueryTimeoutError when operation was cancelled by the deadline of ctx:
+// Oracle reports ORA-01013 and database/sql context.DeadlineExceeded. A client going away is not a timeout.
+func timeoutError(ctx context.Context, operation string, err error) error {
+	if err == nil || IsTimeout(err) || errors.Is(ctx.Err(), context.Canceled) {
+		return err
+	}
+	if !errors.Is(err, context.DeadlineExceeded) && OracleErrorCode(err) != oraUserCancel {
+		return err
+	}
+	t, _ := ctx.Value(operationTimeoutKey{}).(operationTimeout)
+	return &QueryTimeoutError{Operation: operation, Timeout: t.timeout, Err: err}
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+var errUserCancel = errors.New("ORA-01013: user requested cancel of current operation")
+
+var testTimeouts = service.Timeouts{Read: time.Second, Write: 2 * time.Second, Count: 3 * time.Second, Margin: 500 * time.Millisecond}
+
+// readDeadline returns the deadline of the read of repo, zero when it has none
+func readDeadline(t *testing.T, ctx context.Context, repo service.BaseRepository) time.Time {
+	mockSlave := new(MockSlaveDB)
+	var deadline time.Time
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Run(func(args mock.Arguments) {
+		deadline, _ = args.Get(0).(context.Context).Deadline()
+	}).Return(nil)
+	repo.SlaveDB = mockSlave
+
+	var ids []int64
+	assert.NoError(t, repo.SelectOperations(ctx, &ids, selectIDs))
+	return deadline
+}
+
+func TestTimeouts_ByClass(t *testing.T) {
+	repo := service.BaseRepository{}.WithTimeouts(testTimeouts)
+
+	assert.WithinDuration(t, time.Now().Add(time.Second), readDeadline(t, context.Background(), repo), 100*time.Millisecond)
+
+	ctx := service.WithOperationClass(context.Background(), service.OPERATION_COUNT)
+	assert.WithinDuration(t, time.Now().Add(3*time.Second), readDeadline(t, ctx, repo), 100*time.Millisecond)
+}
+
+func TestTimeouts_Override(t *testing.T) {
+	repo := service.BaseRepository{}.WithTimeouts(testTimeouts)
+	ctx := service.WithOperationTimeout(context.Background(), time.Minute)
+
+	assert.WithinDuration(t, time.Now().Add(time.Minute), readDeadline(t, ctx, repo), 100*time.Millisecond)
+	assert.True(t, readDeadline(t, context.Background(), service.BaseRepository{}).IsZero(), "no timeouts")
+}
+
+func TestTimeouts_RequestDeadline(t *testing.T) {
+	repo := service.BaseRepository{}.WithTimeouts(service.Timeouts{Read: time.Minute, Margin: 500 * time.Millisecond})
+	requestDeadline := time.Now().Add(2 * time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), requestDeadline)
+	defer cancel()
+
+	assert.Equal(t, requestDeadline.Add(-500*time.Millisecond), readDeadline(t, ctx, repo), "margin kept for rendering")
+}
+
+func TestTimeouts_BudgetExhausted(t *testing.T) {
+	mockSlave := new(MockSlaveDB)
+	mockSlave.On("SelectContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Return(context.DeadlineExceeded)
+	repo := service.BaseRepository{SlaveDB: mockSlave}.WithTimeouts(testTimeouts)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var ids []int64
+	err := repo.SelectOperations(ctx, &ids, selectIDs)
+
+	var timeout *service.QueryTimeoutError
+	assert.ErrorAs(t, err, &timeout)
+	assert.Equal(t, time.Duration(0), timeout.Timeout, "less than the margin left")
+}
+
+func TestTimeouts_OracleCancel(t *testing.T) {
+	mockSlave := new(MockSlaveDB)
+	mockSlave.On("GetContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Return(errUserCancel)
+	repo := service.BaseRepository{SlaveDB: mockSlave}.WithTimeouts(testTimeouts)
+
+	var id int64
+	err := repo.GetOperations(context.Background(), &id, selectIDs)
+
+	var timeout *service.QueryTimeoutError
+	assert.ErrorAs(t, err, &timeout)
+	assert.ErrorIs(t, err, errUserCancel)
+	assert.Equal(t, "service_test.TestTimeouts_OracleCancel", timeout.Operation)
+	assert.Equal(t, time.Second, timeout.Timeout.Round(time.Second))
+}
+
+func TestTimeouts_ClientGone(t *testing.T) {
+	mockSlave := new(MockSlaveDB)
+	mockSlave.On("GetContext", mock.Anything, mock.Anything, selectIDs, mock.Anything).Return(errUserCancel)
+	repo := service.BaseRepository{SlaveDB: mockSlave}.WithTimeouts(testTimeouts)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var id int64
+	err := repo.GetOperations(ctx, &id, selectIDs)
+
+	assert.ErrorIs(t, err, errUserCancel)
+	assert.False(t, service.IsTimeout(err))
+}
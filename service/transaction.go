@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"oracle.com/oracle/my-go-oracle-app/infra/database"
+)
+
+const (
+	savepointQuery         = "SAVEPOINT %s"
+	rollbackSavepointQuery = "ROLLBACK TO SAVEPOINT %s"
+	savepointPrefix        = "SP_"
+)
+
+type txDepthKey struct{}
+
+// Transactor runs a unit of work. BaseRepository implements it, so services can
+// depend on this interface instead of a concrete repository.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error
+}
+
+// TxOption configures the transaction started by WithTransaction
+type TxOption func(*txOptions)
+
+type txOptions struct {
+	sql.TxOptions
+	replayable bool
+}
+
+// WithIsolation sets the isolation level. Oracle supports sql.LevelReadCommitted and sql.LevelSerializable.
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *txOptions) {
+		o.Isolation = level
+	}
+}
+
+// ReadOnly starts the transaction with SET TRANSACTION READ ONLY
+func ReadOnly() TxOption {
+	return func(o *txOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// Replayable lets the retry policy of the repository, see WithRetry, run the whole transaction again when
+// it fails with a transient error. fn must be safe to replay: a commit lost with the connection may have
+// been applied already, so it should only do writes that converge, e.g. upserts or versioned updates.
+func Replayable() TxOption {
+	return func(o *txOptions) {
+		o.replayable = true
+	}
+}
+
+// WithTransaction runs fn inside a transaction placed in the context passed to fn,
+// so every BaseRepository operation called with that context joins it.
+// The transaction is committed when fn returns nil and rolled back when it returns an error or panics.
+//
+// When ctx already carries a transaction, a SAVEPOINT is issued instead and an error from fn
+// only rolls back to that savepoint; the outer unit of work decides whether to commit.
+// Options are ignored for nested calls because they can only be set when the transaction begins.
+func (r *BaseRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) (err error) {
+	if _, ok := GetTxConnInContext(ctx); ok {
+		return r.withSavepoint(ctx, fn)
+	}
+
+	txOpts := &txOptions{}
+	for _, opt := range opts {
+		opt(txOpts)
+	}
+
+	operation := GetLastFuncCallerName()
+	if txOpts.replayable && r.retry != nil {
+		return r.retry.run(ctx, operation, database.TARGET_TX, func(ctx context.Context) error {
+			return r.transaction(ctx, operation, fn, &txOpts.TxOptions)
+		})
+	}
+	return r.transaction(ctx, operation, fn, &txOpts.TxOptions)
+}
+
+// transaction runs fn in a new transaction, see WithTransaction. The commit is observed as operation.
+func (r *BaseRepository) transaction(ctx context.Context, operation string, fn func(ctx context.Context) error, txOpts *sql.TxOptions) (err error) {
+	tx, err := r.MasterDB.BeginTxx(ctx, txOpts)
+	if err != nil {
+		return err
+	}
+
+	txCtx := context.WithValue(SetTxConnInContext(ctx, tx), txDepthKey{}, 0)
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				slog.ErrorContext(ctx, fmt.Sprintf("rollback after panic failed: %v", rbErr))
+			}
+			panic(p)
+		}
+	}()
+
+	err = fn(txCtx)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			slog.ErrorContext(ctx, fmt.Sprintf("tx err: %v, rb err: %v", err, rbErr))
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return observe(ctx, operation, database.TARGET_TX, func(context.Context) error {
+		return tx.Commit()
+	})
+}
+
+// withSavepoint runs fn inside a savepoint of the transaction already carried by ctx
+func (r *BaseRepository) withSavepoint(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, _ := GetTxConnInContext(ctx)
+	depth, _ := ctx.Value(txDepthKey{}).(int)
+	depth++
+	savepoint := fmt.Sprintf("%s%d", savepointPrefix, depth)
+
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf(savepointQuery, savepoint)); err != nil {
+		return err
+	}
+
+	rollback := func() error {
+		_, rbErr := tx.ExecContext(ctx, fmt.Sprintf(rollbackSavepointQuery, savepoint))
+		return rbErr
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := rollback(); rbErr != nil {
+				slog.ErrorContext(ctx, fmt.Sprintf("rollback to savepoint %s after panic failed: %v", savepoint, rbErr))
+			}
+			panic(p)
+		}
+	}()
+
+	err = fn(context.WithValue(ctx, txDepthKey{}, depth))
+	if err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			slog.ErrorContext(ctx, fmt.Sprintf("savepoint %s err: %v, rb err: %v", savepoint, err, rbErr))
+			return fmt.Errorf("%w (rollback to savepoint %s failed: %v)", err, savepoint, rbErr)
+		}
+		return err
+	}
+
+	return nil
+}
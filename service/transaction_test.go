@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+func setupTxRepo(t *testing.T) (*service.BaseRepository, *fakeDB) {
+	master, fdb := newFakeMasterDB(t)
+	return &service.BaseRepository{MasterDB: master}, fdb
+}
+
+func insertParam(name string) service.SqlParameter {
+	return service.SqlParameter{
+		TableName: "MEMBER",
+		Values:    []service.Value{{Field: "NAME", Value: name}},
+	}
+}
+
+func TestWithTransaction_Commit(t *testing.T) {
+	repo, fdb := setupTxRepo(t)
+
+	err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+		_, ok := service.GetTxConnInContext(ctx)
+		assert.True(t, ok)
+		if _, err := repo.Insert(ctx, insertParam("a")); err != nil {
+			return err
+		}
+		_, err := repo.Insert(ctx, insertParam("b"))
+		return err
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"INSERT INTO MEMBER (NAME) VALUES (:1)",
+		"INSERT INTO MEMBER (NAME) VALUES (:1)",
+		"COMMIT",
+	}, fdb.statements())
+}
+
+func TestWithTransaction_RollbackOnError(t *testing.T) {
+	repo, fdb := setupTxRepo(t)
+	expectedErr := errors.New("business rule failed")
+
+	err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+		if _, err := repo.Insert(ctx, insertParam("a")); err != nil {
+			return err
+		}
+		return expectedErr
+	})
+
+	assert.ErrorIs(t, err, expectedErr)
+	assert.Equal(t, []string{"BEGIN", "INSERT INTO MEMBER (NAME) VALUES (:1)", "ROLLBACK"}, fdb.statements())
+}
+
+func TestWithTransaction_Options(t *testing.T) {
+	repo, fdb := setupTxRepo(t)
+
+	err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+		return nil
+	}, service.WithIsolation(sql.LevelSerializable), service.ReadOnly())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BEGIN Serializable READ ONLY", "COMMIT"}, fdb.statements())
+}
+
+func TestWithTransaction_NestedSavepoint(t *testing.T) {
+	repo, fdb := setupTxRepo(t)
+	innerErr := errors.New("inner failed")
+
+	err := repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+		if _, err := repo.Insert(ctx, insertParam("outer")); err != nil {
+			return err
+		}
+
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			return repo.WithTransaction(ctx, func(ctx context.Context) error {
+				if _, err := repo.Insert(ctx, insertParam("inner")); err != nil {
+					return err
+				}
+				return innerErr
+			})
+		})
+		assert.ErrorIs(t, err, innerErr)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"INSERT INTO MEMBER (NAME) VALUES (:1)",
+		"SAVEPOINT SP_1",
+		"SAVEPOINT SP_2",
+		"INSERT INTO MEMBER (NAME) VALUES (:1)",
+		"ROLLBACK TO SAVEPOINT SP_2",
+		"ROLLBACK TO SAVEPOINT SP_1",
+		"COMMIT",
+	}, fdb.statements())
+}
+
+func TestWithTransaction_RollbackOnPanic(t *testing.T) {
+	repo, fdb := setupTxRepo(t)
+
+	assert.Panics(t, func() {
+		_ = repo.WithTransaction(context.Background(), func(ctx context.Context) error {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, fdb.statements())
+}
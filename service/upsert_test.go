@@ -0,0 +1,164 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+func upsertParam() service.SqlParameter {
+	return service.SqlParameter{
+		TableName: "MEMBER",
+		Values: []service.Value{
+			{Field: "CODE", Value: "M-1"},
+			{Field: "NAME", Value: "Charlie"},
+			{Field: "INFO", Value: "{}"},
+		},
+	}
+}
+
+func TestGenerateQueryUpsert(t *testing.T) {
+	repo := service.BaseRepository{}
+
+	query, args, err := repo.GenerateQueryUpsert(upsertParam(), nil, []string{"CODE"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, upsertBlock, query)
+	assert.Equal(t, []interface{}{sql.Named("v1", "M-1"), sql.Named("v2", "Charlie"), sql.Named("v3", "{}")}, args)
+}
+
+func TestGenerateQueryUpsert_Updates(t *testing.T) {
+	repo := service.BaseRepository{}
+	param := upsertParam()
+	param.Values = append(param.Values,
+		service.Value{Field: "CREATED_DATE", Value: "2024-01-01"},
+		service.Value{Field: "VERSION", Value: int64(1)})
+
+	query, args, err := repo.GenerateQueryUpsert(param, []service.Value{
+		{Field: "CODE", Value: "M-1"},
+		{Field: "NAME", Value: "Charlie"},
+		{Field: "INFO", Value: "{\"a\": 1}"},
+		{Field: "VERSION", Value: int64(7)},
+	}, []string{"CODE"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "BEGIN INSERT INTO MEMBER (CODE,NAME,INFO,CREATED_DATE,VERSION) VALUES (:v1,:v2,:v3,:v4,:v5); :inserted := 1; "+
+		"EXCEPTION WHEN DUP_VAL_ON_INDEX THEN UPDATE MEMBER t SET t.NAME = :v2,t.INFO = :v6,t.VERSION = t.VERSION + 1 WHERE t.CODE = :v1; "+
+		"IF SQL%ROWCOUNT = 0 THEN RAISE; END IF; :inserted := 0; END;", query,
+		"keys, the version and the values left out of updates are not set, the version is incremented")
+	assert.Len(t, args, 6)
+}
+
+func TestGenerateQueryUpsert_InvalidKeys(t *testing.T) {
+	repo := service.BaseRepository{}
+
+	_, _, err := repo.GenerateQueryUpsert(upsertParam(), nil, nil)
+	assert.Error(t, err)
+
+	_, _, err = repo.GenerateQueryUpsert(upsertParam(), nil, []string{"EMAIL"})
+	assert.EqualError(t, err, "upsert MEMBER: key field EMAIL has no value")
+}
+
+// upsertBlock is the statement Upsert runs for upsertParam keyed on CODE
+const upsertBlock = "BEGIN INSERT INTO MEMBER (CODE,NAME,INFO) VALUES (:v1,:v2,:v3); :inserted := 1; " +
+	"EXCEPTION WHEN DUP_VAL_ON_INDEX THEN UPDATE MEMBER t SET t.NAME = :v2,t.INFO = :v3 WHERE t.CODE = :v1; " +
+	"IF SQL%ROWCOUNT = 0 THEN RAISE; END IF; :inserted := 0; END;"
+
+// expectUpsert sets the :inserted out bind of the upsert block to inserted
+func expectUpsert(mockMaster *MockMasterDB, query string, inserted int64) *mock.Call {
+	return mockMaster.On("ExecContext", mock.Anything, query, mock.Anything).Run(func(args mock.Arguments) {
+		queryArgs := args.Get(2).([]interface{})
+		out := queryArgs[len(queryArgs)-1].(sql.NamedArg).Value.(sql.Out)
+		*(out.Dest.(*int64)) = inserted
+	}).Return(mockResult{rowsAffected: 1}, nil)
+}
+
+func TestUpsert(t *testing.T) {
+	tests := []struct {
+		name         string
+		inserted     int64
+		wantInserted bool
+	}{
+		{name: "row inserted", inserted: 1, wantInserted: true},
+		{name: "row updated", inserted: 0, wantInserted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMaster := new(MockMasterDB)
+			repo := service.BaseRepository{MasterDB: mockMaster}
+			expectUpsert(mockMaster, upsertBlock, tt.inserted)
+
+			inserted, err := repo.Upsert(context.Background(), upsertParam(), nil, []string{"CODE"})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantInserted, inserted)
+			mockMaster.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpsert_OnlyKeys(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}
+	param := service.SqlParameter{TableName: "TAG", Values: []service.Value{{Field: "CODE", Value: "X"}}}
+	expectUpsert(mockMaster, "BEGIN INSERT INTO TAG (CODE) VALUES (:v1); :inserted := 1; "+
+		"EXCEPTION WHEN DUP_VAL_ON_INDEX THEN :inserted := 0; END;", 0)
+
+	inserted, err := repo.Upsert(context.Background(), param, nil, []string{"CODE"})
+
+	assert.NoError(t, err)
+	assert.False(t, inserted)
+	mockMaster.AssertExpectations(t)
+}
+
+// TestUpsert_ConcurrentDuplicate upserts the same new key twice at once: the database lets one insert while
+// the other waits on the unique key then takes the DUP_VAL_ON_INDEX handler, so exactly one reports inserted
+// and neither fails.
+func TestUpsert_ConcurrentDuplicate(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}
+	expectUpsert(mockMaster, upsertBlock, 1).Once()
+	expectUpsert(mockMaster, upsertBlock, 0).Once()
+
+	results := make(chan bool, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inserted, err := repo.Upsert(context.Background(), upsertParam(), nil, []string{"CODE"})
+			assert.NoError(t, err)
+			results <- inserted
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var inserts int
+	for inserted := range results {
+		if inserted {
+			inserts++
+		}
+	}
+	assert.Equal(t, 1, inserts)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestUpsert_OtherUniqueKey(t *testing.T) {
+	mockMaster := new(MockMasterDB)
+	repo := service.BaseRepository{MasterDB: mockMaster}
+	dup := errors.New("ORA-00001: unique constraint (MEMBER_NAME_UK) violated")
+	mockMaster.On("ExecContext", mock.Anything, upsertBlock, mock.Anything).Return(mockResult{}, dup)
+
+	_, err := repo.Upsert(context.Background(), upsertParam(), nil, []string{"CODE"})
+
+	assert.ErrorIs(t, err, dup)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"oracle.com/oracle/my-go-oracle-app/pkg/constants"
+)
+
+const (
+	VERSION_COLUMN  = "VERSION"
+	INITIAL_VERSION = 1
+)
+
+// ErrVersionConflict matches every VersionConflictError with errors.Is
+var ErrVersionConflict = errors.New("VERSION_CONFLICT")
+
+// VersionConflictError is returned by an update whose expected version no longer matches the row,
+// i.e. someone else updated it in between. CurrentVersion lets the client reload and retry.
+type VersionConflictError struct {
+	TableName       string
+	ExpectedVersion int64
+	CurrentVersion  int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: %s expected version %d, current version %d", ErrVersionConflict, e.TableName, e.ExpectedVersion, e.CurrentVersion)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// VersionConflictResponse is the payload returned along with HTTP 409
+type VersionConflictResponse struct {
+	CurrentVersion int64 `json:"currentVersion" example:"3"`
+}
+
+// WithVersionColumn returns a copy of r incrementing the VERSION of tables when it soft-deletes or restores
+// their rows, so an update based on the version read before fails with a VersionConflictError.
+func (r BaseRepository) WithVersionColumn(tables ...string) BaseRepository {
+	versioned := maps.Clone(r.versioned)
+	if versioned == nil {
+		versioned = map[string]bool{}
+	}
+	for _, table := range tables {
+		versioned[tableKey(table)] = true
+	}
+	r.versioned = versioned
+	return r
+}
+
+// versionConflict explains why an update guarded by sqlParameter.Version affected no row:
+// sql.ErrNoRows when the row is gone, VersionConflictError otherwise.
+// It reads from the master so the version is not behind a replica.
+func (r *BaseRepository) versionConflict(ctx context.Context, sqlParameter SqlParameter) error {
+	query := fmt.Sprintf("SELECT %s FROM %s", VERSION_COLUMN, sqlParameter.TableName)
+	conditional, args := r.GenerateConditional(sqlParameter)
+
+	var current int64
+	if err := r.GetOperationsMasterConn(ctx, &current, query+conditional, args...); err != nil {
+		return err
+	}
+	return &VersionConflictError{
+		TableName:       sqlParameter.TableName,
+		ExpectedVersion: sqlParameter.Version,
+		CurrentVersion:  current,
+	}
+}
+
+// versionCheck returns the SET clause incrementing the version and the condition matching the expected one
+func versionCheck(sqlParameter SqlParameter) (string, FilterParam) {
+	return fmt.Sprintf("%s=%s+1", VERSION_COLUMN, VERSION_COLUMN),
+		MakeFilterParam(VERSION_COLUMN, constants.EQUAL, sqlParameter.Version)
+}
@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"oracle.com/oracle/my-go-oracle-app/service"
+)
+
+const versionedUpdateQuery = "UPDATE PRODUCT SET CODE=:1,PRICE=:2,UPDATED_DATE=:3,VERSION=VERSION+1 WHERE ID = :4 AND NVL(IS_DELETED, '0') = :5 AND VERSION = :6"
+
+func TestRepository_UpdateById_Version(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+	ctx := context.Background()
+	p := &product{Code: "A2", BaseEntity: service.BaseEntity{Version: 3}}
+
+	mockMaster.On("ExecContext",
+		mock.Anything,
+		versionedUpdateQuery,
+		mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 6 && args[3] == int64(5) && args[5] == int64(3)
+		}),
+	).Return(mockResult{rowsAffected: 1}, nil)
+
+	rows, err := repo.UpdateById(ctx, 5, p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+	assert.Equal(t, int64(4), p.Version)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestRepository_UpdateById_VersionConflict(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+	ctx := context.Background()
+	p := &product{Code: "A2", BaseEntity: service.BaseEntity{Version: 3}}
+
+	mockMaster.On("ExecContext", mock.Anything, versionedUpdateQuery, mock.Anything).Return(mockResult{rowsAffected: 0}, nil)
+	mockMaster.On("GetContext",
+		mock.Anything,
+		mock.AnythingOfType("*int64"),
+		"SELECT VERSION FROM PRODUCT WHERE ID = :1 AND NVL(IS_DELETED, '0') = :2",
+		[]interface{}{int64(5), "0"},
+	).Run(func(args mock.Arguments) {
+		*args.Get(1).(*int64) = 4
+	}).Return(nil)
+
+	_, err := repo.UpdateById(ctx, 5, p)
+
+	var conflict *service.VersionConflictError
+	assert.ErrorIs(t, err, service.ErrVersionConflict)
+	assert.ErrorAs(t, err, &conflict)
+	assert.Equal(t, int64(3), conflict.ExpectedVersion)
+	assert.Equal(t, int64(4), conflict.CurrentVersion)
+	assert.Equal(t, int64(3), p.Version)
+	mockMaster.AssertExpectations(t)
+}
+
+func TestRepository_UpdateById_VersionNotFound(t *testing.T) {
+	repo, mockMaster, _ := setupProductRepo()
+	ctx := context.Background()
+	p := &product{Code: "A2", BaseEntity: service.BaseEntity{Version: 3}}
+
+	mockMaster.On("ExecContext", mock.Anything, versionedUpdateQuery, mock.Anything).Return(mockResult{rowsAffected: 0}, nil)
+	mockMaster.On("GetContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows)
+
+	_, err := repo.UpdateById(ctx, 5, p)
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	mockMaster.AssertExpectations(t)
+}
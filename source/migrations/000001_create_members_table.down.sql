@@ -1,18 +1 @@
-
-
-CREATE TABLE MEMBER (
-    ID          NUMBER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
-    NAME        VARCHAR2(100) NOT NULL,
-    INFO        VARCHAR2(4000) 
-                CONSTRAINT INFO_IS_JSON CHECK (INFO IS JSON)
-);
-
--- Index the 'INFO' column for faster JSON querying
-CREATE INDEX MEMBER_INFO_JSON_IDX ON MEMBER (INFO) INDEXTYPE IS CTXSYS.CONTEXT;
-
-INSERT INTO MEMBER (NAME, INFO) VALUES (
-    'Charlie Brown',
-    '{"address": "789 Tree House", "salary": 45000, "age": 32}'
-);
-COMMIT;
-
+DROP TABLE MEMBER;
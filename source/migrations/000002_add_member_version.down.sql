@@ -0,0 +1 @@
+ALTER TABLE MEMBER DROP COLUMN VERSION;
@@ -0,0 +1 @@
+ALTER TABLE MEMBER ADD (VERSION NUMBER(19) DEFAULT 1 NOT NULL);
@@ -0,0 +1 @@
+DROP TABLE MEMBER_HISTORY;
@@ -0,0 +1,11 @@
+CREATE TABLE MEMBER_HISTORY (
+    ID           NUMBER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    MEMBER_ID    NUMBER NOT NULL,
+    OLD_VALUE    CLOB CONSTRAINT MEMBER_HISTORY_OLD_IS_JSON CHECK (OLD_VALUE IS JSON),
+    NEW_VALUE    CLOB CONSTRAINT MEMBER_HISTORY_NEW_IS_JSON CHECK (NEW_VALUE IS JSON),
+    ACTION       VARCHAR2(20) NOT NULL,
+    CREATED_BY   VARCHAR2(100) NOT NULL,
+    CREATED_DATE TIMESTAMP NOT NULL
+);
+
+CREATE INDEX MEMBER_HISTORY_MEMBER_IDX ON MEMBER_HISTORY (MEMBER_ID, ID);
@@ -0,0 +1 @@
+DROP TABLE MIGRATION_FLAG;
@@ -0,0 +1,12 @@
+-- MIGRATION_FLAG records the checkpoints of the backfills, one row per chunk committed:
+-- LAST_UPDATE_ID is the greatest primary key of TABLE_NAME rewritten by the backfill ACTION
+CREATE TABLE MIGRATION_FLAG (
+    ID             NUMBER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    TABLE_NAME     VARCHAR2(128) NOT NULL,
+    ACTION         VARCHAR2(100) NOT NULL,
+    LAST_UPDATE_ID NUMBER NOT NULL,
+    "LIMIT"        NUMBER NOT NULL,
+    CREATED_DATE   TIMESTAMP NOT NULL
+);
+
+CREATE INDEX MIGRATION_FLAG_ACTION_IDX ON MIGRATION_FLAG (TABLE_NAME, ACTION, ID);
@@ -0,0 +1,16 @@
+-- drops only the columns the up migration added, as recorded into MIGRATION_FLAG: those a database already
+-- had before are kept with their data. The reshaped seed INFO is left as it is.
+BEGIN
+    FOR added IN (SELECT ID, SUBSTR(ACTION, LENGTH('000005_ADD_COLUMN:') + 1) AS COLUMN_NAME
+                  FROM MIGRATION_FLAG
+                  WHERE TABLE_NAME = 'MEMBER' AND ACTION LIKE '000005\_ADD\_COLUMN:%' ESCAPE '\'
+                  ORDER BY ID DESC) LOOP
+        FOR existing IN (SELECT COLUMN_NAME FROM USER_TAB_COLUMNS
+                         WHERE TABLE_NAME = 'MEMBER' AND COLUMN_NAME = added.COLUMN_NAME) LOOP
+            EXECUTE IMMEDIATE 'ALTER TABLE MEMBER DROP COLUMN ' || existing.COLUMN_NAME;
+        END LOOP;
+        DELETE FROM MIGRATION_FLAG WHERE ID = added.ID;
+        COMMIT;
+    END LOOP;
+END;
+/
@@ -0,0 +1,29 @@
+-- MEMBER as the code reads and writes it, 000001 only created ID, NAME and INFO. The columns a database
+-- created before the migrations already has are left as they are; the ones added are recorded into
+-- MIGRATION_FLAG as ACTION 000005_ADD_COLUMN:<column>, the down migration dropping only those.
+DECLARE
+    PROCEDURE add_column(p_column VARCHAR2, p_definition VARCHAR2) IS
+        l_count NUMBER;
+    BEGIN
+        SELECT COUNT(*) INTO l_count FROM USER_TAB_COLUMNS WHERE TABLE_NAME = 'MEMBER' AND COLUMN_NAME = p_column;
+        IF l_count = 0 THEN
+            EXECUTE IMMEDIATE 'ALTER TABLE MEMBER ADD (' || p_column || ' ' || p_definition || ')';
+            INSERT INTO MIGRATION_FLAG (TABLE_NAME, ACTION, LAST_UPDATE_ID, "LIMIT", CREATED_DATE)
+            VALUES ('MEMBER', '000005_ADD_COLUMN:' || p_column, 0, 0, SYSTIMESTAMP);
+            COMMIT;
+        END IF;
+    END;
+BEGIN
+    add_column('DETAIL', 'BLOB CONSTRAINT MEMBER_DETAIL_IS_JSON CHECK (DETAIL IS JSON)');
+    add_column('POLICY', 'VARCHAR2(4000) CONSTRAINT MEMBER_POLICY_IS_JSON CHECK (POLICY IS JSON)');
+    add_column('CREATED_DATE', 'TIMESTAMP DEFAULT SYSTIMESTAMP NOT NULL');
+    add_column('UPDATED_DATE', 'TIMESTAMP');
+    add_column('IS_DELETED', 'VARCHAR2(1) DEFAULT ''0'' NOT NULL');
+END;
+/
+
+-- the seed row of 000001 in the shape of MemberInfo, its address was a bare string
+UPDATE MEMBER
+SET INFO = '{"address": {"primary": "789 Tree House", "secondary": ""}, "salary": 45000, "age": 32}'
+WHERE NAME = 'Charlie Brown' AND JSON_VALUE(INFO, '$.address') = '789 Tree House';
+COMMIT;
@@ -0,0 +1,9 @@
+// Package migrations embeds the numbered schema migrations run by infra/database/migrate
+package migrations
+
+import "embed"
+
+// FS holds the NNNNNN_name.up.sql and NNNNNN_name.down.sql files of this directory
+//
+//go:embed *.sql
+var FS embed.FS